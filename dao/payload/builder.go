@@ -0,0 +1,175 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/settings"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// A setting change to include in a Protocol DAO proposal
+type SettingChangeRequest struct {
+	ContractName string      `json:"contractName"`
+	Path         string      `json:"path"`
+	Value        interface{} `json:"value"` // *big.Int, bool or common.Address, matching the setting's type
+}
+
+// A setting a proposal changes, with its current value, the value the proposal requests, and its value after execution
+type SettingDryRun struct {
+	Current   settings.SettingValue `json:"current"` // Unavailable if the setting isn't registered
+	Requested settings.SettingValue `json:"requested"`
+	Executed  settings.SettingValue `json:"executed"` // Read from the setting's getter after the simulated execution
+	Changed   bool                  `json:"changed"`  // Whether execution changes the value
+	Applied   bool                  `json:"applied"`  // Whether the value after execution is the requested one, e.g. not clamped
+}
+
+// The result of simulating a Protocol DAO proposal's execution
+type ProposalDryRun struct {
+	Payload  []byte                 `json:"payload"`
+	Decoded  DecodedProposalPayload `json:"decoded"`
+	Settings []SettingDryRun        `json:"settings"`
+}
+
+// Check setting changes against the settings registry, encode them into a Protocol DAO proposal payload, and dry-run it
+func BuildSettingsProposal(rp *rocketpool.RocketPool, changes []SettingChangeRequest) (ProposalDryRun, error) {
+	payload, err := BuildSettingsPayload(rp, changes, nil)
+	if err != nil {
+		return ProposalDryRun{}, err
+	}
+	return DryRunProposalPayload(rp, payload)
+}
+
+// Check setting changes against the settings registry and encode them into a Protocol DAO proposal payload
+func BuildSettingsPayload(rp *rocketpool.RocketPool, changes []SettingChangeRequest, opts *bind.CallOpts) ([]byte, error) {
+	daoContractAbi, err := rp.GetABI(ProtocolDaoProposalsContractName, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting '%s' DAO contract ABI: %w", ProtocolDaoProposalsContractName, err)
+	}
+	return BuildSettingsPayloadWithABI(daoContractAbi, changes)
+}
+
+// Check setting changes against the settings registry and encode them with the ABI of the Protocol DAO proposals contract.
+// A single change uses the payload method for the setting's type; multiple changes use proposalSettingMulti.
+func BuildSettingsPayloadWithABI(daoContractAbi *abi.ABI, changes []SettingChangeRequest) ([]byte, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("a settings proposal needs at least one change")
+	}
+
+	// Check the changes
+	contractNames := make([]string, len(changes))
+	settingPaths := make([]string, len(changes))
+	settingTypes := make([]types.ProposalSettingType, len(changes))
+	values := make([]any, len(changes))
+	seen := map[string]bool{}
+	for i, change := range changes {
+		setting, exists := settings.GetSettingDefinition(change.ContractName, change.Path)
+		if !exists {
+			return nil, fmt.Errorf("setting %s is not registered for contract %s", change.Path, change.ContractName)
+		}
+		if setting.Dao != settings.SettingDao_Protocol {
+			return nil, fmt.Errorf("setting %s can't be changed by the Protocol DAO", change.Path)
+		}
+		key := change.ContractName + "/" + change.Path
+		if seen[key] {
+			return nil, fmt.Errorf("setting %s is changed more than once", change.Path)
+		}
+		seen[key] = true
+		if err := settings.CheckSettingValue(setting, change.Value); err != nil {
+			return nil, err
+		}
+		contractNames[i] = change.ContractName
+		settingPaths[i] = change.Path
+		settingTypes[i] = setting.Type
+		values[i] = change.Value
+	}
+
+	// Encode a single change
+	if len(changes) == 1 {
+		method := "proposalSettingUint"
+		switch settingTypes[0] {
+		case types.ProposalSettingType_Bool:
+			method = "proposalSettingBool"
+		case types.ProposalSettingType_Address:
+			method = "proposalSettingAddress"
+		}
+		payload, err := daoContractAbi.Pack(method, contractNames[0], settingPaths[0], values[0])
+		if err != nil {
+			return nil, fmt.Errorf("error encoding setting proposal payload: %w", err)
+		}
+		return payload, nil
+	}
+
+	// Encode multiple changes
+	encodedValues, err := protocol.AbiEncodeMultiValues(settingTypes, values)
+	if err != nil {
+		return nil, fmt.Errorf("error ABI encoding values: %w", err)
+	}
+	payload, err := daoContractAbi.Pack("proposalSettingMulti", contractNames, settingPaths, settingTypes, encodedValues)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding multi-set proposal payload: %w", err)
+	}
+	return payload, nil
+}
+
+// Simulate a Protocol DAO proposal payload's execution with eth_call as if rocketDAOProtocolProposal executed it,
+// and get the current, requested and post-execution value of each affected setting.
+// Post-execution values are read from the settings' getters with the storage the traced execution writes applied,
+// so the client must implement rocketpool.StateOverrideClient (e.g. rocketpool.EIP1898Client on a node with debug_traceCall).
+// If the execution would revert, the error wraps a *rocketpool.RevertError.
+func DryRunProposalPayload(rp *rocketpool.RocketPool, payload []byte) (ProposalDryRun, error) {
+	decoded, err := DecodeProposalPayload(rp, ProtocolDaoProposalsContractName, payload, nil)
+	if err != nil {
+		return ProposalDryRun{}, err
+	}
+	if err := protocol.SimulateProposalExecution(rp, payload); err != nil {
+		return ProposalDryRun{}, fmt.Errorf("error simulating proposal execution: %w", err)
+	}
+
+	// Read the settings as the execution would leave them
+	storage, err := protocol.TraceProposalExecution(rp, payload)
+	if err != nil {
+		return ProposalDryRun{}, fmt.Errorf("error tracing proposal execution: %w", err)
+	}
+	executed := make([]settings.SettingValue, len(decoded.Settings))
+	for i, setting := range decoded.Settings {
+		executed[i] = settings.SettingValue{Setting: setting.New.Setting}
+		if !setting.Known {
+			continue
+		}
+		executed[i], err = settings.GetSettingWithStorage(rp, setting.New.Setting.ContractName, setting.New.Setting.Path, storage, nil)
+		if err != nil {
+			return ProposalDryRun{}, fmt.Errorf("error getting value of setting %s after execution: %w", setting.New.Setting.Path, err)
+		}
+	}
+
+	return ProposalDryRun{
+		Payload:  payload,
+		Decoded:  decoded,
+		Settings: GetSettingDryRuns(decoded, executed),
+	}, nil
+}
+
+// Get the current, requested and post-execution values of the settings a decoded payload changes.
+// The current values must have been loaded, e.g. by DecodeProposalPayload, and executed must have a value per setting.
+func GetSettingDryRuns(decoded DecodedProposalPayload, executed []settings.SettingValue) []SettingDryRun {
+	dryRuns := make([]SettingDryRun, len(decoded.Settings))
+	for i, setting := range decoded.Settings {
+		current := settings.SettingValue{Setting: setting.New.Setting}
+		if setting.Current != nil {
+			current = *setting.Current
+		}
+		dryRuns[i] = SettingDryRun{
+			Current:   current,
+			Requested: setting.New,
+			Executed:  executed[i],
+			Changed:   !current.Equals(executed[i]),
+			Applied:   executed[i].Equals(setting.New),
+		}
+	}
+	return dryRuns
+}
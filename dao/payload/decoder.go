@@ -0,0 +1,535 @@
+package payload
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/dao/security"
+	"github.com/rocket-pool/rocketpool-go/dao/trustednode"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/settings"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// The proposals contracts whose payloads can be decoded
+const (
+	ProtocolDaoProposalsContractName string = "rocketDAOProtocolProposals"
+	OracleDaoProposalsContractName   string = "rocketDAONodeTrustedProposals"
+	SecurityDaoProposalsContractName string = "rocketDAOSecurityProposals"
+	multiValueLength                 int    = 32
+)
+
+// The kind of action a proposal payload performs
+type ProposalPayloadKind string
+
+const (
+	ProposalPayloadKind_Setting                ProposalPayloadKind = "setting"
+	ProposalPayloadKind_RewardsClaimers        ProposalPayloadKind = "rewardsClaimers"
+	ProposalPayloadKind_TreasuryOneTimeSpend   ProposalPayloadKind = "treasuryOneTimeSpend"
+	ProposalPayloadKind_TreasuryNewContract    ProposalPayloadKind = "treasuryNewContract"
+	ProposalPayloadKind_TreasuryUpdateContract ProposalPayloadKind = "treasuryUpdateContract"
+	ProposalPayloadKind_SecurityInvite         ProposalPayloadKind = "securityInvite"
+	ProposalPayloadKind_SecurityKick           ProposalPayloadKind = "securityKick"
+	ProposalPayloadKind_SecurityReplace        ProposalPayloadKind = "securityReplace"
+	ProposalPayloadKind_OracleDaoInvite        ProposalPayloadKind = "oracleDaoInvite"
+	ProposalPayloadKind_OracleDaoLeave         ProposalPayloadKind = "oracleDaoLeave"
+	ProposalPayloadKind_OracleDaoReplace       ProposalPayloadKind = "oracleDaoReplace"
+	ProposalPayloadKind_OracleDaoKick          ProposalPayloadKind = "oracleDaoKick"
+	ProposalPayloadKind_Upgrade                ProposalPayloadKind = "upgrade"
+)
+
+// A setting change in a proposal payload
+type ProposedSetting struct {
+	Known   bool                   `json:"known"` // False if the setting isn't in the settings registry, so its unit is a guess
+	New     settings.SettingValue  `json:"new"`
+	Current *settings.SettingValue `json:"current,omitempty"`
+}
+
+// A change to the RPL rewards split, as fractions scaled by 1e18
+type ProposedRewardsClaimers struct {
+	OracleDaoPercentage    *big.Int                         `json:"oracleDaoPercentage"`
+	ProtocolDaoPercentage  *big.Int                         `json:"protocolDaoPercentage"`
+	NodeOperatorPercentage *big.Int                         `json:"nodeOperatorPercentage"`
+	CurrentRewardsClaimers *psettings.RplRewardsPercentages `json:"currentRewardsClaimers,omitempty"`
+}
+
+// An RPL payment from the Protocol DAO treasury
+type ProposedTreasurySpend struct {
+	InvoiceID    string         `json:"invoiceId,omitempty"`    // One-time spends
+	ContractName string         `json:"contractName,omitempty"` // Recurring spends
+	Recipient    common.Address `json:"recipient"`
+	Amount       *big.Int       `json:"amount"` // RPL wei, per period for recurring spends
+
+	// Recurring spends only; new contracts also have a start time
+	PeriodLength    time.Duration `json:"periodLength,omitempty"`
+	StartTime       time.Time     `json:"startTime,omitempty"`
+	NumberOfPeriods uint64        `json:"numberOfPeriods,omitempty"`
+}
+
+// Get the total amount paid over all of the spend's periods
+func (s ProposedTreasurySpend) GetTotalAmount() *big.Int {
+	if s.NumberOfPeriods == 0 {
+		return big.NewInt(0).Set(s.Amount)
+	}
+	return big.NewInt(0).Mul(s.Amount, big.NewInt(0).SetUint64(s.NumberOfPeriods))
+}
+
+// A DAO member affected by a proposal payload
+type ProposedMember struct {
+	Address common.Address `json:"address"`
+	ID      string         `json:"id,omitempty"`
+	Url     string         `json:"url,omitempty"`
+
+	// The member's current state
+	IsMember  bool   `json:"isMember"`
+	CurrentID string `json:"currentId,omitempty"`
+}
+
+// A network contract upgrade
+type ProposedUpgrade struct {
+	UpgradeType     string          `json:"upgradeType"`
+	ContractName    string          `json:"contractName"`
+	ContractAddress common.Address  `json:"contractAddress"`
+	CompressedAbi   string          `json:"compressedAbi"`
+	CurrentAddress  *common.Address `json:"currentAddress,omitempty"`
+}
+
+// A proposal payload decoded into typed values.
+// Only the fields for the payload's kind are set.
+type DecodedProposalPayload struct {
+	DaoName string              `json:"daoName"`
+	Kind    ProposalPayloadKind `json:"kind"`
+	Method  string              `json:"method"`
+
+	Settings        []ProposedSetting        `json:"settings,omitempty"`
+	RewardsClaimers *ProposedRewardsClaimers `json:"rewardsClaimers,omitempty"`
+	TreasurySpend   *ProposedTreasurySpend   `json:"treasurySpend,omitempty"`
+	Members         []ProposedMember         `json:"members,omitempty"`     // Invited, leaving, kicked or replaced
+	Replacement     *ProposedMember          `json:"replacement,omitempty"` // Replace proposals only
+	RplFine         *big.Int                 `json:"rplFine,omitempty"`     // Oracle DAO kick proposals only
+	Upgrade         *ProposedUpgrade         `json:"upgrade,omitempty"`
+}
+
+// Decode a proposal payload for a DAO's proposals contract and load the current values it would change
+func DecodeProposalPayload(rp *rocketpool.RocketPool, daoName string, payload []byte, opts *bind.CallOpts) (DecodedProposalPayload, error) {
+	daoContractAbi, err := rp.GetABI(daoName, opts)
+	if err != nil {
+		return DecodedProposalPayload{}, fmt.Errorf("error getting '%s' DAO contract ABI: %w", daoName, err)
+	}
+	decoded, err := DecodeProposalPayloadWithABI(daoContractAbi, daoName, payload)
+	if err != nil {
+		return DecodedProposalPayload{}, err
+	}
+	if err := loadCurrentValues(rp, &decoded, opts); err != nil {
+		return DecodedProposalPayload{}, err
+	}
+	return decoded, nil
+}
+
+// Decode a proposal payload with the ABI of a DAO's proposals contract, without loading current values
+func DecodeProposalPayloadWithABI(daoContractAbi *abi.ABI, daoName string, payload []byte) (DecodedProposalPayload, error) {
+
+	// Get proposal payload method & argument values
+	if len(payload) < 4 {
+		return DecodedProposalPayload{}, fmt.Errorf("proposal payload is too short")
+	}
+	method, err := daoContractAbi.MethodById(payload)
+	if err != nil {
+		return DecodedProposalPayload{}, fmt.Errorf("error getting proposal payload method: %w", err)
+	}
+	args, err := method.Inputs.UnpackValues(payload[4:])
+	if err != nil {
+		return DecodedProposalPayload{}, fmt.Errorf("error getting proposal payload arguments: %w", err)
+	}
+	a := &argReader{method: method.RawName, args: args}
+
+	// Decode the arguments
+	decoded := DecodedProposalPayload{
+		DaoName: daoName,
+		Method:  method.RawName,
+	}
+	switch method.RawName {
+	case "proposalSettingUint", "proposalSettingBool", "proposalSettingAddress":
+		decoded.Kind = ProposalPayloadKind_Setting
+		contractName, path := a.string(0), a.string(1)
+		var value settings.SettingValue
+		switch method.RawName {
+		case "proposalSettingUint":
+			value = settings.SettingValue{Uint: a.uint(2)}
+		case "proposalSettingBool":
+			value = settings.SettingValue{Bool: a.bool(2)}
+		default:
+			value = settings.SettingValue{Address: a.address(2)}
+		}
+		if a.err == nil {
+			decoded.Settings = []ProposedSetting{newProposedSetting(daoName, contractName, path, getSettingType(method.RawName), value)}
+		}
+
+	case "proposalSettingMulti":
+		decoded.Kind = ProposalPayloadKind_Setting
+		contractNames, paths, settingTypes, values := a.strings(0), a.strings(1), a.uint8s(2), a.bytesSlice(3)
+		if a.err != nil {
+			break
+		}
+		if len(paths) != len(contractNames) || len(settingTypes) != len(contractNames) || len(values) != len(contractNames) {
+			return DecodedProposalPayload{}, fmt.Errorf("multi-setting proposal has mismatched argument lengths")
+		}
+		decoded.Settings = make([]ProposedSetting, len(contractNames))
+		for i := range contractNames {
+			settingType := types.ProposalSettingType(settingTypes[i])
+			value, err := decodeMultiValue(settingType, values[i])
+			if err != nil {
+				return DecodedProposalPayload{}, fmt.Errorf("error decoding value %d of multi-setting proposal: %w", i, err)
+			}
+			decoded.Settings[i] = newProposedSetting(daoName, contractNames[i], paths[i], settingType, value)
+		}
+
+	case "proposalSettingRewardsClaimers":
+		decoded.Kind = ProposalPayloadKind_RewardsClaimers
+		decoded.RewardsClaimers = &ProposedRewardsClaimers{
+			OracleDaoPercentage:    a.uint(0),
+			ProtocolDaoPercentage:  a.uint(1),
+			NodeOperatorPercentage: a.uint(2),
+		}
+
+	case "proposalTreasuryOneTimeSpend":
+		decoded.Kind = ProposalPayloadKind_TreasuryOneTimeSpend
+		decoded.TreasurySpend = &ProposedTreasurySpend{
+			InvoiceID: a.string(0),
+			Recipient: a.address(1),
+			Amount:    a.uint(2),
+		}
+
+	case "proposalTreasuryNewContract":
+		decoded.Kind = ProposalPayloadKind_TreasuryNewContract
+		decoded.TreasurySpend = &ProposedTreasurySpend{
+			ContractName:    a.string(0),
+			Recipient:       a.address(1),
+			Amount:          a.uint(2),
+			PeriodLength:    time.Duration(a.uint64(3)) * time.Second,
+			StartTime:       time.Unix(int64(a.uint64(4)), 0),
+			NumberOfPeriods: a.uint64(5),
+		}
+
+	case "proposalTreasuryUpdateContract":
+		decoded.Kind = ProposalPayloadKind_TreasuryUpdateContract
+		decoded.TreasurySpend = &ProposedTreasurySpend{
+			ContractName:    a.string(0),
+			Recipient:       a.address(1),
+			Amount:          a.uint(2),
+			PeriodLength:    time.Duration(a.uint64(3)) * time.Second,
+			NumberOfPeriods: a.uint64(4),
+		}
+
+	case "proposalSecurityInvite":
+		decoded.Kind = ProposalPayloadKind_SecurityInvite
+		decoded.Members = []ProposedMember{{ID: a.string(0), Address: a.address(1)}}
+
+	case "proposalSecurityKick":
+		decoded.Kind = ProposalPayloadKind_SecurityKick
+		decoded.Members = []ProposedMember{{Address: a.address(0)}}
+
+	case "proposalSecurityKickMulti", "proposalKickMulti":
+		decoded.Kind = ProposalPayloadKind_SecurityKick
+		for _, address := range a.addresses(0) {
+			decoded.Members = append(decoded.Members, ProposedMember{Address: address})
+		}
+
+	case "proposalSecurityReplace":
+		decoded.Kind = ProposalPayloadKind_SecurityReplace
+		decoded.Members = []ProposedMember{{Address: a.address(0)}}
+		decoded.Replacement = &ProposedMember{ID: a.string(1), Address: a.address(2)}
+
+	case "proposalInvite":
+		if daoName == SecurityDaoProposalsContractName {
+			decoded.Kind = ProposalPayloadKind_SecurityInvite
+			decoded.Members = []ProposedMember{{ID: a.string(0), Address: a.address(1)}}
+			break
+		}
+		decoded.Kind = ProposalPayloadKind_OracleDaoInvite
+		decoded.Members = []ProposedMember{{ID: a.string(0), Url: a.string(1), Address: a.address(2)}}
+
+	case "proposalLeave":
+		decoded.Kind = ProposalPayloadKind_OracleDaoLeave
+		decoded.Members = []ProposedMember{{Address: a.address(0)}}
+
+	case "proposalReplace":
+		if daoName == SecurityDaoProposalsContractName {
+			decoded.Kind = ProposalPayloadKind_SecurityReplace
+			decoded.Members = []ProposedMember{{Address: a.address(0)}}
+			decoded.Replacement = &ProposedMember{ID: a.string(1), Address: a.address(2)}
+			break
+		}
+		decoded.Kind = ProposalPayloadKind_OracleDaoReplace
+		decoded.Members = []ProposedMember{{Address: a.address(0)}}
+		decoded.Replacement = &ProposedMember{ID: a.string(1), Url: a.string(2), Address: a.address(3)}
+
+	case "proposalKick":
+		if daoName == SecurityDaoProposalsContractName {
+			decoded.Kind = ProposalPayloadKind_SecurityKick
+			decoded.Members = []ProposedMember{{Address: a.address(0)}}
+			break
+		}
+		decoded.Kind = ProposalPayloadKind_OracleDaoKick
+		decoded.Members = []ProposedMember{{Address: a.address(0)}}
+		decoded.RplFine = a.uint(1)
+
+	case "proposalUpgrade":
+		decoded.Kind = ProposalPayloadKind_Upgrade
+		decoded.Upgrade = &ProposedUpgrade{
+			UpgradeType:     a.string(0),
+			ContractName:    a.string(1),
+			CompressedAbi:   a.string(2),
+			ContractAddress: a.address(3),
+		}
+
+	default:
+		return DecodedProposalPayload{}, fmt.Errorf("unknown proposal payload method %s", method.RawName)
+	}
+	if a.err != nil {
+		return DecodedProposalPayload{}, a.err
+	}
+	return decoded, nil
+
+}
+
+// Load the current values of everything a decoded payload changes
+func loadCurrentValues(rp *rocketpool.RocketPool, decoded *DecodedProposalPayload, opts *bind.CallOpts) error {
+
+	// Settings
+	for i, setting := range decoded.Settings {
+		if !setting.Known {
+			continue
+		}
+		current, err := settings.GetSetting(rp, setting.New.Setting.ContractName, setting.New.Setting.Path, opts)
+		if err != nil {
+			return fmt.Errorf("error getting current value of setting %s: %w", setting.New.Setting.Path, err)
+		}
+		decoded.Settings[i].Current = &current
+	}
+
+	// Rewards split
+	if decoded.RewardsClaimers != nil {
+		current, err := psettings.GetRewardsPercentages(rp, opts)
+		if err != nil {
+			return fmt.Errorf("error getting current rewards percentages: %w", err)
+		}
+		decoded.RewardsClaimers.CurrentRewardsClaimers = &current
+	}
+
+	// Members
+	getMemberExists, getMemberID := trustednode.GetMemberExists, trustednode.GetMemberID
+	if decoded.DaoName == ProtocolDaoProposalsContractName || decoded.DaoName == SecurityDaoProposalsContractName {
+		getMemberExists, getMemberID = security.GetMemberExists, security.GetMemberID
+	}
+	members := make([]*ProposedMember, 0, len(decoded.Members)+1)
+	for i := range decoded.Members {
+		members = append(members, &decoded.Members[i])
+	}
+	if decoded.Replacement != nil {
+		members = append(members, decoded.Replacement)
+	}
+	for _, member := range members {
+		exists, err := getMemberExists(rp, member.Address, opts)
+		if err != nil {
+			return fmt.Errorf("error getting membership of %s: %w", member.Address.Hex(), err)
+		}
+		member.IsMember = exists
+		if !exists {
+			continue
+		}
+		member.CurrentID, err = getMemberID(rp, member.Address, opts)
+		if err != nil {
+			return fmt.Errorf("error getting member ID of %s: %w", member.Address.Hex(), err)
+		}
+	}
+
+	// Upgraded contract
+	if decoded.Upgrade != nil && strings.HasPrefix(decoded.Upgrade.UpgradeType, "upgrade") {
+		current, err := rp.GetAddress(decoded.Upgrade.ContractName, opts)
+		if err != nil {
+			return fmt.Errorf("error getting current address of contract %s: %w", decoded.Upgrade.ContractName, err)
+		}
+		decoded.Upgrade.CurrentAddress = current
+	}
+
+	return nil
+
+}
+
+// Create a setting change, using the registry to get its unit
+func newProposedSetting(daoName string, contractName string, path string, settingType types.ProposalSettingType, value settings.SettingValue) ProposedSetting {
+	var definition settings.Setting
+	var known bool
+	if daoName == SecurityDaoProposalsContractName {
+		// The security council passes the setting's namespace instead of its contract
+		definition, known = settings.GetSecurityCouncilSettingDefinition(contractName, path)
+	} else {
+		definition, known = settings.GetSettingDefinition(contractName, path)
+	}
+	if !known || definition.Type != settingType {
+		known = false
+		definition = settings.Setting{
+			Dao:          settings.SettingDao_Protocol,
+			ContractName: contractName,
+			Path:         path,
+			Type:         settingType,
+			Unit:         settings.SettingUnit_None,
+		}
+		if daoName == OracleDaoProposalsContractName {
+			definition.Dao = settings.SettingDao_OracleDao
+		}
+		if settingType == types.ProposalSettingType_Uint256 {
+			definition.Unit = settings.SettingUnit_Count
+		}
+	}
+	value.Setting = definition
+	value.Available = true
+	return ProposedSetting{
+		Known: known,
+		New:   value,
+	}
+}
+
+// Get the setting type changed by a single-setting payload method
+func getSettingType(method string) types.ProposalSettingType {
+	switch method {
+	case "proposalSettingBool":
+		return types.ProposalSettingType_Bool
+	case "proposalSettingAddress":
+		return types.ProposalSettingType_Address
+	default:
+		return types.ProposalSettingType_Uint256
+	}
+}
+
+// Decode a value encoded by a multi-setting proposal, which is a single ABI word
+func decodeMultiValue(settingType types.ProposalSettingType, data []byte) (settings.SettingValue, error) {
+	if len(data) != multiValueLength {
+		return settings.SettingValue{}, fmt.Errorf("value is %d bytes instead of %d", len(data), multiValueLength)
+	}
+	word := big.NewInt(0).SetBytes(data)
+	switch settingType {
+	case types.ProposalSettingType_Uint256:
+		return settings.SettingValue{Uint: word}, nil
+	case types.ProposalSettingType_Bool:
+		if word.Cmp(common.Big1) > 0 {
+			return settings.SettingValue{}, fmt.Errorf("value %s is not a bool", word.String())
+		}
+		return settings.SettingValue{Bool: word.Sign() > 0}, nil
+	case types.ProposalSettingType_Address:
+		if word.BitLen() > common.AddressLength*8 {
+			return settings.SettingValue{}, fmt.Errorf("value 0x%x is not an address", data)
+		}
+		return settings.SettingValue{Address: common.BytesToAddress(data)}, nil
+	default:
+		return settings.SettingValue{}, fmt.Errorf("unknown proposal setting type [%v]", settingType)
+	}
+}
+
+// Reads typed payload arguments, keeping the first error
+type argReader struct {
+	method string
+	args   []interface{}
+	err    error
+}
+
+func (a *argReader) get(index int) interface{} {
+	if a.err != nil {
+		return nil
+	}
+	if index >= len(a.args) {
+		a.err = fmt.Errorf("proposal payload method %s is missing argument %d", a.method, index)
+		return nil
+	}
+	return a.args[index]
+}
+
+func (a *argReader) fail(index int, typeName string) {
+	if a.err == nil {
+		a.err = fmt.Errorf("argument %d of proposal payload method %s is not a %s", index, a.method, typeName)
+	}
+}
+
+func (a *argReader) string(index int) string {
+	value, ok := a.get(index).(string)
+	if !ok {
+		a.fail(index, "string")
+	}
+	return value
+}
+
+func (a *argReader) bool(index int) bool {
+	value, ok := a.get(index).(bool)
+	if !ok {
+		a.fail(index, "bool")
+	}
+	return value
+}
+
+func (a *argReader) address(index int) common.Address {
+	value, ok := a.get(index).(common.Address)
+	if !ok {
+		a.fail(index, "address")
+	}
+	return value
+}
+
+func (a *argReader) uint(index int) *big.Int {
+	value, ok := a.get(index).(*big.Int)
+	if !ok {
+		a.fail(index, "uint256")
+	}
+	return value
+}
+
+func (a *argReader) uint64(index int) uint64 {
+	value := a.uint(index)
+	if value == nil {
+		return 0
+	}
+	if !value.IsUint64() {
+		a.fail(index, "uint64")
+		return 0
+	}
+	return value.Uint64()
+}
+
+func (a *argReader) strings(index int) []string {
+	value, ok := a.get(index).([]string)
+	if !ok {
+		a.fail(index, "string[]")
+	}
+	return value
+}
+
+func (a *argReader) uint8s(index int) []uint8 {
+	value, ok := a.get(index).([]uint8)
+	if !ok {
+		a.fail(index, "uint8[]")
+	}
+	return value
+}
+
+func (a *argReader) bytesSlice(index int) [][]byte {
+	value, ok := a.get(index).([][]byte)
+	if !ok {
+		a.fail(index, "bytes[]")
+	}
+	return value
+}
+
+func (a *argReader) addresses(index int) []common.Address {
+	value, ok := a.get(index).([]common.Address)
+	if !ok {
+		a.fail(index, "address[]")
+	}
+	return value
+}
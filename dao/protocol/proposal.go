@@ -1,7 +1,6 @@
 package protocol
 
 import (
-	"context"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -9,7 +8,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -583,7 +581,7 @@ func estimateProposalGas(rp *rocketpool.RocketPool, message string, payload []by
 	if err != nil {
 		return rocketpool.GasInfo{}, err
 	}
-	err = simulateProposalExecution(rp, payload)
+	err = SimulateProposalExecution(rp, payload)
 	if err != nil {
 		return rocketpool.GasInfo{}, fmt.Errorf("error simulating proposal execution: %w", err)
 	}
@@ -696,8 +694,12 @@ func ExecuteProposal(rp *rocketpool.RocketPool, proposalId uint64, opts *bind.Tr
 	return tx.Hash(), nil
 }
 
-// Simulate a proposal's execution to verify it won't revert
-func simulateProposalExecution(rp *rocketpool.RocketPool, payload []byte) error {
+// Simulate a proposal's execution by rocketDAOProtocolProposal at the pending block to verify it won't revert.
+// A revert is returned as a *rocketpool.RevertError.
+func SimulateProposalExecution(rp *rocketpool.RocketPool, payload []byte) error {
+	if len(payload) < 4 {
+		return fmt.Errorf("proposal payload is too short")
+	}
 	rocketDAOProtocolProposal, err := getRocketDAOProtocolProposal(rp, nil)
 	if err != nil {
 		return err
@@ -707,16 +709,31 @@ func simulateProposalExecution(rp *rocketpool.RocketPool, payload []byte) error
 		return err
 	}
 
-	_, err = rp.Client.EstimateGas(context.Background(), ethereum.CallMsg{
-		From:     *rocketDAOProtocolProposal.Address,
-		To:       rocketDAOProtocolProposals.Address,
-		GasPrice: big.NewInt(0),
-		Value:    nil,
-		Data:     payload,
-	})
+	_, err = rocketDAOProtocolProposals.SimulateCalldata(&bind.TransactOpts{
+		From: *rocketDAOProtocolProposal.Address,
+	}, payload)
 	return err
 }
 
+// Trace a proposal's execution by rocketDAOProtocolProposal at the latest block and get the storage it would write.
+// The client must implement rocketpool.StateOverrideClient.
+func TraceProposalExecution(rp *rocketpool.RocketPool, payload []byte) (rocketpool.StorageChanges, error) {
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("proposal payload is too short")
+	}
+	rocketDAOProtocolProposal, err := getRocketDAOProtocolProposal(rp, nil)
+	if err != nil {
+		return nil, err
+	}
+	rocketDAOProtocolProposals, err := getRocketDAOProtocolProposals(rp, nil)
+	if err != nil {
+		return nil, err
+	}
+	return rocketDAOProtocolProposals.TraceCalldataStorageChanges(&bind.TransactOpts{
+		From: *rocketDAOProtocolProposal.Address,
+	}, payload)
+}
+
 // Get contracts
 var rocketDAOProtocolProposalLock sync.Mutex
 
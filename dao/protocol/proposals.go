@@ -19,7 +19,7 @@ func EstimateProposeSetMultiGas(rp *rocketpool.RocketPool, message string, contr
 	if err != nil {
 		return rocketpool.GasInfo{}, err
 	}
-	encodedValues, err := abiEncodeMultiValues(settingTypes, values)
+	encodedValues, err := AbiEncodeMultiValues(settingTypes, values)
 	if err != nil {
 		return rocketpool.GasInfo{}, fmt.Errorf("error ABI encoding values: %w", err)
 	}
@@ -36,7 +36,7 @@ func ProposeSetMulti(rp *rocketpool.RocketPool, message string, contractNames []
 	if err != nil {
 		return 0, common.Hash{}, err
 	}
-	encodedValues, err := abiEncodeMultiValues(settingTypes, values)
+	encodedValues, err := AbiEncodeMultiValues(settingTypes, values)
 	if err != nil {
 		return 0, common.Hash{}, fmt.Errorf("error ABI encoding values: %w", err)
 	}
@@ -334,7 +334,7 @@ func ProposeReplaceSecurityCouncilMember(rp *rocketpool.RocketPool, message stri
 }
 
 // Get the ABI encoding of multiple values for a ProposeSettingMulti call
-func abiEncodeMultiValues(settingTypes []types.ProposalSettingType, values []any) ([][]byte, error) {
+func AbiEncodeMultiValues(settingTypes []types.ProposalSettingType, values []any) ([][]byte, error) {
 	// Sanity check the lengths
 	settingCount := len(settingTypes)
 	if settingCount != len(values) {
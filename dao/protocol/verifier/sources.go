@@ -0,0 +1,224 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol/votingtree"
+	"github.com/rocket-pool/rocketpool-go/network"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// Provides the locally computed voting tree for the snapshot block of a proposal
+type VotingTreeProvider interface {
+	GetVotingTree(blockNumber uint32) (*votingtree.ProposalVotingTree, error)
+}
+
+// Provides the verification events and challenge data for proposals
+type ChainDataSource interface {
+	GetRootSubmittedEvents(proposalIDs []uint64) ([]protocol.RootSubmitted, error)
+	GetChallengeSubmittedEvents(proposalIDs []uint64) ([]protocol.ChallengeSubmitted, error)
+	GetChallengeStates(proposalIDs []uint64, indices []uint64) ([]types.ChallengeState, error)
+	GetNode(proposalID uint64, index uint64) (types.VotingTreeNode, error)
+}
+
+// ===========================
+// === Network Data Source ===
+// ===========================
+
+// Builds voting trees from the voting power snapshot on the network
+type NetworkVotingTreeProvider struct {
+	rp               *rocketpool.RocketPool
+	multicallAddress common.Address
+	trees            map[uint32]*votingtree.ProposalVotingTree
+	treesLock        sync.Mutex
+}
+
+// Create a new voting tree provider backed by the network
+func NewNetworkVotingTreeProvider(rp *rocketpool.RocketPool, multicallAddress common.Address) *NetworkVotingTreeProvider {
+	return &NetworkVotingTreeProvider{
+		rp:               rp,
+		multicallAddress: multicallAddress,
+		trees:            map[uint32]*votingtree.ProposalVotingTree{},
+	}
+}
+
+// Get the voting tree for the given block, building it on first use
+func (p *NetworkVotingTreeProvider) GetVotingTree(blockNumber uint32) (*votingtree.ProposalVotingTree, error) {
+	p.treesLock.Lock()
+	defer p.treesLock.Unlock()
+	if tree, exists := p.trees[blockNumber]; exists {
+		return tree, nil
+	}
+
+	opts := &bind.CallOpts{
+		BlockNumber: big.NewInt(int64(blockNumber)),
+	}
+	votingInfo, err := network.GetNodeInfoSnapshotFast(p.rp, blockNumber, p.multicallAddress, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting voting snapshot for block %d: %w", blockNumber, err)
+	}
+	depthPerRound, err := protocol.GetDepthPerRound(p.rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := votingtree.NewProposalVotingTree(votingInfo, depthPerRound)
+	if err != nil {
+		return nil, fmt.Errorf("error creating voting tree for block %d: %w", blockNumber, err)
+	}
+	p.trees[blockNumber] = tree
+	return tree, nil
+}
+
+// Reads verification events and challenge states from the network
+type NetworkDataSource struct {
+	RocketPool        *rocketpool.RocketPool
+	MulticallAddress  common.Address
+	IntervalSize      *big.Int
+	StartBlock        *big.Int
+	EndBlock          *big.Int
+	VerifierAddresses []common.Address
+	Opts              *bind.CallOpts
+}
+
+// Get the RootSubmitted events for the given proposals
+func (s *NetworkDataSource) GetRootSubmittedEvents(proposalIDs []uint64) ([]protocol.RootSubmitted, error) {
+	return protocol.GetRootSubmittedEvents(s.RocketPool, proposalIDs, s.IntervalSize, s.StartBlock, s.EndBlock, s.VerifierAddresses, s.Opts)
+}
+
+// Get the ChallengeSubmitted events for the given proposals
+func (s *NetworkDataSource) GetChallengeSubmittedEvents(proposalIDs []uint64) ([]protocol.ChallengeSubmitted, error) {
+	return protocol.GetChallengeSubmittedEvents(s.RocketPool, proposalIDs, s.IntervalSize, s.StartBlock, s.EndBlock, s.VerifierAddresses, s.Opts)
+}
+
+// Get the challenge states of the given proposal / index pairs
+func (s *NetworkDataSource) GetChallengeStates(proposalIDs []uint64, indices []uint64) ([]types.ChallengeState, error) {
+	return protocol.GetMultiChallengeStatesFast(s.RocketPool, s.MulticallAddress, proposalIDs, indices, s.Opts)
+}
+
+// Get the node stored on-chain for a proposal at the given index
+func (s *NetworkDataSource) GetNode(proposalID uint64, index uint64) (types.VotingTreeNode, error) {
+	return protocol.GetNode(s.RocketPool, proposalID, index, s.Opts)
+}
+
+// ============================
+// === Recorded Data Source ===
+// ============================
+
+// Builds voting trees from recorded voting power snapshots
+type StaticVotingTreeProvider struct {
+	Snapshots     map[uint32][]types.NodeVotingInfo `json:"snapshots"`
+	DepthPerRound uint64                            `json:"depthPerRound"`
+}
+
+// Get the voting tree for the given block
+func (p *StaticVotingTreeProvider) GetVotingTree(blockNumber uint32) (*votingtree.ProposalVotingTree, error) {
+	votingInfo, exists := p.Snapshots[blockNumber]
+	if !exists {
+		return nil, fmt.Errorf("no voting snapshot recorded for block %d", blockNumber)
+	}
+	return votingtree.NewProposalVotingTree(votingInfo, p.DepthPerRound)
+}
+
+// A recorded challenge state for a proposal index
+type RecordedChallengeState struct {
+	ProposalID uint64               `json:"proposalId"`
+	Index      uint64               `json:"index"`
+	State      types.ChallengeState `json:"state"`
+}
+
+// A recorded on-chain node for a proposal index
+type RecordedNode struct {
+	ProposalID uint64               `json:"proposalId"`
+	Index      uint64               `json:"index"`
+	Node       types.VotingTreeNode `json:"node"`
+}
+
+// Serves verification data from recorded fixtures, such as the ones captured from a NetworkDataSource
+type RecordedDataSource struct {
+	RootSubmittedEvents      []protocol.RootSubmitted      `json:"rootSubmittedEvents"`
+	ChallengeSubmittedEvents []protocol.ChallengeSubmitted `json:"challengeSubmittedEvents"`
+	ChallengeStates          []RecordedChallengeState      `json:"challengeStates"`
+	Nodes                    []RecordedNode                `json:"nodes"`
+}
+
+// Load a recorded data source from a JSON file
+func LoadRecordedDataSource(path string) (*RecordedDataSource, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recorded verification data from %s: %w", path, err)
+	}
+	source := new(RecordedDataSource)
+	if err := json.Unmarshal(bytes, source); err != nil {
+		return nil, fmt.Errorf("error parsing recorded verification data from %s: %w", path, err)
+	}
+	return source, nil
+}
+
+// Get the recorded RootSubmitted events for the given proposals
+func (s *RecordedDataSource) GetRootSubmittedEvents(proposalIDs []uint64) ([]protocol.RootSubmitted, error) {
+	ids := makeIDSet(proposalIDs)
+	events := []protocol.RootSubmitted{}
+	for _, event := range s.RootSubmittedEvents {
+		if ids[event.ProposalID.Uint64()] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Get the recorded ChallengeSubmitted events for the given proposals
+func (s *RecordedDataSource) GetChallengeSubmittedEvents(proposalIDs []uint64) ([]protocol.ChallengeSubmitted, error) {
+	ids := makeIDSet(proposalIDs)
+	events := []protocol.ChallengeSubmitted{}
+	for _, event := range s.ChallengeSubmittedEvents {
+		if ids[event.ProposalID.Uint64()] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Get the recorded challenge states; indices without a record are unchallenged
+func (s *RecordedDataSource) GetChallengeStates(proposalIDs []uint64, indices []uint64) ([]types.ChallengeState, error) {
+	if len(proposalIDs) != len(indices) {
+		return nil, fmt.Errorf("have %d proposal IDs but %d challenge indices", len(proposalIDs), len(indices))
+	}
+	states := make([]types.ChallengeState, len(proposalIDs))
+	for i := range proposalIDs {
+		states[i] = types.ChallengeState_Unchallenged
+		for _, record := range s.ChallengeStates {
+			if record.ProposalID == proposalIDs[i] && record.Index == indices[i] {
+				states[i] = record.State
+				break
+			}
+		}
+	}
+	return states, nil
+}
+
+// Get the recorded on-chain node for a proposal at the given index
+func (s *RecordedDataSource) GetNode(proposalID uint64, index uint64) (types.VotingTreeNode, error) {
+	for _, record := range s.Nodes {
+		if record.ProposalID == proposalID && record.Index == index {
+			return record.Node, nil
+		}
+	}
+	return types.VotingTreeNode{}, fmt.Errorf("no node recorded for proposal %d / index %d", proposalID, index)
+}
+
+// Make a lookup set of proposal IDs
+func makeIDSet(proposalIDs []uint64) map[uint64]bool {
+	ids := make(map[uint64]bool, len(proposalIDs))
+	for _, id := range proposalIDs {
+		ids[id] = true
+	}
+	return ids
+}
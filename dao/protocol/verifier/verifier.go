@@ -0,0 +1,270 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol/votingtree"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// The arguments for a protocol.CreateChallenge call against an invalid tree node
+type Challenge struct {
+	ProposalID uint64                 `json:"proposalId"`
+	Index      uint64                 `json:"index"`
+	Node       types.VotingTreeNode   `json:"node"`
+	Witness    []types.VotingTreeNode `json:"witness"`
+}
+
+// The arguments for a protocol.SubmitRoot call in response to a challenge
+type Response struct {
+	ProposalID uint64                 `json:"proposalId"`
+	Index      uint64                 `json:"index"`
+	TreeNodes  []types.VotingTreeNode `json:"treeNodes"`
+}
+
+// The result of checking a single submitted pollard against the local voting tree
+type SubmissionVerification struct {
+	Submission protocol.RootSubmitted `json:"submission"`
+	IsValid    bool                   `json:"isValid"`
+
+	// The first index in the pollard that doesn't match the local tree, and the node the local tree has for it
+	MismatchIndex  uint64               `json:"mismatchIndex"`
+	ExpectedNode   types.VotingTreeNode `json:"expectedNode"`
+	ChallengeState types.ChallengeState `json:"challengeState"`
+
+	// The challenge to submit, if the mismatching index hasn't been challenged yet
+	Challenge *Challenge `json:"challenge,omitempty"`
+}
+
+// The result of checking a challenge against a proposal
+type ChallengeVerification struct {
+	Event          protocol.ChallengeSubmitted `json:"event"`
+	State          types.ChallengeState        `json:"state"`
+	ProposalBlock  uint32                      `json:"proposalBlock"`
+	IsRespondable  bool                        `json:"isRespondable"`
+	NodeMismatched bool                        `json:"nodeMismatched"`
+
+	// The response to submit, if the challenge is still open and the local tree can answer it
+	Response *Response `json:"response,omitempty"`
+}
+
+// Checks proposal trees against locally computed voting trees and determines the challenges or responses needed
+type Verifier struct {
+	trees  VotingTreeProvider
+	source ChainDataSource
+}
+
+// Create a new verifier
+func NewVerifier(trees VotingTreeProvider, source ChainDataSource) *Verifier {
+	return &Verifier{
+		trees:  trees,
+		source: source,
+	}
+}
+
+// Check every pollard submitted for the given proposals, in submission order
+func (v *Verifier) VerifySubmissions(proposalIDs []uint64) ([]SubmissionVerification, error) {
+	events, err := v.source.GetRootSubmittedEvents(proposalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting root submission events: %w", err)
+	}
+	sortRootSubmissions(events)
+
+	// Compare each pollard to the local tree
+	results := make([]SubmissionVerification, len(events))
+	mismatchIDs := []uint64{}
+	mismatchIndices := []uint64{}
+	for i, event := range events {
+		results[i].Submission = event
+		tree, err := v.trees.GetVotingTree(event.BlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("error getting voting tree for proposal %s: %w", event.ProposalID.String(), err)
+		}
+		mismatchIndex, expectedNode, err := findFirstMismatch(tree, event)
+		if err != nil {
+			return nil, fmt.Errorf("error verifying proposal %s / index %s: %w", event.ProposalID.String(), event.Index.String(), err)
+		}
+		if mismatchIndex == 0 {
+			results[i].IsValid = true
+			continue
+		}
+		results[i].MismatchIndex = mismatchIndex
+		results[i].ExpectedNode = expectedNode
+		mismatchIDs = append(mismatchIDs, event.ProposalID.Uint64())
+		mismatchIndices = append(mismatchIndices, mismatchIndex)
+	}
+	if len(mismatchIDs) == 0 {
+		return results, nil
+	}
+
+	// Only unchallenged indices need a new challenge
+	states, err := v.source.GetChallengeStates(mismatchIDs, mismatchIndices)
+	if err != nil {
+		return nil, fmt.Errorf("error getting challenge states: %w", err)
+	}
+	stateIndex := 0
+	for i := range results {
+		result := &results[i]
+		if result.IsValid {
+			continue
+		}
+		result.ChallengeState = states[stateIndex]
+		stateIndex++
+		if result.ChallengeState != types.ChallengeState_Unchallenged {
+			continue
+		}
+
+		// Build the witness from the submitted pollard, since that's what the contract will verify against
+		submission := result.Submission
+		pollardRoot := submission.Index.Uint64()
+		offset := result.MismatchIndex - (pollardRoot << votingtree.GetDepthForLeafCount(uint64(len(submission.TreeNodes))))
+		witness, err := votingtree.GetWitnessFromPollard(submission.TreeNodes, pollardRoot, result.MismatchIndex)
+		if err != nil {
+			return nil, fmt.Errorf("error creating witness for proposal %s / index %d: %w", submission.ProposalID.String(), result.MismatchIndex, err)
+		}
+		result.Challenge = &Challenge{
+			ProposalID: submission.ProposalID.Uint64(),
+			Index:      result.MismatchIndex,
+			Node:       submission.TreeNodes[offset],
+			Witness:    witness,
+		}
+	}
+	return results, nil
+}
+
+// Check the challenges made against proposals created by the given proposer, and get the responses for any that are
+// still open. If proposer is nil, challenges against every proposal are checked.
+func (v *Verifier) VerifyChallenges(proposalIDs []uint64, proposer *common.Address) ([]ChallengeVerification, error) {
+	submissions, err := v.source.GetRootSubmittedEvents(proposalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting root submission events: %w", err)
+	}
+
+	// Find the original submission of each proposal
+	proposals := map[uint64]protocol.RootSubmitted{}
+	for _, submission := range submissions {
+		if submission.Index.Uint64() == 1 {
+			proposals[submission.ProposalID.Uint64()] = submission
+		}
+	}
+
+	challenges, err := v.source.GetChallengeSubmittedEvents(proposalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting challenge submission events: %w", err)
+	}
+	sort.SliceStable(challenges, func(i, j int) bool {
+		return challenges[i].Timestamp.Before(challenges[j].Timestamp)
+	})
+
+	// Filter by proposer
+	relevant := []protocol.ChallengeSubmitted{}
+	for _, challenge := range challenges {
+		proposal, exists := proposals[challenge.ProposalID.Uint64()]
+		if !exists {
+			return nil, fmt.Errorf("proposal %s was challenged but its root submission was not found", challenge.ProposalID.String())
+		}
+		if proposer != nil && proposal.Proposer != *proposer {
+			continue
+		}
+		relevant = append(relevant, challenge)
+	}
+	if len(relevant) == 0 {
+		return []ChallengeVerification{}, nil
+	}
+
+	// Get the challenge states
+	ids := make([]uint64, len(relevant))
+	indices := make([]uint64, len(relevant))
+	for i, challenge := range relevant {
+		ids[i] = challenge.ProposalID.Uint64()
+		indices[i] = challenge.Index.Uint64()
+	}
+	states, err := v.source.GetChallengeStates(ids, indices)
+	if err != nil {
+		return nil, fmt.Errorf("error getting challenge states: %w", err)
+	}
+
+	results := make([]ChallengeVerification, len(relevant))
+	for i, challenge := range relevant {
+		proposal := proposals[ids[i]]
+		results[i] = ChallengeVerification{
+			Event:         challenge,
+			State:         states[i],
+			ProposalBlock: proposal.BlockNumber,
+		}
+		if states[i] != types.ChallengeState_Challenged {
+			continue
+		}
+
+		tree, err := v.trees.GetVotingTree(proposal.BlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("error getting voting tree for proposal %d: %w", ids[i], err)
+		}
+		index := indices[i]
+
+		// A response is only accepted if the challenged node matches the local tree
+		challengedNode, err := v.source.GetNode(ids[i], index)
+		if err != nil {
+			return nil, fmt.Errorf("error getting challenged node for proposal %d / index %d: %w", ids[i], index, err)
+		}
+		localNode, err := tree.GetNode(index)
+		if err != nil {
+			return nil, fmt.Errorf("error getting local node for proposal %d / index %d: %w", ids[i], index, err)
+		}
+		if !votingtree.NodesEqual(challengedNode, localNode) {
+			results[i].NodeMismatched = true
+			continue
+		}
+
+		// Leaves can't be responded to with a pollard
+		if votingtree.GetDepthFromIndex(index) == tree.GetMaxDepth() {
+			continue
+		}
+		pollard, err := tree.GetPollardForIndex(index)
+		if err != nil {
+			return nil, fmt.Errorf("error getting pollard for proposal %d / index %d: %w", ids[i], index, err)
+		}
+		results[i].IsRespondable = true
+		results[i].Response = &Response{
+			ProposalID: ids[i],
+			Index:      index,
+			TreeNodes:  pollard,
+		}
+	}
+	return results, nil
+}
+
+// Find the first node in a submitted pollard that doesn't match the local tree, returning 0 if they all match
+func findFirstMismatch(tree *votingtree.ProposalVotingTree, submission protocol.RootSubmitted) (uint64, types.VotingTreeNode, error) {
+	rootIndex := submission.Index.Uint64()
+	count := uint64(len(submission.TreeNodes))
+	if count == 0 || count&(count-1) != 0 {
+		return 0, types.VotingTreeNode{}, fmt.Errorf("pollard has %d nodes but must have a power of two", count)
+	}
+	start := rootIndex << votingtree.GetDepthForLeafCount(count)
+	for i, submitted := range submission.TreeNodes {
+		index := start + uint64(i)
+		expected, err := tree.GetNode(index)
+		if err != nil {
+			return 0, types.VotingTreeNode{}, err
+		}
+		if !votingtree.NodesEqual(submitted, expected) {
+			return index, expected, nil
+		}
+	}
+	return 0, types.VotingTreeNode{}, nil
+}
+
+// Sort root submissions by proposal and then by time
+func sortRootSubmissions(events []protocol.RootSubmitted) {
+	sort.SliceStable(events, func(i, j int) bool {
+		idCmp := events[i].ProposalID.Cmp(events[j].ProposalID)
+		if idCmp != 0 {
+			return idCmp < 0
+		}
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+}
@@ -0,0 +1,314 @@
+package votingtree
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// The complete voting tree for a Protocol DAO proposal.
+//
+// The tree is made of two phases. The phase 1 network tree has one leaf per node in the voting snapshot (padded with
+// zero leaves to a power of two), where each leaf holds the total voting power delegated to that node. Each network
+// leaf is then the root of a phase 2 node tree with the same depth, where leaf i holds the voting power of node i if
+// it delegated to the network leaf's node, or zero if it did not. Indices below the network leaves continue the
+// generalized indexing of the network tree, so the full tree has twice the depth of the network tree.
+//
+// Pollards are split into rounds of depthPerRound levels; each phase is sliced independently, so the last round of
+// a phase is shortened to end exactly at that phase's leaves.
+type ProposalVotingTree struct {
+	// The phase 1 tree of delegated voting power
+	NetworkTree *VotingTree `json:"networkTree"`
+
+	// The number of tree levels covered by a single pollard
+	DepthPerRound uint64 `json:"depthPerRound"`
+
+	votingInfo    []types.NodeVotingInfo
+	delegateIndex []uint64
+	nodeTrees     map[uint64]*VotingTree
+	nodeTreesLock sync.Mutex
+}
+
+// Create the complete voting tree for a proposal from a voting power snapshot, such as the one provided by
+// network.GetNodeInfoSnapshotFast. The snapshot must be in node index order.
+func NewProposalVotingTree(votingInfo []types.NodeVotingInfo, depthPerRound uint64) (*ProposalVotingTree, error) {
+	if depthPerRound == 0 {
+		return nil, fmt.Errorf("depth per round must be greater than zero")
+	}
+
+	// Resolve each node's delegate
+	delegateIndex, err := getDelegateIndices(votingInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Aggregate the delegated voting power
+	delegatedPower := make([]*big.Int, len(votingInfo))
+	for i := range delegatedPower {
+		delegatedPower[i] = big.NewInt(0)
+	}
+	for i, info := range votingInfo {
+		delegatedPower[delegateIndex[i]].Add(delegatedPower[delegateIndex[i]], info.VotingPower)
+	}
+
+	// Build the network tree
+	networkTree, err := NewVotingTree(delegatedPower)
+	if err != nil {
+		return nil, fmt.Errorf("error creating network tree: %w", err)
+	}
+
+	return &ProposalVotingTree{
+		NetworkTree:   networkTree,
+		DepthPerRound: depthPerRound,
+		votingInfo:    votingInfo,
+		delegateIndex: delegateIndex,
+		nodeTrees:     map[uint64]*VotingTree{},
+	}, nil
+}
+
+// Get the depth of the network tree's leaves
+func (t *ProposalVotingTree) GetNetworkDepth() uint64 {
+	return t.NetworkTree.Depth
+}
+
+// Get the depth of the phase 2 leaves, which is the maximum depth of the tree
+func (t *ProposalVotingTree) GetMaxDepth() uint64 {
+	return t.NetworkTree.Depth * 2
+}
+
+// Get the root node of the tree
+func (t *ProposalVotingTree) GetRoot() types.VotingTreeNode {
+	return t.NetworkTree.GetRoot()
+}
+
+// Get the total voting power that was delegated to each node, in node index order
+func (t *ProposalVotingTree) GetDelegatedVotingPower() []*big.Int {
+	powers := make([]*big.Int, len(t.votingInfo))
+	for i := range powers {
+		powers[i] = t.NetworkTree.Nodes[t.NetworkTree.GetLeafCount()+uint64(i)-1].Sum
+	}
+	return powers
+}
+
+// Get the phase 2 tree for the node with the given index
+func (t *ProposalVotingTree) GetNodeTree(nodeIndex uint64) (*VotingTree, error) {
+	if nodeIndex >= t.NetworkTree.GetLeafCount() {
+		return nil, fmt.Errorf("node index %d is out of range for a network tree of depth %d", nodeIndex, t.NetworkTree.Depth)
+	}
+
+	t.nodeTreesLock.Lock()
+	defer t.nodeTreesLock.Unlock()
+	if tree, exists := t.nodeTrees[nodeIndex]; exists {
+		return tree, nil
+	}
+
+	// Only nodes that delegated to this one contribute their voting power
+	leafSums := make([]*big.Int, t.NetworkTree.GetLeafCount())
+	for i := range leafSums {
+		if i < len(t.votingInfo) && t.delegateIndex[i] == nodeIndex {
+			leafSums[i] = t.votingInfo[i].VotingPower
+		} else {
+			leafSums[i] = big.NewInt(0)
+		}
+	}
+	tree, err := NewVotingTree(leafSums)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tree for node %d: %w", nodeIndex, err)
+	}
+	t.nodeTrees[nodeIndex] = tree
+	return tree, nil
+}
+
+// Get the node at any generalized index in the tree, in either phase
+func (t *ProposalVotingTree) GetNode(index uint64) (types.VotingTreeNode, error) {
+	tree, localIndex, err := t.resolveIndex(index)
+	if err != nil {
+		return types.VotingTreeNode{}, err
+	}
+	return tree.GetNode(localIndex)
+}
+
+// Get the root node and the pollard that must be submitted when creating a proposal
+func (t *ProposalVotingTree) GetProposalPollard() (types.VotingTreeNode, []types.VotingTreeNode, error) {
+	pollard, err := t.GetPollardForIndex(1)
+	if err != nil {
+		return types.VotingTreeNode{}, nil, err
+	}
+	return t.GetRoot(), pollard, nil
+}
+
+// Get the pollard that must be submitted in response to a challenge on the given index
+func (t *ProposalVotingTree) GetPollardForIndex(index uint64) ([]types.VotingTreeNode, error) {
+	tree, localIndex, err := t.resolveIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	depth := GetDepthFromIndex(index)
+	if depth == t.GetMaxDepth() {
+		return nil, fmt.Errorf("index %d is a leaf of the tree and has no pollard", index)
+	}
+
+	// Network leaves start a new round at the root of their node tree
+	if depth == t.GetNetworkDepth() && t.GetNetworkDepth() > 0 {
+		tree, err = t.GetNodeTree(index - t.NetworkTree.GetLeafCount())
+		if err != nil {
+			return nil, err
+		}
+		localIndex = 1
+	}
+	return tree.GetPollard(localIndex, t.DepthPerRound)
+}
+
+// Get the index of the pollard root that the given index was submitted under, which is the index that was
+// challenged (or the proposal root) in the previous round
+func (t *ProposalVotingTree) GetPollardRootIndex(index uint64) (uint64, error) {
+	depth := GetDepthFromIndex(index)
+	if index == 0 || depth > t.GetMaxDepth() {
+		return 0, fmt.Errorf("index %d is out of range for a tree of depth %d", index, t.GetMaxDepth())
+	}
+	if depth == 0 {
+		return 0, fmt.Errorf("the root is not part of a pollard")
+	}
+	phaseStart := uint64(0)
+	if depth > t.GetNetworkDepth() {
+		phaseStart = t.GetNetworkDepth()
+	}
+	rootDepth := phaseStart + ((depth-phaseStart-1)/t.DepthPerRound)*t.DepthPerRound
+	return index >> (depth - rootDepth), nil
+}
+
+// Get the pollard root that a challenge on the given index is verified against, along with its index.
+// For the first round of phase 2 this is the root of the node tree rather than the network leaf, since a network
+// leaf's hash commits only to its sum.
+func (t *ProposalVotingTree) GetPollardRoot(index uint64) (uint64, types.VotingTreeNode, error) {
+	rootIndex, err := t.GetPollardRootIndex(index)
+	if err != nil {
+		return 0, types.VotingTreeNode{}, err
+	}
+	if GetDepthFromIndex(index) > t.GetNetworkDepth() && GetDepthFromIndex(rootIndex) == t.GetNetworkDepth() {
+		nodeTree, err := t.GetNodeTree(rootIndex - t.NetworkTree.GetLeafCount())
+		if err != nil {
+			return 0, types.VotingTreeNode{}, err
+		}
+		return rootIndex, nodeTree.GetRoot(), nil
+	}
+	root, err := t.GetNode(rootIndex)
+	if err != nil {
+		return 0, types.VotingTreeNode{}, err
+	}
+	return rootIndex, root, nil
+}
+
+// Check if a challenge can be made against the given index; it must be at the bottom of a pollard
+func (t *ProposalVotingTree) IsChallengeableIndex(index uint64) bool {
+	rootIndex, err := t.GetPollardRootIndex(index)
+	if err != nil {
+		return false
+	}
+	depth := GetDepthFromIndex(index)
+	rootDepth := GetDepthFromIndex(rootIndex)
+	phaseEnd := t.GetMaxDepth()
+	if rootDepth < t.GetNetworkDepth() {
+		phaseEnd = t.GetNetworkDepth()
+	}
+	return depth == rootDepth+t.DepthPerRound || depth == phaseEnd
+}
+
+// Get the witness proving the node at the given index against the root of the pollard it was submitted in
+func (t *ProposalVotingTree) GetWitness(index uint64) ([]types.VotingTreeNode, error) {
+	rootIndex, err := t.GetPollardRootIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return t.GetWitnessToIndex(index, rootIndex)
+}
+
+// Get the witness proving the node at the given index against one of its ancestors
+func (t *ProposalVotingTree) GetWitnessToIndex(index uint64, rootIndex uint64) ([]types.VotingTreeNode, error) {
+	if !isAncestor(rootIndex, index) {
+		return nil, fmt.Errorf("index %d is not a descendant of index %d", index, rootIndex)
+	}
+	witness := []types.VotingTreeNode{}
+	for i := index; i > rootIndex; i >>= 1 {
+		sibling, err := t.GetNode(i ^ 1)
+		if err != nil {
+			return nil, err
+		}
+		witness = append(witness, sibling)
+	}
+	return witness, nil
+}
+
+// Get the voting power and witness a node must provide to protocol.VoteOnProposal
+func (t *ProposalVotingTree) GetVotingArtifacts(nodeIndex uint64) (*big.Int, []types.VotingTreeNode, error) {
+	if nodeIndex >= uint64(len(t.votingInfo)) {
+		return nil, nil, fmt.Errorf("node index %d is not in the voting snapshot", nodeIndex)
+	}
+	leafIndex := t.GetNetworkLeafIndex(nodeIndex)
+	leaf, err := t.NetworkTree.GetNode(leafIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	witness, err := t.NetworkTree.GetWitness(leafIndex, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return leaf.Sum, witness, nil
+}
+
+// Get the index of the network tree leaf for the node with the given index
+func (t *ProposalVotingTree) GetNetworkLeafIndex(nodeIndex uint64) uint64 {
+	return t.NetworkTree.GetLeafCount() + nodeIndex
+}
+
+// Map a full tree index to the tree that holds it and the index within that tree
+func (t *ProposalVotingTree) resolveIndex(index uint64) (*VotingTree, uint64, error) {
+	if index == 0 {
+		return nil, 0, fmt.Errorf("index 0 is not a valid tree index")
+	}
+	depth := GetDepthFromIndex(index)
+	networkDepth := t.GetNetworkDepth()
+	if depth <= networkDepth {
+		return t.NetworkTree, index, nil
+	}
+	if depth > t.GetMaxDepth() {
+		return nil, 0, fmt.Errorf("index %d is out of range for a tree of depth %d", index, t.GetMaxDepth())
+	}
+
+	// Find the network leaf above this index and the position within its node tree
+	localDepth := depth - networkDepth
+	networkLeaf := index >> localDepth
+	localIndex := (uint64(1) << localDepth) | (index & ((uint64(1) << localDepth) - 1))
+	tree, err := t.GetNodeTree(networkLeaf - t.NetworkTree.GetLeafCount())
+	if err != nil {
+		return nil, 0, err
+	}
+	return tree, localIndex, nil
+}
+
+// Get the index of each node's delegate, treating an empty or unknown delegate as the node itself
+func getDelegateIndices(votingInfo []types.NodeVotingInfo) ([]uint64, error) {
+	nodeIndices := make(map[common.Address]uint64, len(votingInfo))
+	for i, info := range votingInfo {
+		if _, exists := nodeIndices[info.NodeAddress]; exists {
+			return nil, fmt.Errorf("node %s is in the voting snapshot more than once", info.NodeAddress.Hex())
+		}
+		if info.VotingPower == nil {
+			return nil, fmt.Errorf("node %s does not have a voting power", info.NodeAddress.Hex())
+		}
+		nodeIndices[info.NodeAddress] = uint64(i)
+	}
+
+	delegateIndex := make([]uint64, len(votingInfo))
+	for i, info := range votingInfo {
+		index, exists := nodeIndices[info.Delegate]
+		if !exists {
+			index = uint64(i)
+		}
+		delegateIndex[i] = index
+	}
+	return delegateIndex, nil
+}
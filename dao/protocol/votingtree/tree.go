@@ -0,0 +1,232 @@
+package votingtree
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// A binary voting Merkle tree with generalized indices: the root has index 1, and the children of index i are 2i and 2i+1
+type VotingTree struct {
+	// The depth of the leaves (the root is at depth 0)
+	Depth uint64 `json:"depth"`
+
+	// Every node in the tree, where the node with generalized index i is stored at Nodes[i-1]
+	Nodes []types.VotingTreeNode `json:"nodes"`
+}
+
+// Get the hash of a leaf node with the given voting power, keccak256(abi.encodePacked(sum))
+func GetLeafHash(sum *big.Int) common.Hash {
+	return crypto.Keccak256Hash(uint256Bytes(sum))
+}
+
+// Get a leaf node with the given voting power
+func GetLeafNode(sum *big.Int) types.VotingTreeNode {
+	return types.VotingTreeNode{
+		Sum:  big.NewInt(0).Set(sum),
+		Hash: GetLeafHash(sum),
+	}
+}
+
+// Get the parent of two sibling nodes, keccak256(abi.encodePacked(left.hash, left.sum, right.hash, right.sum))
+func GetParentNode(left types.VotingTreeNode, right types.VotingTreeNode) types.VotingTreeNode {
+	return types.VotingTreeNode{
+		Sum:  big.NewInt(0).Add(left.Sum, right.Sum),
+		Hash: crypto.Keccak256Hash(left.Hash.Bytes(), uint256Bytes(left.Sum), right.Hash.Bytes(), uint256Bytes(right.Sum)),
+	}
+}
+
+// Get the depth of a generalized index (the root is at depth 0)
+func GetDepthFromIndex(index uint64) uint64 {
+	return uint64(bits.Len64(index)) - 1
+}
+
+// Get the smallest tree depth that can hold the given number of leaves
+func GetDepthForLeafCount(count uint64) uint64 {
+	if count <= 1 {
+		return 0
+	}
+	return uint64(bits.Len64(count - 1))
+}
+
+// Create a tree from voting power leaves, padding it with zero-power leaves up to the next power of two
+func NewVotingTree(leafSums []*big.Int) (*VotingTree, error) {
+	if len(leafSums) == 0 {
+		return nil, fmt.Errorf("cannot create a voting tree without any leaves")
+	}
+	depth := GetDepthForLeafCount(uint64(len(leafSums)))
+	leaves := make([]types.VotingTreeNode, 1<<depth)
+	zero := big.NewInt(0)
+	for i := range leaves {
+		if i < len(leafSums) {
+			if leafSums[i] == nil {
+				return nil, fmt.Errorf("leaf %d has no voting power value", i)
+			}
+			leaves[i] = GetLeafNode(leafSums[i])
+		} else {
+			leaves[i] = GetLeafNode(zero)
+		}
+	}
+	return newVotingTreeFromNodes(leaves), nil
+}
+
+// Get the root node of the tree
+func (t *VotingTree) GetRoot() types.VotingTreeNode {
+	return t.Nodes[0]
+}
+
+// Get the number of leaves in the tree, including padding
+func (t *VotingTree) GetLeafCount() uint64 {
+	return 1 << t.Depth
+}
+
+// Get the node with the given generalized index
+func (t *VotingTree) GetNode(index uint64) (types.VotingTreeNode, error) {
+	if index == 0 || index > uint64(len(t.Nodes)) {
+		return types.VotingTreeNode{}, fmt.Errorf("index %d is out of range for a tree of depth %d", index, t.Depth)
+	}
+	return t.Nodes[index-1], nil
+}
+
+// Get the nodes that are `depth` levels below the given index, in left-to-right order.
+// The depth is clamped to the leaves of the tree.
+func (t *VotingTree) GetPollard(index uint64, depth uint64) ([]types.VotingTreeNode, error) {
+	if _, err := t.GetNode(index); err != nil {
+		return nil, err
+	}
+	indexDepth := GetDepthFromIndex(index)
+	if indexDepth+depth > t.Depth {
+		depth = t.Depth - indexDepth
+	}
+	start := index << depth
+	count := uint64(1) << depth
+	pollard := make([]types.VotingTreeNode, count)
+	for i := uint64(0); i < count; i++ {
+		pollard[i] = copyNode(t.Nodes[start+i-1])
+	}
+	return pollard, nil
+}
+
+// Get the Merkle witness proving the node at index against its ancestor at rootIndex.
+// The witness is ordered from the sibling of the node upwards, and excludes the ancestor itself.
+func (t *VotingTree) GetWitness(index uint64, rootIndex uint64) ([]types.VotingTreeNode, error) {
+	if _, err := t.GetNode(index); err != nil {
+		return nil, err
+	}
+	if !isAncestor(rootIndex, index) {
+		return nil, fmt.Errorf("index %d is not a descendant of index %d", index, rootIndex)
+	}
+	witness := []types.VotingTreeNode{}
+	for i := index; i > rootIndex; i >>= 1 {
+		witness = append(witness, copyNode(t.Nodes[(i^1)-1]))
+	}
+	return witness, nil
+}
+
+// Compute the root of a pollard, which must contain a power-of-two number of nodes
+func ComputePollardRoot(pollard []types.VotingTreeNode) (types.VotingTreeNode, error) {
+	if err := checkPollardSize(pollard); err != nil {
+		return types.VotingTreeNode{}, err
+	}
+	return newVotingTreeFromNodes(pollard).GetRoot(), nil
+}
+
+// Get the Merkle witness for a node inside a pollard that was submitted for the node at rootIndex.
+// This is used to build the witness for a challenge against another party's pollard.
+func GetWitnessFromPollard(pollard []types.VotingTreeNode, rootIndex uint64, index uint64) ([]types.VotingTreeNode, error) {
+	if err := checkPollardSize(pollard); err != nil {
+		return nil, err
+	}
+	pollardDepth := GetDepthForLeafCount(uint64(len(pollard)))
+	if !isAncestor(rootIndex, index) || GetDepthFromIndex(index)-GetDepthFromIndex(rootIndex) != pollardDepth {
+		return nil, fmt.Errorf("index %d is not part of the pollard below index %d", index, rootIndex)
+	}
+
+	// Build the pollard's subtree and map the index into it
+	subtree := newVotingTreeFromNodes(pollard)
+	localIndex := (uint64(1) << pollardDepth) | (index - (rootIndex << pollardDepth))
+	return subtree.GetWitness(localIndex, 1)
+}
+
+// Verify a node against its ancestor using a witness from GetWitness
+func VerifyWitness(node types.VotingTreeNode, index uint64, witness []types.VotingTreeNode, root types.VotingTreeNode, rootIndex uint64) bool {
+	if !isAncestor(rootIndex, index) || uint64(len(witness)) != GetDepthFromIndex(index)-GetDepthFromIndex(rootIndex) {
+		return false
+	}
+	current := node
+	for i, sibling := range witness {
+		if (index>>i)&1 == 0 {
+			current = GetParentNode(current, sibling)
+		} else {
+			current = GetParentNode(sibling, current)
+		}
+	}
+	return NodesEqual(current, root)
+}
+
+// Create a tree from a power-of-two number of leaf nodes
+func newVotingTreeFromNodes(leaves []types.VotingTreeNode) *VotingTree {
+	depth := GetDepthForLeafCount(uint64(len(leaves)))
+	leafCount := uint64(1) << depth
+	nodes := make([]types.VotingTreeNode, 2*leafCount-1)
+	for i, leaf := range leaves {
+		nodes[leafCount+uint64(i)-1] = copyNode(leaf)
+	}
+	for i := leafCount - 1; i >= 1; i-- {
+		nodes[i-1] = GetParentNode(nodes[2*i-1], nodes[2*i])
+	}
+	return &VotingTree{
+		Depth: depth,
+		Nodes: nodes,
+	}
+}
+
+// Make sure a pollard is usable
+func checkPollardSize(pollard []types.VotingTreeNode) error {
+	count := uint64(len(pollard))
+	if count == 0 || count&(count-1) != 0 {
+		return fmt.Errorf("pollard has %d nodes but must have a power of two", count)
+	}
+	for i, node := range pollard {
+		if node.Sum == nil {
+			return fmt.Errorf("pollard node %d has no sum", i)
+		}
+	}
+	return nil
+}
+
+// Check if an index is the same as or below the given ancestor index
+func isAncestor(ancestor uint64, index uint64) bool {
+	if ancestor == 0 || index < ancestor {
+		return false
+	}
+	depthDiff := GetDepthFromIndex(index) - GetDepthFromIndex(ancestor)
+	return index>>depthDiff == ancestor
+}
+
+// Check if two tree nodes have the same sum and hash
+func NodesEqual(a types.VotingTreeNode, b types.VotingTreeNode) bool {
+	if a.Sum == nil || b.Sum == nil {
+		return false
+	}
+	return a.Hash == b.Hash && a.Sum.Cmp(b.Sum) == 0
+}
+
+// Copy a node so callers can't mutate the tree's sums
+func copyNode(node types.VotingTreeNode) types.VotingTreeNode {
+	return types.VotingTreeNode{
+		Sum:  big.NewInt(0).Set(node.Sum),
+		Hash: node.Hash,
+	}
+}
+
+// Serialize a value as a 32-byte big-endian uint256
+func uint256Bytes(value *big.Int) []byte {
+	buffer := make([]byte, 32)
+	value.FillBytes(buffer)
+	return buffer
+}
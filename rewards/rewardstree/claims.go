@@ -0,0 +1,266 @@
+package rewardstree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rewards"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+)
+
+// Settings
+const (
+	ClaimStatusBatchSize = 200
+)
+
+// A node's unclaimed rewards for a single interval
+type IntervalRewards struct {
+	Index       uint64        `json:"index"`
+	AmountRPL   *big.Int      `json:"amountRPL"`
+	AmountETH   *big.Int      `json:"amountETH"`
+	MerkleProof []common.Hash `json:"merkleProof"`
+}
+
+// A node's unclaimed rewards across every finished interval
+type UnclaimedRewards struct {
+	NodeAddress common.Address    `json:"nodeAddress"`
+	Intervals   []IntervalRewards `json:"intervals"`
+	TotalRPL    *big.Int          `json:"totalRPL"`
+	TotalETH    *big.Int          `json:"totalETH"`
+
+	// Unclaimed intervals whose rewards files weren't in the store
+	MissingIntervals []uint64 `json:"missingIntervals"`
+}
+
+// The arguments of a Claim or ClaimAndStake transaction
+type ClaimPlan struct {
+	NodeAddress  common.Address  `json:"nodeAddress"`
+	Indices      []*big.Int      `json:"indices"`
+	AmountRPL    []*big.Int      `json:"amountRPL"`
+	AmountETH    []*big.Int      `json:"amountETH"`
+	MerkleProofs [][]common.Hash `json:"merkleProofs"`
+	TotalRPL     *big.Int        `json:"totalRPL"`
+	TotalETH     *big.Int        `json:"totalETH"`
+
+	// The RPL to restake; if it is zero, the plan is submitted with Claim instead of ClaimAndStake
+	StakeAmount *big.Int `json:"stakeAmount"`
+
+	// Set if the requested restake amount was reduced to stay within the node's maximum RPL stake
+	StakeCapped bool `json:"stakeCapped"`
+}
+
+// Get a node's unclaimed rewards for every finished interval.
+// Claim statuses and Merkle roots are read in bulk through the multicall contract, and every interval's proof is
+// checked against the root stored by the distributor.
+func GetUnclaimedRewards(rp *rocketpool.RocketPool, multicallAddress common.Address, store TreeStore, nodeAddress common.Address, opts *bind.CallOpts) (*UnclaimedRewards, error) {
+	rocketDistributorMainnet, err := rp.GetContract("rocketMerkleDistributorMainnet", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the number of finished intervals
+	rewardIndex, err := rewards.GetRewardIndex(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	count := int(rewardIndex.Uint64())
+
+	// Get the claim statuses and roots
+	claimed := make([]bool, count)
+	roots := make([][32]byte, count)
+	var wg errgroup.Group
+	for i := 0; i < count; i += ClaimStatusBatchSize {
+		i := i
+		max := i + ClaimStatusBatchSize
+		if max > count {
+			max = count
+		}
+
+		wg.Go(func() error {
+			mc, err := multicall.NewMultiCaller(rp.Client, multicallAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				mc.AddCall(rocketDistributorMainnet, &claimed[j], "isClaimed", big.NewInt(int64(j)), nodeAddress)
+				mc.AddCall(rocketDistributorMainnet, &roots[j], "merkleRoots", big.NewInt(int64(j)))
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting claim statuses for node %s: %w", nodeAddress.Hex(), err)
+	}
+
+	// Get the rewards for each unclaimed interval
+	unclaimed := &UnclaimedRewards{
+		NodeAddress:      nodeAddress,
+		Intervals:        []IntervalRewards{},
+		TotalRPL:         big.NewInt(0),
+		TotalETH:         big.NewInt(0),
+		MissingIntervals: []uint64{},
+	}
+	for i := 0; i < count; i++ {
+		if claimed[i] {
+			continue
+		}
+		index := uint64(i)
+		file, err := store.GetRewardsFile(index)
+		if errors.Is(err, ErrRewardsFileNotFound) {
+			unclaimed.MissingIntervals = append(unclaimed.MissingIntervals, index)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		intervalRewards, exists, err := GetIntervalRewards(file, nodeAddress, roots[i])
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		unclaimed.Intervals = append(unclaimed.Intervals, intervalRewards)
+		unclaimed.TotalRPL.Add(unclaimed.TotalRPL, intervalRewards.AmountRPL)
+		unclaimed.TotalETH.Add(unclaimed.TotalETH, intervalRewards.AmountETH)
+	}
+	return unclaimed, nil
+}
+
+// Get a node's rewards from an interval's file, checking its proof against the distributor's root for the interval.
+// Returns false if the node has no rewards in the interval.
+func GetIntervalRewards(file *RewardsFile, nodeAddress common.Address, root common.Hash) (IntervalRewards, bool, error) {
+	if file.MerkleRoot != root {
+		return IntervalRewards{}, false, fmt.Errorf("rewards file for interval %d has Merkle root %s but the distributor has %s", file.Index, file.MerkleRoot.Hex(), root.Hex())
+	}
+	nodeRewards, exists := file.GetNodeRewards(nodeAddress)
+	if !exists || (nodeRewards.GetTotalRpl().Sign() == 0 && nodeRewards.SmoothingPoolEth.Sign() == 0) {
+		return IntervalRewards{}, false, nil
+	}
+
+	// Generate the proof if the file doesn't include it
+	proof := nodeRewards.MerkleProof
+	leaf := nodeRewards.GetLeafHash()
+	if !VerifyProof(root, leaf, proof) {
+		tree, err := NewRewardsTree(file.NodeRewards)
+		if err != nil {
+			return IntervalRewards{}, false, err
+		}
+		proof, err = tree.GetProof(nodeAddress)
+		if err != nil {
+			return IntervalRewards{}, false, err
+		}
+		if !VerifyProof(root, leaf, proof) {
+			return IntervalRewards{}, false, fmt.Errorf("rewards for node %s in interval %d don't match the distributor's Merkle root", nodeAddress.Hex(), file.Index)
+		}
+	}
+
+	return IntervalRewards{
+		Index:       file.Index,
+		AmountRPL:   nodeRewards.GetTotalRpl(),
+		AmountETH:   big.NewInt(0).Set(nodeRewards.SmoothingPoolEth),
+		MerkleProof: proof,
+	}, true, nil
+}
+
+// Plan a claim of every unclaimed interval, restaking a fraction of the claimed RPL.
+// restakeFraction is scaled by 1e18 (1e18 restakes all of it). The restake amount is capped so the node's stake doesn't
+// exceed its maximum RPL stake.
+func (u *UnclaimedRewards) PlanClaim(rp *rocketpool.RocketPool, restakeFraction *big.Int, opts *bind.CallOpts) (*ClaimPlan, error) {
+	if len(u.Intervals) == 0 {
+		return nil, fmt.Errorf("node %s has no unclaimed rewards", u.NodeAddress.Hex())
+	}
+	one := eth.EthToWei(1)
+	if restakeFraction == nil {
+		restakeFraction = big.NewInt(0)
+	}
+	if restakeFraction.Sign() < 0 || restakeFraction.Cmp(one) > 0 {
+		return nil, fmt.Errorf("restake fraction %s is not between 0 and 1e18", restakeFraction.String())
+	}
+
+	plan := &ClaimPlan{
+		NodeAddress:  u.NodeAddress,
+		Indices:      make([]*big.Int, len(u.Intervals)),
+		AmountRPL:    make([]*big.Int, len(u.Intervals)),
+		AmountETH:    make([]*big.Int, len(u.Intervals)),
+		MerkleProofs: make([][]common.Hash, len(u.Intervals)),
+		TotalRPL:     big.NewInt(0).Set(u.TotalRPL),
+		TotalETH:     big.NewInt(0).Set(u.TotalETH),
+		StakeAmount:  big.NewInt(0),
+	}
+	for i, interval := range u.Intervals {
+		plan.Indices[i] = big.NewInt(0).SetUint64(interval.Index)
+		plan.AmountRPL[i] = interval.AmountRPL
+		plan.AmountETH[i] = interval.AmountETH
+		plan.MerkleProofs[i] = interval.MerkleProof
+	}
+
+	// Get the restake amount
+	stakeAmount := big.NewInt(0).Mul(u.TotalRPL, restakeFraction)
+	stakeAmount.Div(stakeAmount, one)
+	if stakeAmount.Sign() == 0 {
+		return plan, nil
+	}
+
+	// Cap it at the node's maximum stake
+	var wg errgroup.Group
+	var currentStake *big.Int
+	var maximumStake *big.Int
+	wg.Go(func() error {
+		var err error
+		currentStake, err = node.GetNodeRPLStake(rp, u.NodeAddress, opts)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		maximumStake, err = node.GetNodeMaximumRPLStake(rp, u.NodeAddress, opts)
+		return err
+	})
+	if err := wg.Wait(); err != nil {
+		return nil, err
+	}
+	capStakeAmount(plan, stakeAmount, currentStake, maximumStake)
+	return plan, nil
+}
+
+// Set a plan's stake amount, capping it so the node's stake stays within its maximum
+func capStakeAmount(plan *ClaimPlan, stakeAmount *big.Int, currentStake *big.Int, maximumStake *big.Int) {
+	room := big.NewInt(0).Sub(maximumStake, currentStake)
+	if room.Sign() < 0 {
+		room.SetUint64(0)
+	}
+	if stakeAmount.Cmp(room) > 0 {
+		plan.StakeAmount = room
+		plan.StakeCapped = true
+		return
+	}
+	plan.StakeAmount = stakeAmount
+}
+
+// Estimate the gas of the plan's claim
+func (p *ClaimPlan) EstimateGas(rp *rocketpool.RocketPool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if p.StakeAmount != nil && p.StakeAmount.Sign() > 0 {
+		return rewards.EstimateClaimAndStakeGas(rp, p.NodeAddress, p.Indices, p.AmountRPL, p.AmountETH, p.MerkleProofs, p.StakeAmount, opts)
+	}
+	return rewards.EstimateClaimGas(rp, p.NodeAddress, p.Indices, p.AmountRPL, p.AmountETH, p.MerkleProofs, opts)
+}
+
+// Submit the plan's claim
+func (p *ClaimPlan) Submit(rp *rocketpool.RocketPool, opts *bind.TransactOpts) (common.Hash, error) {
+	if p.StakeAmount != nil && p.StakeAmount.Sign() > 0 {
+		return rewards.ClaimAndStake(rp, p.NodeAddress, p.Indices, p.AmountRPL, p.AmountETH, p.MerkleProofs, p.StakeAmount, opts)
+	}
+	return rewards.Claim(rp, p.NodeAddress, p.Indices, p.AmountRPL, p.AmountETH, p.MerkleProofs, opts)
+}
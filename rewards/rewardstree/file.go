@@ -0,0 +1,452 @@
+package rewardstree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// A node's rewards for an interval
+type NodeRewards struct {
+	Address          common.Address `json:"address"`
+	Network          uint64         `json:"network"`
+	CollateralRpl    *big.Int       `json:"collateralRpl"`
+	OracleDaoRpl     *big.Int       `json:"oracleDaoRpl"`
+	SmoothingPoolEth *big.Int       `json:"smoothingPoolEth"`
+	MerkleProof      []common.Hash  `json:"merkleProof"`
+}
+
+// Get the total RPL a node can claim, which is its collateral rewards plus its Oracle DAO rewards
+func (r *NodeRewards) GetTotalRpl() *big.Int {
+	return big.NewInt(0).Add(r.CollateralRpl, r.OracleDaoRpl)
+}
+
+// The rewards for a single network
+type NetworkRewards struct {
+	Network          uint64   `json:"network"`
+	CollateralRpl    *big.Int `json:"collateralRpl"`
+	OracleDaoRpl     *big.Int `json:"oracleDaoRpl"`
+	SmoothingPoolEth *big.Int `json:"smoothingPoolEth"`
+}
+
+// The rewards distributed across every network
+type TotalRewards struct {
+	ProtocolDaoRpl               *big.Int `json:"protocolDaoRpl"`
+	TotalCollateralRpl           *big.Int `json:"totalCollateralRpl"`
+	TotalOracleDaoRpl            *big.Int `json:"totalOracleDaoRpl"`
+	TotalSmoothingPoolEth        *big.Int `json:"totalSmoothingPoolEth"`
+	PoolStakerSmoothingPoolEth   *big.Int `json:"poolStakerSmoothingPoolEth"`
+	NodeOperatorSmoothingPoolEth *big.Int `json:"nodeOperatorSmoothingPoolEth"`
+	TotalNodeWeight              *big.Int `json:"totalNodeWeight,omitempty"`
+}
+
+// An interval's rewards tree file.
+// Version 1 files store network and node rewards as objects keyed by network ID and node address; version 2 and 3
+// files use the SSZ-style layout, where they are lists of entries that carry their own network ID and address.
+// Node rewards are kept in address order regardless of the layout.
+type RewardsFile struct {
+	RewardsFileVersion         uint64           `json:"rewardsFileVersion"`
+	RulesetVersion             uint64           `json:"rulesetVersion,omitempty"`
+	Index                      uint64           `json:"index"`
+	Network                    string           `json:"network"`
+	StartTime                  time.Time        `json:"startTime"`
+	EndTime                    time.Time        `json:"endTime"`
+	ConsensusStartBlock        uint64           `json:"consensusStartBlock"`
+	ConsensusEndBlock          uint64           `json:"consensusEndBlock"`
+	ExecutionStartBlock        uint64           `json:"executionStartBlock"`
+	ExecutionEndBlock          uint64           `json:"executionEndBlock"`
+	IntervalsPassed            uint64           `json:"intervalsPassed"`
+	MerkleRoot                 common.Hash      `json:"merkleRoot"`
+	MinipoolPerformanceFileCID string           `json:"minipoolPerformanceFileCid,omitempty"`
+	TotalRewards               TotalRewards     `json:"totalRewards"`
+	NetworkRewards             []NetworkRewards `json:"networkRewards"`
+	NodeRewards                []NodeRewards    `json:"nodeRewards"`
+}
+
+// Get a node's rewards
+func (f *RewardsFile) GetNodeRewards(address common.Address) (*NodeRewards, bool) {
+	index := sort.Search(len(f.NodeRewards), func(i int) bool {
+		return bytes.Compare(f.NodeRewards[i].Address.Bytes(), address.Bytes()) >= 0
+	})
+	if index < len(f.NodeRewards) && f.NodeRewards[index].Address == address {
+		return &f.NodeRewards[index], true
+	}
+	return nil, false
+}
+
+// Load a rewards tree file from disk
+func LoadRewardsFile(path string) (*RewardsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rewards file %s: %w", path, err)
+	}
+	file, err := ParseRewardsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing rewards file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Parse a rewards tree file in either layout
+func ParseRewardsFile(data []byte) (*RewardsFile, error) {
+	var raw rawRewardsFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error decoding rewards file: %w", err)
+	}
+	file := &RewardsFile{
+		RewardsFileVersion:         raw.RewardsFileVersion,
+		RulesetVersion:             raw.RulesetVersion,
+		Index:                      raw.Index,
+		Network:                    raw.Network,
+		StartTime:                  raw.StartTime,
+		EndTime:                    raw.EndTime,
+		ConsensusStartBlock:        raw.ConsensusStartBlock,
+		ConsensusEndBlock:          raw.ConsensusEndBlock,
+		ExecutionStartBlock:        raw.ExecutionStartBlock,
+		ExecutionEndBlock:          raw.ExecutionEndBlock,
+		IntervalsPassed:            raw.IntervalsPassed,
+		MerkleRoot:                 raw.MerkleRoot,
+		MinipoolPerformanceFileCID: raw.MinipoolPerformanceFileCID,
+		TotalRewards: TotalRewards{
+			ProtocolDaoRpl:               raw.TotalRewards.ProtocolDaoRpl.toInt(),
+			TotalCollateralRpl:           raw.TotalRewards.TotalCollateralRpl.toInt(),
+			TotalOracleDaoRpl:            raw.TotalRewards.TotalOracleDaoRpl.toInt(),
+			TotalSmoothingPoolEth:        raw.TotalRewards.TotalSmoothingPoolEth.toInt(),
+			PoolStakerSmoothingPoolEth:   raw.TotalRewards.PoolStakerSmoothingPoolEth.toInt(),
+			NodeOperatorSmoothingPoolEth: raw.TotalRewards.NodeOperatorSmoothingPoolEth.toInt(),
+		},
+		NetworkRewards: []NetworkRewards{},
+		NodeRewards:    []NodeRewards{},
+	}
+	if raw.TotalRewards.TotalNodeWeight != nil {
+		file.TotalRewards.TotalNodeWeight = raw.TotalRewards.TotalNodeWeight.toInt()
+	}
+
+	// Network rewards
+	if isJSONObject(raw.NetworkRewards) {
+		networkRewards := map[string]rawNetworkRewardsV1{}
+		if err := json.Unmarshal(raw.NetworkRewards, &networkRewards); err != nil {
+			return nil, fmt.Errorf("error decoding network rewards: %w", err)
+		}
+		for key, rewards := range networkRewards {
+			network, err := strconv.ParseUint(key, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network ID %q: %w", key, err)
+			}
+			file.NetworkRewards = append(file.NetworkRewards, rewards.toNetworkRewards(network))
+		}
+	} else if len(raw.NetworkRewards) > 0 {
+		networkRewards := []rawNetworkRewardsV3{}
+		if err := json.Unmarshal(raw.NetworkRewards, &networkRewards); err != nil {
+			return nil, fmt.Errorf("error decoding network rewards: %w", err)
+		}
+		for _, rewards := range networkRewards {
+			file.NetworkRewards = append(file.NetworkRewards, rewards.toNetworkRewards())
+		}
+	}
+	sort.Slice(file.NetworkRewards, func(i, j int) bool {
+		return file.NetworkRewards[i].Network < file.NetworkRewards[j].Network
+	})
+
+	// Node rewards
+	if isJSONObject(raw.NodeRewards) {
+		nodeRewards := map[string]rawNodeRewardsV1{}
+		if err := json.Unmarshal(raw.NodeRewards, &nodeRewards); err != nil {
+			return nil, fmt.Errorf("error decoding node rewards: %w", err)
+		}
+		for key, rewards := range nodeRewards {
+			if !common.IsHexAddress(key) {
+				return nil, fmt.Errorf("invalid node address %q", key)
+			}
+			file.NodeRewards = append(file.NodeRewards, rewards.toNodeRewards(common.HexToAddress(key)))
+		}
+	} else if len(raw.NodeRewards) > 0 {
+		nodeRewards := []rawNodeRewardsV3{}
+		if err := json.Unmarshal(raw.NodeRewards, &nodeRewards); err != nil {
+			return nil, fmt.Errorf("error decoding node rewards: %w", err)
+		}
+		for _, rewards := range nodeRewards {
+			file.NodeRewards = append(file.NodeRewards, rewards.toNodeRewards())
+		}
+	}
+	sortNodeRewards(file.NodeRewards)
+	for i := 1; i < len(file.NodeRewards); i++ {
+		if file.NodeRewards[i].Address == file.NodeRewards[i-1].Address {
+			return nil, fmt.Errorf("node %s has more than one rewards entry", file.NodeRewards[i].Address.Hex())
+		}
+	}
+
+	return file, nil
+}
+
+// Serialize a rewards tree file in the layout of its version
+func (f *RewardsFile) Serialize() ([]byte, error) {
+	raw := rawRewardsFile{
+		RewardsFileVersion:         f.RewardsFileVersion,
+		RulesetVersion:             f.RulesetVersion,
+		Index:                      f.Index,
+		Network:                    f.Network,
+		StartTime:                  f.StartTime,
+		EndTime:                    f.EndTime,
+		ConsensusStartBlock:        f.ConsensusStartBlock,
+		ConsensusEndBlock:          f.ConsensusEndBlock,
+		ExecutionStartBlock:        f.ExecutionStartBlock,
+		ExecutionEndBlock:          f.ExecutionEndBlock,
+		IntervalsPassed:            f.IntervalsPassed,
+		MerkleRoot:                 f.MerkleRoot,
+		MinipoolPerformanceFileCID: f.MinipoolPerformanceFileCID,
+		TotalRewards: rawTotalRewards{
+			ProtocolDaoRpl:               newQuotedBig(f.TotalRewards.ProtocolDaoRpl),
+			TotalCollateralRpl:           newQuotedBig(f.TotalRewards.TotalCollateralRpl),
+			TotalOracleDaoRpl:            newQuotedBig(f.TotalRewards.TotalOracleDaoRpl),
+			TotalSmoothingPoolEth:        newQuotedBig(f.TotalRewards.TotalSmoothingPoolEth),
+			PoolStakerSmoothingPoolEth:   newQuotedBig(f.TotalRewards.PoolStakerSmoothingPoolEth),
+			NodeOperatorSmoothingPoolEth: newQuotedBig(f.TotalRewards.NodeOperatorSmoothingPoolEth),
+		},
+	}
+	if f.TotalRewards.TotalNodeWeight != nil {
+		weight := newQuotedBig(f.TotalRewards.TotalNodeWeight)
+		raw.TotalRewards.TotalNodeWeight = &weight
+	}
+
+	var err error
+	if f.RewardsFileVersion <= 1 {
+		// Keyed layout
+		networkRewards := map[string]rawNetworkRewardsV1{}
+		for _, rewards := range f.NetworkRewards {
+			networkRewards[strconv.FormatUint(rewards.Network, 10)] = newRawNetworkRewardsV1(rewards)
+		}
+		nodeRewards := map[string]rawNodeRewardsV1{}
+		for _, rewards := range f.NodeRewards {
+			nodeRewards[rewards.Address.Hex()] = newRawNodeRewardsV1(rewards)
+		}
+		if raw.NetworkRewards, err = json.Marshal(networkRewards); err != nil {
+			return nil, err
+		}
+		if raw.NodeRewards, err = json.Marshal(nodeRewards); err != nil {
+			return nil, err
+		}
+	} else {
+		// SSZ-style layout
+		networkRewards := make([]rawNetworkRewardsV3, len(f.NetworkRewards))
+		for i, rewards := range f.NetworkRewards {
+			networkRewards[i] = newRawNetworkRewardsV3(rewards)
+		}
+		nodeRewards := make([]rawNodeRewardsV3, len(f.NodeRewards))
+		for i, rewards := range f.NodeRewards {
+			nodeRewards[i] = newRawNodeRewardsV3(rewards)
+		}
+		if raw.NetworkRewards, err = json.Marshal(networkRewards); err != nil {
+			return nil, err
+		}
+		if raw.NodeRewards, err = json.Marshal(nodeRewards); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(raw, "", "  ")
+}
+
+// Sort node rewards by address
+func sortNodeRewards(nodeRewards []NodeRewards) {
+	sort.Slice(nodeRewards, func(i, j int) bool {
+		return bytes.Compare(nodeRewards[i].Address.Bytes(), nodeRewards[j].Address.Bytes()) < 0
+	})
+}
+
+// Check if a raw JSON value is an object
+func isJSONObject(data json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// The on-disk layout of a rewards tree file
+type rawRewardsFile struct {
+	RewardsFileVersion         uint64          `json:"rewardsFileVersion"`
+	RulesetVersion             uint64          `json:"rulesetVersion,omitempty"`
+	Index                      uint64          `json:"index"`
+	Network                    string          `json:"network"`
+	StartTime                  time.Time       `json:"startTime"`
+	EndTime                    time.Time       `json:"endTime"`
+	ConsensusStartBlock        uint64          `json:"consensusStartBlock"`
+	ConsensusEndBlock          uint64          `json:"consensusEndBlock"`
+	ExecutionStartBlock        uint64          `json:"executionStartBlock"`
+	ExecutionEndBlock          uint64          `json:"executionEndBlock"`
+	IntervalsPassed            uint64          `json:"intervalsPassed"`
+	MerkleRoot                 common.Hash     `json:"merkleRoot"`
+	MinipoolPerformanceFileCID string          `json:"minipoolPerformanceFileCid,omitempty"`
+	TotalRewards               rawTotalRewards `json:"totalRewards"`
+	NetworkRewards             json.RawMessage `json:"networkRewards"`
+	NodeRewards                json.RawMessage `json:"nodeRewards"`
+}
+
+type rawTotalRewards struct {
+	ProtocolDaoRpl               quotedBig  `json:"protocolDaoRpl"`
+	TotalCollateralRpl           quotedBig  `json:"totalCollateralRpl"`
+	TotalOracleDaoRpl            quotedBig  `json:"totalOracleDaoRpl"`
+	TotalSmoothingPoolEth        quotedBig  `json:"totalSmoothingPoolEth"`
+	PoolStakerSmoothingPoolEth   quotedBig  `json:"poolStakerSmoothingPoolEth"`
+	NodeOperatorSmoothingPoolEth quotedBig  `json:"nodeOperatorSmoothingPoolEth"`
+	TotalNodeWeight              *quotedBig `json:"totalNodeWeight,omitempty"`
+}
+
+// Network rewards in the version 1 layout, keyed by network ID
+type rawNetworkRewardsV1 struct {
+	CollateralRpl    quotedBig `json:"collateralRpl"`
+	OracleDaoRpl     quotedBig `json:"oracleDaoRpl"`
+	SmoothingPoolEth quotedBig `json:"smoothingPoolEth"`
+}
+
+func newRawNetworkRewardsV1(rewards NetworkRewards) rawNetworkRewardsV1 {
+	return rawNetworkRewardsV1{
+		CollateralRpl:    newQuotedBig(rewards.CollateralRpl),
+		OracleDaoRpl:     newQuotedBig(rewards.OracleDaoRpl),
+		SmoothingPoolEth: newQuotedBig(rewards.SmoothingPoolEth),
+	}
+}
+
+func (r rawNetworkRewardsV1) toNetworkRewards(network uint64) NetworkRewards {
+	return NetworkRewards{
+		Network:          network,
+		CollateralRpl:    r.CollateralRpl.toInt(),
+		OracleDaoRpl:     r.OracleDaoRpl.toInt(),
+		SmoothingPoolEth: r.SmoothingPoolEth.toInt(),
+	}
+}
+
+// Network rewards in the SSZ-style layout of versions 2 and 3
+type rawNetworkRewardsV3 struct {
+	Network          uint64    `json:"network"`
+	CollateralRpl    quotedBig `json:"collateralRpl"`
+	OracleDaoRpl     quotedBig `json:"oracleDaoRpl"`
+	SmoothingPoolEth quotedBig `json:"smoothingPoolEth"`
+}
+
+func newRawNetworkRewardsV3(rewards NetworkRewards) rawNetworkRewardsV3 {
+	return rawNetworkRewardsV3{
+		Network:          rewards.Network,
+		CollateralRpl:    newQuotedBig(rewards.CollateralRpl),
+		OracleDaoRpl:     newQuotedBig(rewards.OracleDaoRpl),
+		SmoothingPoolEth: newQuotedBig(rewards.SmoothingPoolEth),
+	}
+}
+
+func (r rawNetworkRewardsV3) toNetworkRewards() NetworkRewards {
+	return NetworkRewards{
+		Network:          r.Network,
+		CollateralRpl:    r.CollateralRpl.toInt(),
+		OracleDaoRpl:     r.OracleDaoRpl.toInt(),
+		SmoothingPoolEth: r.SmoothingPoolEth.toInt(),
+	}
+}
+
+// Node rewards in the version 1 layout, keyed by node address
+type rawNodeRewardsV1 struct {
+	RewardNetwork    uint64        `json:"rewardNetwork"`
+	CollateralRpl    quotedBig     `json:"collateralRpl"`
+	OracleDaoRpl     quotedBig     `json:"oracleDaoRpl"`
+	SmoothingPoolEth quotedBig     `json:"smoothingPoolEth"`
+	MerkleProof      []common.Hash `json:"merkleProof"`
+}
+
+func newRawNodeRewardsV1(rewards NodeRewards) rawNodeRewardsV1 {
+	return rawNodeRewardsV1{
+		RewardNetwork:    rewards.Network,
+		CollateralRpl:    newQuotedBig(rewards.CollateralRpl),
+		OracleDaoRpl:     newQuotedBig(rewards.OracleDaoRpl),
+		SmoothingPoolEth: newQuotedBig(rewards.SmoothingPoolEth),
+		MerkleProof:      getMerkleProof(rewards.MerkleProof),
+	}
+}
+
+func (r rawNodeRewardsV1) toNodeRewards(address common.Address) NodeRewards {
+	return NodeRewards{
+		Address:          address,
+		Network:          r.RewardNetwork,
+		CollateralRpl:    r.CollateralRpl.toInt(),
+		OracleDaoRpl:     r.OracleDaoRpl.toInt(),
+		SmoothingPoolEth: r.SmoothingPoolEth.toInt(),
+		MerkleProof:      getMerkleProof(r.MerkleProof),
+	}
+}
+
+// Node rewards in the SSZ-style layout of versions 2 and 3
+type rawNodeRewardsV3 struct {
+	Address          common.Address `json:"address"`
+	Network          uint64         `json:"network"`
+	CollateralRpl    quotedBig      `json:"collateralRpl"`
+	OracleDaoRpl     quotedBig      `json:"oracleDaoRpl"`
+	SmoothingPoolEth quotedBig      `json:"smoothingPoolEth"`
+	MerkleProof      []common.Hash  `json:"merkleProof"`
+}
+
+func newRawNodeRewardsV3(rewards NodeRewards) rawNodeRewardsV3 {
+	return rawNodeRewardsV3{
+		Address:          rewards.Address,
+		Network:          rewards.Network,
+		CollateralRpl:    newQuotedBig(rewards.CollateralRpl),
+		OracleDaoRpl:     newQuotedBig(rewards.OracleDaoRpl),
+		SmoothingPoolEth: newQuotedBig(rewards.SmoothingPoolEth),
+		MerkleProof:      getMerkleProof(rewards.MerkleProof),
+	}
+}
+
+func (r rawNodeRewardsV3) toNodeRewards() NodeRewards {
+	return NodeRewards{
+		Address:          r.Address,
+		Network:          r.Network,
+		CollateralRpl:    r.CollateralRpl.toInt(),
+		OracleDaoRpl:     r.OracleDaoRpl.toInt(),
+		SmoothingPoolEth: r.SmoothingPoolEth.toInt(),
+		MerkleProof:      getMerkleProof(r.MerkleProof),
+	}
+}
+
+// Get a Merkle proof that serializes as an empty list rather than null
+func getMerkleProof(proof []common.Hash) []common.Hash {
+	if proof == nil {
+		return []common.Hash{}
+	}
+	return proof
+}
+
+// A big integer serialized as a quoted decimal string, which also accepts plain JSON numbers
+type quotedBig big.Int
+
+func newQuotedBig(value *big.Int) quotedBig {
+	if value == nil {
+		return quotedBig{}
+	}
+	return quotedBig(*value)
+}
+
+func (q quotedBig) toInt() *big.Int {
+	value := big.Int(q)
+	return big.NewInt(0).Set(&value)
+}
+
+func (q quotedBig) MarshalJSON() ([]byte, error) {
+	value := big.Int(q)
+	return []byte(`"` + value.String() + `"`), nil
+}
+
+func (q *quotedBig) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*q = quotedBig{}
+		return nil
+	}
+	value, ok := big.NewInt(0).SetString(text, 10)
+	if !ok {
+		return fmt.Errorf("invalid integer %s", string(data))
+	}
+	*q = quotedBig(*value)
+	return nil
+}
@@ -0,0 +1,352 @@
+package rewardstree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// The rewards file version produced by the generator
+const GeneratedRewardsFileVersion uint64 = 3
+
+// The ruleset version of generated files. The generator doesn't implement a Smartnode ruleset: it takes attestation
+// totals rather than per-duty performance, and doesn't track smoothing pool opt-in and opt-out windows or the bond and
+// fee of each eligible slot. Generated files are therefore left without a ruleset version, so they can't be mistaken
+// for canonical trees, and their roots won't match the ones the Oracle DAO submits.
+const GeneratedRulesetVersion uint64 = 0
+
+// The attestation performance of a minipool's validator over an interval
+type AttestationPerformance struct {
+	Successful uint64 `json:"successful"`
+	Missed     uint64 `json:"missed"`
+}
+
+// Beacon chain data for an interval, keyed by minipool address
+type BeaconInputs struct {
+	// The attestation performance of each minipool's validator
+	Attestations map[common.Address]AttestationPerformance `json:"attestations"`
+
+	// The balance of each minipool's validator at the end of the interval, in wei.
+	// Validators without a positive balance are treated as inactive; if nil, every staking minipool is active.
+	Balances map[common.Address]*big.Int `json:"balances"`
+
+	// The nodes that were in the smoothing pool for the interval; if nil, the registration status in the state is used
+	SmoothingPoolMembers map[common.Address]bool `json:"smoothingPoolMembers"`
+}
+
+// The details of the interval being generated
+type IntervalSettings struct {
+	Index               uint64    `json:"index"`
+	IntervalsPassed     uint64    `json:"intervalsPassed"`
+	Network             string    `json:"network"`
+	StartTime           time.Time `json:"startTime"`
+	EndTime             time.Time `json:"endTime"`
+	ConsensusStartBlock uint64    `json:"consensusStartBlock"`
+	ConsensusEndBlock   uint64    `json:"consensusEndBlock"`
+	ExecutionStartBlock uint64    `json:"executionStartBlock"`
+	ExecutionEndBlock   uint64    `json:"executionEndBlock"`
+}
+
+// The output of the rewards generator
+type GeneratedRewards struct {
+	File *RewardsFile
+	Tree *RewardsTree
+}
+
+// Generate an interval's rewards from a network snapshot taken at the end of the interval.
+// RPL rewards are split by the network's claimer percentages; node operator rewards are shared by effective RPL
+// stake and Oracle DAO rewards are shared equally, both prorated for nodes and members that joined mid-interval.
+// Rounding remainders go to the protocol DAO. Smoothing pool ETH is shared by attestation score, where each successful
+// attestation earns the node its bond fraction plus its commission on the borrowed ETH; the rest goes to the pool stakers.
+// The output is deterministic for the same inputs, but it's an estimate rather than a canonical tree: it can't be used
+// to verify or reproduce an interval the Oracle DAO submitted (see GeneratedRulesetVersion).
+func GenerateRewards(networkState *state.NetworkState, beacon *BeaconInputs, settings IntervalSettings) (*GeneratedRewards, error) {
+	if networkState.NetworkDetails == nil {
+		return nil, errors.New("network state has no network details")
+	}
+	if !settings.EndTime.After(settings.StartTime) {
+		return nil, fmt.Errorf("interval end time %s is not after its start time %s", settings.EndTime, settings.StartTime)
+	}
+	if beacon == nil {
+		beacon = &BeaconInputs{}
+	}
+	if settings.ExecutionEndBlock == 0 {
+		settings.ExecutionEndBlock = networkState.ElBlockNumber
+	}
+	details := networkState.NetworkDetails
+	one := big.NewInt(1e18)
+
+	// Split the pending RPL rewards
+	pendingRpl := details.PendingRPLRewards
+	nodeOperatorRpl := mulDiv(pendingRpl, details.NodeOperatorRewardsPercent, one)
+	oracleDaoRpl := mulDiv(pendingRpl, details.TrustedNodeOperatorRewardsPercent, one)
+	protocolDaoRpl := big.NewInt(0).Sub(pendingRpl, nodeOperatorRpl)
+	protocolDaoRpl.Sub(protocolDaoRpl, oracleDaoRpl)
+
+	// Get the nodes in address order
+	nodes := make([]*state.NativeNodeDetails, len(networkState.NodeDetails))
+	for i := range networkState.NodeDetails {
+		nodes[i] = &networkState.NodeDetails[i]
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].NodeAddress.Bytes(), nodes[j].NodeAddress.Bytes()) < 0
+	})
+	allocations := map[common.Address]*NodeRewards{}
+	getAllocation := func(address common.Address) *NodeRewards {
+		allocation, exists := allocations[address]
+		if !exists {
+			allocation = &NodeRewards{
+				Address:          address,
+				CollateralRpl:    big.NewInt(0),
+				OracleDaoRpl:     big.NewInt(0),
+				SmoothingPoolEth: big.NewInt(0),
+			}
+			allocations[address] = allocation
+		}
+		return allocation
+	}
+	for _, node := range nodes {
+		allocation := getAllocation(node.NodeAddress)
+		if node.RewardNetwork != nil {
+			allocation.Network = node.RewardNetwork.Uint64()
+		}
+	}
+
+	// Node operator rewards
+	intervalSeconds := big.NewInt(int64(settings.EndTime.Sub(settings.StartTime).Seconds()))
+	nodeWeights := make([]*big.Int, len(nodes))
+	totalNodeWeight := big.NewInt(0)
+	for i, node := range nodes {
+		nodeWeights[i] = big.NewInt(0)
+		if !node.Exists || node.EffectiveRPLStake == nil || node.EffectiveRPLStake.Sign() <= 0 {
+			continue
+		}
+		var registrationTime time.Time
+		if node.RegistrationTime != nil {
+			registrationTime = time.Unix(node.RegistrationTime.Int64(), 0)
+		}
+		nodeWeights[i] = prorate(node.EffectiveRPLStake, registrationTime, settings.StartTime, settings.EndTime, intervalSeconds)
+		totalNodeWeight.Add(totalNodeWeight, nodeWeights[i])
+	}
+	totalCollateralRpl := big.NewInt(0)
+	if totalNodeWeight.Sign() > 0 {
+		for i, node := range nodes {
+			if nodeWeights[i].Sign() == 0 {
+				continue
+			}
+			amount := mulDiv(nodeOperatorRpl, nodeWeights[i], totalNodeWeight)
+			getAllocation(node.NodeAddress).CollateralRpl.Add(getAllocation(node.NodeAddress).CollateralRpl, amount)
+			totalCollateralRpl.Add(totalCollateralRpl, amount)
+		}
+	}
+	protocolDaoRpl.Add(protocolDaoRpl, big.NewInt(0).Sub(nodeOperatorRpl, totalCollateralRpl))
+
+	// Oracle DAO rewards
+	members := make([]*state.OracleDaoMemberDetails, 0, len(networkState.OracleDaoMemberDetails))
+	for i := range networkState.OracleDaoMemberDetails {
+		if networkState.OracleDaoMemberDetails[i].Exists {
+			members = append(members, &networkState.OracleDaoMemberDetails[i])
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return bytes.Compare(members[i].Address.Bytes(), members[j].Address.Bytes()) < 0
+	})
+	memberWeights := make([]*big.Int, len(members))
+	totalMemberWeight := big.NewInt(0)
+	for i, member := range members {
+		memberWeights[i] = prorate(one, member.JoinedTime, settings.StartTime, settings.EndTime, intervalSeconds)
+		totalMemberWeight.Add(totalMemberWeight, memberWeights[i])
+	}
+	totalOracleDaoRpl := big.NewInt(0)
+	if totalMemberWeight.Sign() > 0 {
+		for i, member := range members {
+			if memberWeights[i].Sign() == 0 {
+				continue
+			}
+			amount := mulDiv(oracleDaoRpl, memberWeights[i], totalMemberWeight)
+			getAllocation(member.Address).OracleDaoRpl.Add(getAllocation(member.Address).OracleDaoRpl, amount)
+			totalOracleDaoRpl.Add(totalOracleDaoRpl, amount)
+		}
+	}
+	protocolDaoRpl.Add(protocolDaoRpl, big.NewInt(0).Sub(oracleDaoRpl, totalOracleDaoRpl))
+
+	// Smoothing pool rewards
+	smoothingPoolEth, nodeOperatorEth, err := allocateSmoothingPool(networkState, beacon, getAllocation)
+	if err != nil {
+		return nil, err
+	}
+	poolStakerEth := big.NewInt(0).Sub(smoothingPoolEth, nodeOperatorEth)
+
+	// Build the file
+	file := &RewardsFile{
+		RewardsFileVersion:  GeneratedRewardsFileVersion,
+		RulesetVersion:      GeneratedRulesetVersion,
+		Index:               settings.Index,
+		Network:             settings.Network,
+		StartTime:           settings.StartTime,
+		EndTime:             settings.EndTime,
+		ConsensusStartBlock: settings.ConsensusStartBlock,
+		ConsensusEndBlock:   settings.ConsensusEndBlock,
+		ExecutionStartBlock: settings.ExecutionStartBlock,
+		ExecutionEndBlock:   settings.ExecutionEndBlock,
+		IntervalsPassed:     settings.IntervalsPassed,
+		TotalRewards: TotalRewards{
+			ProtocolDaoRpl:               protocolDaoRpl,
+			TotalCollateralRpl:           totalCollateralRpl,
+			TotalOracleDaoRpl:            totalOracleDaoRpl,
+			TotalSmoothingPoolEth:        big.NewInt(0).Set(smoothingPoolEth),
+			PoolStakerSmoothingPoolEth:   poolStakerEth,
+			NodeOperatorSmoothingPoolEth: nodeOperatorEth,
+		},
+		NetworkRewards: []NetworkRewards{},
+		NodeRewards:    []NodeRewards{},
+	}
+	networkRewards := map[uint64]*NetworkRewards{}
+	for _, allocation := range allocations {
+		if allocation.GetTotalRpl().Sign() == 0 && allocation.SmoothingPoolEth.Sign() == 0 {
+			continue
+		}
+		file.NodeRewards = append(file.NodeRewards, *allocation)
+		network, exists := networkRewards[allocation.Network]
+		if !exists {
+			network = &NetworkRewards{
+				Network:          allocation.Network,
+				CollateralRpl:    big.NewInt(0),
+				OracleDaoRpl:     big.NewInt(0),
+				SmoothingPoolEth: big.NewInt(0),
+			}
+			networkRewards[allocation.Network] = network
+		}
+		network.CollateralRpl.Add(network.CollateralRpl, allocation.CollateralRpl)
+		network.OracleDaoRpl.Add(network.OracleDaoRpl, allocation.OracleDaoRpl)
+		network.SmoothingPoolEth.Add(network.SmoothingPoolEth, allocation.SmoothingPoolEth)
+	}
+	sortNodeRewards(file.NodeRewards)
+	for _, rewards := range networkRewards {
+		file.NetworkRewards = append(file.NetworkRewards, *rewards)
+	}
+	sort.Slice(file.NetworkRewards, func(i, j int) bool {
+		return file.NetworkRewards[i].Network < file.NetworkRewards[j].Network
+	})
+
+	// Build the tree
+	tree, err := file.GenerateProofs()
+	if err != nil {
+		return nil, fmt.Errorf("error generating rewards tree for interval %d: %w", settings.Index, err)
+	}
+	file.MerkleRoot = tree.GetRoot()
+
+	return &GeneratedRewards{
+		File: file,
+		Tree: tree,
+	}, nil
+
+}
+
+// Allocate the smoothing pool balance to node operators by attestation score.
+// Returns the smoothing pool balance and the total allocated to node operators.
+func allocateSmoothingPool(networkState *state.NetworkState, beacon *BeaconInputs, getAllocation func(common.Address) *NodeRewards) (*big.Int, *big.Int, error) {
+	smoothingPoolEth := big.NewInt(0)
+	if networkState.NetworkDetails.SmoothingPoolBalance != nil {
+		smoothingPoolEth.Set(networkState.NetworkDetails.SmoothingPoolBalance)
+	}
+	nodeOperatorEth := big.NewInt(0)
+	if smoothingPoolEth.Sign() == 0 {
+		return smoothingPoolEth, nodeOperatorEth, nil
+	}
+
+	// Get the smoothing pool members
+	members := beacon.SmoothingPoolMembers
+	if members == nil {
+		members = map[common.Address]bool{}
+		for _, node := range networkState.NodeDetails {
+			members[node.NodeAddress] = node.SmoothingPoolRegistrationState
+		}
+	}
+
+	// Get the eligible minipools in address order
+	minipools := []*state.NativeMinipoolDetails{}
+	for i := range networkState.MinipoolDetails {
+		mpd := &networkState.MinipoolDetails[i]
+		if mpd.Status != types.Staking || mpd.Finalised || !members[mpd.NodeAddress] {
+			continue
+		}
+		if beacon.Balances != nil {
+			balance, exists := beacon.Balances[mpd.MinipoolAddress]
+			if !exists || balance == nil || balance.Sign() <= 0 {
+				continue
+			}
+		}
+		minipools = append(minipools, mpd)
+	}
+	sort.Slice(minipools, func(i, j int) bool {
+		return bytes.Compare(minipools[i].MinipoolAddress.Bytes(), minipools[j].MinipoolAddress.Bytes()) < 0
+	})
+
+	// Score each minipool's attestations by the node's share of its rewards
+	one := big.NewInt(1e18)
+	scores := make([]*big.Int, len(minipools))
+	totalScore := big.NewInt(0)
+	totalAttestations := big.NewInt(0)
+	for i, mpd := range minipools {
+		scores[i] = big.NewInt(0)
+		performance := beacon.Attestations[mpd.MinipoolAddress]
+		if performance.Successful == 0 {
+			continue
+		}
+		bond := mpd.NodeDepositBalance
+		borrowed := mpd.UserDepositBalance
+		total := big.NewInt(0).Add(bond, borrowed)
+		if total.Sign() == 0 {
+			return nil, nil, fmt.Errorf("minipool %s has no deposit balance", mpd.MinipoolAddress.Hex())
+		}
+		share := big.NewInt(0).Mul(bond, one)
+		share.Add(share, big.NewInt(0).Mul(borrowed, mpd.NodeFee))
+		share.Div(share, total)
+		scores[i].Mul(share, big.NewInt(0).SetUint64(performance.Successful))
+		totalScore.Add(totalScore, scores[i])
+		totalAttestations.Add(totalAttestations, big.NewInt(0).SetUint64(performance.Successful))
+	}
+	if totalScore.Sign() == 0 {
+		return smoothingPoolEth, nodeOperatorEth, nil
+	}
+
+	// Allocate the node operator share
+	nodeOperatorShare := big.NewInt(0).Mul(smoothingPoolEth, totalScore)
+	nodeOperatorShare.Div(nodeOperatorShare, big.NewInt(0).Mul(totalAttestations, one))
+	for i, mpd := range minipools {
+		if scores[i].Sign() == 0 {
+			continue
+		}
+		amount := mulDiv(nodeOperatorShare, scores[i], totalScore)
+		allocation := getAllocation(mpd.NodeAddress)
+		allocation.SmoothingPoolEth.Add(allocation.SmoothingPoolEth, amount)
+		nodeOperatorEth.Add(nodeOperatorEth, amount)
+	}
+	return smoothingPoolEth, nodeOperatorEth, nil
+}
+
+// Prorate a weight by the portion of the interval after a join time
+func prorate(weight *big.Int, joined time.Time, start time.Time, end time.Time, intervalSeconds *big.Int) *big.Int {
+	if joined.After(end) {
+		return big.NewInt(0)
+	}
+	if !joined.After(start) {
+		return big.NewInt(0).Set(weight)
+	}
+	return mulDiv(weight, big.NewInt(int64(end.Sub(joined).Seconds())), intervalSeconds)
+}
+
+// Get a * b / c
+func mulDiv(a *big.Int, b *big.Int, c *big.Int) *big.Int {
+	result := big.NewInt(0).Mul(a, b)
+	return result.Div(result, c)
+}
@@ -0,0 +1,94 @@
+package rewardstree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Returned by a store that doesn't have the rewards file for an interval
+var ErrRewardsFileNotFound = errors.New("rewards file not found")
+
+// A source of interval rewards tree files
+type TreeStore interface {
+	// Get the rewards file for an interval, or ErrRewardsFileNotFound if the store doesn't have it
+	GetRewardsFile(index uint64) (*RewardsFile, error)
+}
+
+// A store that reads rewards files from a directory, using the Smartnode's file naming (e.g. rp-rewards-mainnet-4.json)
+type DirectoryStore struct {
+	Path    string
+	Network string
+}
+
+// Create a new directory store
+func NewDirectoryStore(path string, network string) *DirectoryStore {
+	return &DirectoryStore{
+		Path:    path,
+		Network: network,
+	}
+}
+
+// Get the path of the rewards file for an interval
+func (s *DirectoryStore) GetFilePath(index uint64) string {
+	return filepath.Join(s.Path, fmt.Sprintf("rp-rewards-%s-%d.json", s.Network, index))
+}
+
+// Get the rewards file for an interval
+func (s *DirectoryStore) GetRewardsFile(index uint64) (*RewardsFile, error) {
+	path := s.GetFilePath(index)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: interval %d (%s)", ErrRewardsFileNotFound, index, path)
+	}
+	return LoadRewardsFile(path)
+}
+
+// Save the rewards file for an interval
+func (s *DirectoryStore) SaveRewardsFile(file *RewardsFile) error {
+	data, err := file.Serialize()
+	if err != nil {
+		return fmt.Errorf("error serializing rewards file for interval %d: %w", file.Index, err)
+	}
+	if err := os.WriteFile(s.GetFilePath(file.Index), data, 0644); err != nil {
+		return fmt.Errorf("error saving rewards file for interval %d: %w", file.Index, err)
+	}
+	return nil
+}
+
+// A store that keeps rewards files in memory
+type MemoryStore struct {
+	Files map[uint64]*RewardsFile
+	lock  sync.Mutex
+}
+
+// Create a new memory store
+func NewMemoryStore(files ...*RewardsFile) *MemoryStore {
+	store := &MemoryStore{
+		Files: map[uint64]*RewardsFile{},
+	}
+	for _, file := range files {
+		store.Files[file.Index] = file
+	}
+	return store
+}
+
+// Get the rewards file for an interval
+func (s *MemoryStore) GetRewardsFile(index uint64) (*RewardsFile, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, exists := s.Files[index]
+	if !exists {
+		return nil, fmt.Errorf("%w: interval %d", ErrRewardsFileNotFound, index)
+	}
+	return file, nil
+}
+
+// Save the rewards file for an interval
+func (s *MemoryStore) SaveRewardsFile(file *RewardsFile) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Files[file.Index] = file
+	return nil
+}
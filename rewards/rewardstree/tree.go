@@ -0,0 +1,188 @@
+package rewardstree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rocket-pool/rocketpool-go/rewards"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// A rewards Merkle tree, as verified by RocketMerkleDistributorMainnet.
+// Leaves are the hashes of every node with a non-zero reward, in address order, padded with zero hashes to a power of
+// two. Parents are the hash of their children in ascending order, so proofs don't need to record sibling positions.
+type RewardsTree struct {
+	// Every level of the tree, from the leaves up to the root
+	Levels [][]common.Hash
+
+	leafIndices map[common.Address]int
+}
+
+// Get the leaf data for a node's rewards, abi.encodePacked(address, network, rpl, eth)
+func GetLeafData(address common.Address, network uint64, amountRpl *big.Int, amountEth *big.Int) []byte {
+	data := make([]byte, 0, 20+32*3)
+	data = append(data, address.Bytes()...)
+	data = append(data, uint256Bytes(big.NewInt(0).SetUint64(network))...)
+	data = append(data, uint256Bytes(amountRpl)...)
+	data = append(data, uint256Bytes(amountEth)...)
+	return data
+}
+
+// Get the leaf hash for a node's rewards
+func GetLeafHash(address common.Address, network uint64, amountRpl *big.Int, amountEth *big.Int) common.Hash {
+	return crypto.Keccak256Hash(GetLeafData(address, network, amountRpl, amountEth))
+}
+
+// Get the leaf hash for a node's rewards entry
+func (r *NodeRewards) GetLeafHash() common.Hash {
+	return GetLeafHash(r.Address, r.Network, r.GetTotalRpl(), r.SmoothingPoolEth)
+}
+
+// Get the parent of two sibling hashes
+func GetParentHash(a common.Hash, b common.Hash) common.Hash {
+	if bytes.Compare(a.Bytes(), b.Bytes()) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a.Bytes(), b.Bytes())
+}
+
+// Create a rewards tree from node rewards
+func NewRewardsTree(nodeRewards []NodeRewards) (*RewardsTree, error) {
+
+	// Get the rewarded nodes in address order
+	nodes := make([]NodeRewards, 0, len(nodeRewards))
+	for _, rewards := range nodeRewards {
+		if rewards.GetTotalRpl().Sign() == 0 && rewards.SmoothingPoolEth.Sign() == 0 {
+			continue
+		}
+		nodes = append(nodes, rewards)
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("rewards tree has no rewarded nodes")
+	}
+	sortNodeRewards(nodes)
+
+	// Build the leaves
+	leafCount := 1
+	for leafCount < len(nodes) {
+		leafCount *= 2
+	}
+	leaves := make([]common.Hash, leafCount)
+	leafIndices := make(map[common.Address]int, len(nodes))
+	for i, rewards := range nodes {
+		if _, exists := leafIndices[rewards.Address]; exists {
+			return nil, fmt.Errorf("node %s has more than one rewards entry", rewards.Address.Hex())
+		}
+		leaves[i] = rewards.GetLeafHash()
+		leafIndices[rewards.Address] = i
+	}
+
+	// Build the branches
+	levels := [][]common.Hash{leaves}
+	for level := leaves; len(level) > 1; {
+		parents := make([]common.Hash, len(level)/2)
+		for i := range parents {
+			parents[i] = GetParentHash(level[2*i], level[2*i+1])
+		}
+		levels = append(levels, parents)
+		level = parents
+	}
+
+	return &RewardsTree{
+		Levels:      levels,
+		leafIndices: leafIndices,
+	}, nil
+
+}
+
+// Get the root of the tree
+func (t *RewardsTree) GetRoot() common.Hash {
+	return t.Levels[len(t.Levels)-1][0]
+}
+
+// Get the proof for a node's leaf
+func (t *RewardsTree) GetProof(address common.Address) ([]common.Hash, error) {
+	index, exists := t.leafIndices[address]
+	if !exists {
+		return nil, fmt.Errorf("node %s has no rewards in the tree", address.Hex())
+	}
+	proof := make([]common.Hash, 0, len(t.Levels)-1)
+	for _, level := range t.Levels[:len(t.Levels)-1] {
+		proof = append(proof, level[index^1])
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Check a proof for a leaf against a root
+func VerifyProof(root common.Hash, leaf common.Hash, proof []common.Hash) bool {
+	hash := leaf
+	for _, sibling := range proof {
+		hash = GetParentHash(hash, sibling)
+	}
+	return hash == root
+}
+
+// Build the rewards tree for the file and set the Merkle proof of every node
+func (f *RewardsFile) GenerateProofs() (*RewardsTree, error) {
+	tree, err := NewRewardsTree(f.NodeRewards)
+	if err != nil {
+		return nil, err
+	}
+	for i := range f.NodeRewards {
+		rewards := &f.NodeRewards[i]
+		if _, exists := tree.leafIndices[rewards.Address]; !exists {
+			rewards.MerkleProof = []common.Hash{}
+			continue
+		}
+		if rewards.MerkleProof, err = tree.GetProof(rewards.Address); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+// Check that the file's Merkle root matches its node rewards and that every node's proof is valid
+func (f *RewardsFile) Verify() error {
+	tree, err := NewRewardsTree(f.NodeRewards)
+	if err != nil {
+		return err
+	}
+	if tree.GetRoot() != f.MerkleRoot {
+		return fmt.Errorf("rewards file for interval %d has Merkle root %s but its node rewards have root %s", f.Index, f.MerkleRoot.Hex(), tree.GetRoot().Hex())
+	}
+	for _, rewards := range f.NodeRewards {
+		if _, exists := tree.leafIndices[rewards.Address]; !exists {
+			continue
+		}
+		if !VerifyProof(f.MerkleRoot, rewards.GetLeafHash(), rewards.MerkleProof) {
+			return fmt.Errorf("rewards file for interval %d has an invalid Merkle proof for node %s", f.Index, rewards.Address.Hex())
+		}
+	}
+	return nil
+}
+
+// Check the file against the Merkle root stored by the distributor for its interval
+func (f *RewardsFile) VerifyOnChain(rp *rocketpool.RocketPool, opts *bind.CallOpts) error {
+	if err := f.Verify(); err != nil {
+		return err
+	}
+	root, err := rewards.MerkleRoots(rp, big.NewInt(0).SetUint64(f.Index), opts)
+	if err != nil {
+		return err
+	}
+	if common.BytesToHash(root) != f.MerkleRoot {
+		return fmt.Errorf("rewards file for interval %d has Merkle root %s but the distributor has %s", f.Index, f.MerkleRoot.Hex(), common.BytesToHash(root).Hex())
+	}
+	return nil
+}
+
+// Get a uint256 as 32 big-endian bytes
+func uint256Bytes(value *big.Int) []byte {
+	return common.LeftPadBytes(value.Bytes(), 32)
+}
@@ -0,0 +1,22 @@
+package rocketpool
+
+import (
+	"context"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// This is the common interface for Beacon chain clients.
+// State and block IDs follow the Beacon API: "head", "finalized", "genesis", a slot number or a root.
+type BeaconClient interface {
+
+	// GetGenesis returns the chain's genesis details.
+	GetGenesis(ctx context.Context) (types.BeaconGenesis, error)
+
+	// GetBeaconBlock returns a block, or false if there is no block for the ID (e.g. a missed slot).
+	GetBeaconBlock(ctx context.Context, blockID string) (types.BeaconBlock, bool, error)
+
+	// GetValidatorStatuses returns the statuses of validators at a state, keyed by pubkey.
+	// Validators that aren't on the Beacon chain yet are omitted.
+	GetValidatorStatuses(ctx context.Context, pubkeys []types.ValidatorPubkey, stateID string) (map[types.ValidatorPubkey]types.ValidatorStatus, error)
+}
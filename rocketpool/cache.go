@@ -0,0 +1,663 @@
+package rocketpool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The kind of value stored in a contract cache
+type CacheValueType string
+
+const (
+	CacheValueType_Address CacheValueType = "address"
+	CacheValueType_ABI     CacheValueType = "abi"
+)
+
+// How often current lookups check for contract upgrades when nothing else applies them
+const CacheSyncInterval = 300 // 5 minutes
+
+// The largest block range to scan for upgrade logs in one request when syncing automatically
+const cacheSyncIntervalSize = 10000
+
+// Stores contract addresses and encoded ABIs by contract name and block range.
+// Current values stay valid until they are invalidated by an upgrade of the contract.
+type Cache interface {
+	// Get the value of a contract at a block, or its current value if blockNumber is nil
+	Get(valueType CacheValueType, contractName string, blockNumber *uint64) (string, bool, error)
+
+	// Record the value of a contract at a block; if current is set, the value is valid from that block onwards
+	Set(valueType CacheValueType, contractName string, value string, blockNumber uint64, current bool) error
+
+	// Mark the current values of the contract with the given name hash as superseded from the given block
+	Invalidate(nameHash common.Hash, blockNumber uint64) error
+
+	// Get the last block that upgrade events have been applied through, or 0 if unknown
+	GetCheckpoint() (uint64, error)
+
+	// Set the last block that upgrade events have been applied through
+	SetCheckpoint(blockNumber uint64) error
+}
+
+// A cached value and the range of blocks it is known to be valid for
+type CacheEntry struct {
+	Value     string `json:"value"`
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+	Current   bool   `json:"current"`
+	Closed    bool   `json:"closed"`
+}
+
+// Check if the entry is valid at a block, or is current if blockNumber is nil
+func (e *CacheEntry) isValidAt(blockNumber *uint64) bool {
+	if blockNumber == nil {
+		return e.Current
+	}
+	if *blockNumber < e.FromBlock {
+		return false
+	}
+	return e.Current || *blockNumber <= e.ToBlock
+}
+
+// ====================
+// === Memory Cache ===
+// ====================
+
+// Contract cache held in memory
+type MemoryCache struct {
+	Entries    map[CacheValueType]map[string][]*CacheEntry `json:"entries"`
+	Checkpoint uint64                                      `json:"checkpoint"`
+	lock       sync.RWMutex
+}
+
+// Create a new in-memory contract cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		Entries: map[CacheValueType]map[string][]*CacheEntry{},
+	}
+}
+
+// Get the value of a contract at a block, or its current value if blockNumber is nil
+func (c *MemoryCache) Get(valueType CacheValueType, contractName string, blockNumber *uint64) (string, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, entry := range c.Entries[valueType][contractName] {
+		if entry.isValidAt(blockNumber) {
+			return entry.Value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Record the value of a contract at a block
+func (c *MemoryCache) Set(valueType CacheValueType, contractName string, value string, blockNumber uint64, current bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.set(valueType, contractName, value, blockNumber, current)
+	return nil
+}
+
+// Mark the current values of a contract as superseded from the given block
+func (c *MemoryCache) Invalidate(nameHash common.Hash, blockNumber uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.invalidate(nameHash, blockNumber)
+	return nil
+}
+
+// Get the last block that upgrade events have been applied through
+func (c *MemoryCache) GetCheckpoint() (uint64, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.Checkpoint, nil
+}
+
+// Set the last block that upgrade events have been applied through
+func (c *MemoryCache) SetCheckpoint(blockNumber uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Checkpoint = blockNumber
+	return nil
+}
+
+// Record a value, extending the entry that already holds it if possible; the lock must be held
+func (c *MemoryCache) set(valueType CacheValueType, contractName string, value string, blockNumber uint64, current bool) {
+	if c.Entries[valueType] == nil {
+		c.Entries[valueType] = map[string][]*CacheEntry{}
+	}
+	entries := c.Entries[valueType][contractName]
+
+	// Deployments are never reused, so a matching value is the same deployment unless it was superseded before this block
+	var match *CacheEntry
+	for _, entry := range entries {
+		if entry.Value == value && !(entry.Closed && blockNumber > entry.ToBlock) {
+			match = entry
+			break
+		}
+	}
+
+	// A new current value supersedes the old one
+	if current {
+		for _, entry := range entries {
+			if entry != match && entry.Current {
+				entry.Current = false
+				entry.Closed = true
+			}
+		}
+	}
+
+	if match == nil {
+		c.Entries[valueType][contractName] = append(entries, &CacheEntry{
+			Value:     value,
+			FromBlock: blockNumber,
+			ToBlock:   blockNumber,
+			Current:   current,
+		})
+		return
+	}
+	if blockNumber < match.FromBlock {
+		match.FromBlock = blockNumber
+	}
+	if blockNumber > match.ToBlock {
+		match.ToBlock = blockNumber
+	}
+	if current && !match.Closed {
+		match.Current = true
+	}
+}
+
+// Close the current entries of a contract at the block before an upgrade; the lock must be held
+func (c *MemoryCache) invalidate(nameHash common.Hash, blockNumber uint64) {
+	for _, contracts := range c.Entries {
+		for contractName, entries := range contracts {
+			if getUpgradeNameHash(contractName) != nameHash {
+				continue
+			}
+			for _, entry := range entries {
+				// Entries first seen at or after the upgrade already hold the new value
+				if !entry.Current || entry.FromBlock >= blockNumber {
+					continue
+				}
+				entry.Current = false
+				entry.Closed = true
+				if entry.ToBlock < blockNumber-1 {
+					entry.ToBlock = blockNumber - 1
+				}
+			}
+		}
+	}
+}
+
+// ==================
+// === Disk Cache ===
+// ==================
+
+// The number of changes appended to a disk cache before it is compacted into a single snapshot
+const diskCacheCompactThreshold = 1000
+
+// A line in a disk cache file: either a snapshot of the whole cache or a single change to it
+type diskCacheRecord struct {
+	Entries    map[CacheValueType]map[string][]*CacheEntry `json:"entries,omitempty"`
+	Checkpoint *uint64                                     `json:"checkpoint,omitempty"`
+	Set        *diskCacheSet                               `json:"set,omitempty"`
+	Invalidate *diskCacheInvalidate                        `json:"invalidate,omitempty"`
+}
+
+// A value recorded in a disk cache
+type diskCacheSet struct {
+	ValueType    CacheValueType `json:"valueType"`
+	ContractName string         `json:"contractName"`
+	Value        string         `json:"value"`
+	BlockNumber  uint64         `json:"blockNumber"`
+	Current      bool           `json:"current"`
+}
+
+// An upgrade applied to a disk cache
+type diskCacheInvalidate struct {
+	NameHash    common.Hash `json:"nameHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+}
+
+// Contract cache held in memory and persisted to a file.
+// Each change is appended to the file as a JSON line, and the file is rewritten as a single snapshot once
+// diskCacheCompactThreshold changes have been appended. Changes are not synced to disk; any lost by a crash are
+// reloaded from the network.
+type DiskCache struct {
+	MemoryCache
+	path     string
+	file     *os.File
+	appended int
+}
+
+// Create a contract cache backed by the file at the given path, loading it if it already exists
+func NewDiskCache(path string) (*DiskCache, error) {
+	c := &DiskCache{
+		MemoryCache: MemoryCache{
+			Entries: map[CacheValueType]map[string][]*CacheEntry{},
+		},
+		path: path,
+	}
+	compact, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	if compact {
+		err = c.compact()
+	} else {
+		err = c.openFile()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close the cache file
+func (c *DiskCache) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.file.Close()
+}
+
+// Record the value of a contract at a block and save the change
+func (c *DiskCache) Set(valueType CacheValueType, contractName string, value string, blockNumber uint64, current bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.set(valueType, contractName, value, blockNumber, current)
+	return c.write(diskCacheRecord{Set: &diskCacheSet{
+		ValueType:    valueType,
+		ContractName: contractName,
+		Value:        value,
+		BlockNumber:  blockNumber,
+		Current:      current,
+	}})
+}
+
+// Mark the current values of a contract as superseded from the given block and save the change
+func (c *DiskCache) Invalidate(nameHash common.Hash, blockNumber uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.invalidate(nameHash, blockNumber)
+	return c.write(diskCacheRecord{Invalidate: &diskCacheInvalidate{NameHash: nameHash, BlockNumber: blockNumber}})
+}
+
+// Set the last block that upgrade events have been applied through and save the change
+func (c *DiskCache) SetCheckpoint(blockNumber uint64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Checkpoint = blockNumber
+	return c.write(diskCacheRecord{Checkpoint: &blockNumber})
+}
+
+// Append a change to the file, compacting it if enough changes have built up; the lock must be held
+func (c *DiskCache) write(record diskCacheRecord) error {
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing contract cache change: %w", err)
+	}
+	if _, err := c.file.Write(append(bytes, '\n')); err != nil {
+		return fmt.Errorf("error writing contract cache to %s: %w", c.path, err)
+	}
+	c.appended++
+	if c.appended >= diskCacheCompactThreshold {
+		return c.compact()
+	}
+	return nil
+}
+
+// Replay the file, returning true if it should be compacted.
+// Files written by earlier versions hold a single snapshot without a trailing newline.
+func (c *DiskCache) load() (bool, error) {
+	file, err := os.Open(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading contract cache from %s: %w", c.path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Incomplete lines are from an interrupted write
+			if len(line) > 0 {
+				var record diskCacheRecord
+				if json.Unmarshal(line, &record) == nil {
+					c.apply(record)
+				}
+				return true, nil
+			}
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("error reading contract cache from %s: %w", c.path, err)
+		}
+		var record diskCacheRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return false, fmt.Errorf("error parsing contract cache from %s: %w", c.path, err)
+		}
+		c.apply(record)
+		if record.Set != nil || record.Invalidate != nil || record.Entries == nil {
+			c.appended++
+		}
+	}
+	return c.appended >= diskCacheCompactThreshold, nil
+}
+
+// Apply a record from the file
+func (c *DiskCache) apply(record diskCacheRecord) {
+	switch {
+	case record.Set != nil:
+		c.set(record.Set.ValueType, record.Set.ContractName, record.Set.Value, record.Set.BlockNumber, record.Set.Current)
+	case record.Invalidate != nil:
+		c.invalidate(record.Invalidate.NameHash, record.Invalidate.BlockNumber)
+	case record.Entries != nil:
+		c.Entries = record.Entries
+		if record.Checkpoint != nil {
+			c.Checkpoint = *record.Checkpoint
+		}
+	case record.Checkpoint != nil:
+		c.Checkpoint = *record.Checkpoint
+	}
+}
+
+// Write a snapshot of the cache to a temporary file, move it into place and append to it from then on; the lock must be held
+func (c *DiskCache) compact() error {
+	bytes, err := json.Marshal(diskCacheRecord{Entries: c.Entries, Checkpoint: &c.Checkpoint})
+	if err != nil {
+		return fmt.Errorf("error serializing contract cache: %w", err)
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary contract cache file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	_, err = tempFile.Write(append(bytes, '\n'))
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("error writing contract cache to %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, c.path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("error saving contract cache to %s: %w", c.path, err)
+	}
+	if c.file != nil {
+		_ = c.file.Close()
+	}
+	c.appended = 0
+	return c.openFile()
+}
+
+// Open the file for appending changes
+func (c *DiskCache) openFile() error {
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening contract cache %s: %w", c.path, err)
+	}
+	c.file = file
+	return nil
+}
+
+// ======================
+// === Decoded Values ===
+// ======================
+
+// ABIs decoded from cached values, by contract name and then by encoded ABI.
+// ABI decoding is client-independent, so views share their parent's ABIs.
+type abiCache struct {
+	abis map[string]map[string]*abi.ABI
+	lock sync.RWMutex
+}
+
+// Create an empty decoded ABI cache
+func newAbiCache() *abiCache {
+	return &abiCache{
+		abis: map[string]map[string]*abi.ABI{},
+	}
+}
+
+// Get a decoded ABI, decoding and storing it on a miss
+func (c *abiCache) get(contractName string, abiEncoded string) (*abi.ABI, error) {
+	c.lock.RLock()
+	decoded, exists := c.abis[contractName][abiEncoded]
+	c.lock.RUnlock()
+	if exists {
+		return decoded, nil
+	}
+	decoded, err := DecodeAbi(abiEncoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding contract %s ABI: %w", contractName, err)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.abis[contractName] == nil {
+		c.abis[contractName] = map[string]*abi.ABI{}
+	}
+	c.abis[contractName][abiEncoded] = decoded
+	return decoded, nil
+}
+
+// Drop the decoded ABIs of the contract with the given name hash
+func (c *abiCache) invalidate(nameHash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for contractName := range c.abis {
+		if getUpgradeNameHash(contractName) == nameHash {
+			delete(c.abis, contractName)
+		}
+	}
+}
+
+// The cached values a bound contract was built from
+type contractKey struct {
+	address    common.Address
+	abiEncoded string
+}
+
+// Contracts bound from cached values, by contract name and then by address and encoded ABI.
+// Contracts are bound to a client, so every view has its own.
+type contractCache struct {
+	contracts map[string]map[contractKey]*Contract
+	lock      sync.RWMutex
+}
+
+// Create an empty bound contract cache
+func newContractCache() *contractCache {
+	return &contractCache{
+		contracts: map[string]map[contractKey]*Contract{},
+	}
+}
+
+// Get a bound contract
+func (c *contractCache) get(contractName string, key contractKey) (*Contract, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	contract, exists := c.contracts[contractName][key]
+	return contract, exists
+}
+
+// Store a bound contract
+func (c *contractCache) set(contractName string, key contractKey, contract *Contract) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.contracts[contractName] == nil {
+		c.contracts[contractName] = map[contractKey]*Contract{}
+	}
+	c.contracts[contractName][key] = contract
+}
+
+// Drop the bound contracts of the contract with the given name hash
+func (c *contractCache) invalidate(nameHash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for contractName := range c.contracts {
+		if getUpgradeNameHash(contractName) == nameHash {
+			delete(c.contracts, contractName)
+		}
+	}
+}
+
+// ========================
+// === Upgrade Tracking ===
+// ========================
+
+// Events emitted by rocketDAONodeTrustedUpgrade when a contract's address or ABI changes
+var upgradeEventNames = []string{"ContractUpgraded", "ContractAdded", "ABIUpgraded", "ABIAdded"}
+
+// Invalidate the cached values replaced by a contract upgrade log from rocketDAONodeTrustedUpgrade.
+// Logs that aren't upgrade events are ignored, as are removed logs since reorged upgrades are re-included.
+func (rp *RocketPool) ProcessUpgradeLog(log types.Log) error {
+	if log.Removed || len(log.Topics) < 2 {
+		return nil
+	}
+	upgradeContract, err := rp.GetContract("rocketDAONodeTrustedUpgrade", nil)
+	if err != nil {
+		return err
+	}
+	if !isUpgradeEvent(upgradeContract, log.Topics[0]) {
+		return nil
+	}
+	return rp.invalidateCache(log.Topics[1], log.BlockNumber)
+}
+
+// Apply every contract upgrade since the cache checkpoint, up to the latest block
+func (rp *RocketPool) SyncCache(ctx context.Context, intervalSize *big.Int) error {
+	checkpoint, err := rp.cache.GetCheckpoint()
+	if err != nil {
+		return fmt.Errorf("error getting contract cache checkpoint: %w", err)
+	}
+	if checkpoint == 0 {
+		// Nothing current has been cached yet
+		return nil
+	}
+	latestBlock, err := rp.Client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting latest block number: %w", err)
+	}
+	if intervalSize == nil || intervalSize.Sign() <= 0 {
+		intervalSize = big.NewInt(0).SetUint64(latestBlock - checkpoint)
+	}
+	interval := intervalSize.Uint64()
+	upgradeNameHash := crypto.Keccak256Hash([]byte("rocketDAONodeTrustedUpgrade"))
+
+	// Scan with the upgrade contract that was active at the checkpoint, switching when it is upgraded itself
+	addressBlock := checkpoint
+	fromBlock := checkpoint + 1
+	for fromBlock <= latestBlock {
+		upgradeContract, err := rp.GetContractContext(ctx, "rocketDAONodeTrustedUpgrade", &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(addressBlock), Context: ctx})
+		if err != nil {
+			return err
+		}
+		topics := [][]common.Hash{{}}
+		for _, name := range upgradeEventNames {
+			if event, exists := upgradeContract.ABI.Events[name]; exists {
+				topics[0] = append(topics[0], event.ID)
+			}
+		}
+
+		restarted := false
+		for fromBlock <= latestBlock && !restarted {
+			toBlock := fromBlock + interval - 1
+			if interval == 0 || toBlock > latestBlock {
+				toBlock = latestBlock
+			}
+			logs, err := rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
+				Addresses: []common.Address{*upgradeContract.Address},
+				Topics:    topics,
+				FromBlock: big.NewInt(0).SetUint64(fromBlock),
+				ToBlock:   big.NewInt(0).SetUint64(toBlock),
+			})
+			if err != nil {
+				return fmt.Errorf("error getting upgrade logs for blocks %d - %d: %w", fromBlock, toBlock, err)
+			}
+			for _, log := range logs {
+				if log.Removed || len(log.Topics) < 2 {
+					continue
+				}
+				if err := rp.invalidateCache(log.Topics[1], log.BlockNumber); err != nil {
+					return err
+				}
+				if log.Topics[1] == upgradeNameHash && log.Topics[0] == upgradeContract.ABI.Events["ContractUpgraded"].ID {
+					// Continue from the upgrade block with the new contract
+					addressBlock = log.BlockNumber
+					toBlock = log.BlockNumber - 1
+					restarted = true
+					break
+				}
+			}
+			if toBlock >= fromBlock {
+				if err := rp.cache.SetCheckpoint(toBlock); err != nil {
+					return fmt.Errorf("error setting contract cache checkpoint: %w", err)
+				}
+			}
+			fromBlock = toBlock + 1
+		}
+	}
+	return nil
+}
+
+// Sync the cache if it hasn't been synced in the last CacheSyncInterval, so current values can't outlive an upgrade
+// that was never processed
+func (rp *RocketPool) syncCacheIfStale(ctx context.Context) error {
+	rp.syncLock.Lock()
+	defer rp.syncLock.Unlock()
+	if time.Since(rp.lastSync) < CacheSyncInterval*time.Second {
+		return nil
+	}
+	if err := rp.SyncCache(ctx, big.NewInt(cacheSyncIntervalSize)); err != nil {
+		return fmt.Errorf("error syncing contract cache: %w", err)
+	}
+	rp.lastSync = time.Now()
+	return nil
+}
+
+// Invalidate the cached values of the contract with the given name hash, and the ABIs and contracts decoded from them
+func (rp *RocketPool) invalidateCache(nameHash common.Hash, blockNumber uint64) error {
+	if err := rp.cache.Invalidate(nameHash, blockNumber); err != nil {
+		return fmt.Errorf("error invalidating contract cache for upgrade in block %d: %w", blockNumber, err)
+	}
+	rp.abis.invalidate(nameHash)
+	rp.contracts.invalidate(nameHash)
+	return nil
+}
+
+// Get the name hash that upgrade logs use for a contract.
+// Legacy names (e.g. rocketRewardsPool.v1) are assigned when the contract they're named after is upgraded, so they share its hash.
+func getUpgradeNameHash(contractName string) common.Hash {
+	if i := strings.LastIndex(contractName, ".v"); i > 0 {
+		contractName = contractName[:i]
+	}
+	return crypto.Keccak256Hash([]byte(contractName))
+}
+
+// Check if an event ID belongs to one of the upgrade events
+func isUpgradeEvent(upgradeContract *Contract, eventID common.Hash) bool {
+	for _, name := range upgradeEventNames {
+		if event, exists := upgradeContract.ABI.Events[name]; exists && event.ID == eventID {
+			return true
+		}
+	}
+	return false
+}
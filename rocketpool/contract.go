@@ -3,12 +3,10 @@ package rocketpool
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
-	"regexp"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -40,13 +38,23 @@ type GasInfo struct {
 
 // Call a contract method
 func (c *Contract) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return c.CallContext(GetCallContext(opts), opts, result, method, params...)
+}
+
+// Call a contract method, cancelling the underlying client request when the context is done
+func (c *Contract) CallContext(ctx context.Context, opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
 	results := make([]interface{}, 1)
 	results[0] = result
-	return c.Contract.Call(opts, &results, method, params...)
+	return c.Contract.Call(callOptsWithContext(ctx, opts), &results, method, params...)
 }
 
 // Get Gas Limit for transaction
 func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string, params ...interface{}) (GasInfo, error) {
+	return c.GetTransactionGasInfoContext(getTransactContext(opts), opts, method, params...)
+}
+
+// Get Gas Limit for transaction with a context
+func (c *Contract) GetTransactionGasInfoContext(ctx context.Context, opts *bind.TransactOpts, method string, params ...interface{}) (GasInfo, error) {
 
 	response := GasInfo{}
 
@@ -57,7 +65,7 @@ func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string,
 	}
 
 	// Estimate gas limit
-	estGasLimit, safeGasLimit, err := c.estimateGasLimit(opts, input)
+	estGasLimit, safeGasLimit, err := c.estimateGasLimit(ctx, opts, input)
 
 	if err != nil {
 		return response, fmt.Errorf("Error getting transaction gas info: could not estimate gas limit: %w", err)
@@ -70,6 +78,11 @@ func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string,
 
 // Transact on a contract method and wait for a receipt
 func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return c.TransactContext(getTransactContext(opts), opts, method, params...)
+}
+
+// Transact on a contract method with a context
+func (c *Contract) TransactContext(ctx context.Context, opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
 
 	// Estimate gas limit
 	if opts.GasLimit == 0 {
@@ -77,7 +90,7 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 		if err != nil {
 			return nil, fmt.Errorf("error encoding input data: %w", err)
 		}
-		_, safeGasLimit, err := c.estimateGasLimit(opts, input)
+		_, safeGasLimit, err := c.estimateGasLimit(ctx, opts, input)
 		if err != nil {
 			return nil, err
 		}
@@ -85,7 +98,7 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 	}
 
 	// Send transaction
-	tx, err := c.Contract.Transact(opts, method, params...)
+	tx, err := c.Contract.Transact(transactOptsWithContext(ctx, opts), method, params...)
 	if err != nil {
 		return nil, c.normalizeErrorMessage(err)
 	}
@@ -96,11 +109,16 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 
 // Get gas limit for a transfer call
 func (c *Contract) GetTransferGasInfo(opts *bind.TransactOpts) (GasInfo, error) {
+	return c.GetTransferGasInfoContext(getTransactContext(opts), opts)
+}
+
+// Get gas limit for a transfer call with a context
+func (c *Contract) GetTransferGasInfoContext(ctx context.Context, opts *bind.TransactOpts) (GasInfo, error) {
 
 	response := GasInfo{}
 
 	// Estimate gas limit
-	estGasLimit, safeGasLimit, err := c.estimateGasLimit(opts, []byte{})
+	estGasLimit, safeGasLimit, err := c.estimateGasLimit(ctx, opts, []byte{})
 	if err != nil {
 		return response, fmt.Errorf("Error getting transfer gas info: could not estimate gas limit: %w", err)
 	}
@@ -112,10 +130,15 @@ func (c *Contract) GetTransferGasInfo(opts *bind.TransactOpts) (GasInfo, error)
 
 // Transfer ETH to a contract and wait for a receipt
 func (c *Contract) Transfer(opts *bind.TransactOpts) (common.Hash, error) {
+	return c.TransferContext(getTransactContext(opts), opts)
+}
+
+// Transfer ETH to a contract with a context
+func (c *Contract) TransferContext(ctx context.Context, opts *bind.TransactOpts) (common.Hash, error) {
 
 	// Estimate gas limit
 	if opts.GasLimit == 0 {
-		_, safeGasLimit, err := c.estimateGasLimit(opts, []byte{})
+		_, safeGasLimit, err := c.estimateGasLimit(ctx, opts, []byte{})
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -123,7 +146,7 @@ func (c *Contract) Transfer(opts *bind.TransactOpts) (common.Hash, error) {
 	}
 
 	// Send transaction
-	tx, err := c.Contract.Transfer(opts)
+	tx, err := c.Contract.Transfer(transactOptsWithContext(ctx, opts))
 	if err != nil {
 		return common.Hash{}, c.normalizeErrorMessage(err)
 	}
@@ -133,10 +156,10 @@ func (c *Contract) Transfer(opts *bind.TransactOpts) (common.Hash, error) {
 }
 
 // Estimate the expected and safe gas limits for a contract transaction
-func (c *Contract) estimateGasLimit(opts *bind.TransactOpts, input []byte) (uint64, uint64, error) {
+func (c *Contract) estimateGasLimit(ctx context.Context, opts *bind.TransactOpts, input []byte) (uint64, uint64, error) {
 
 	// Estimate gas limit
-	gasLimit, err := c.Client.EstimateGas(context.Background(), ethereum.CallMsg{
+	gasLimit, err := c.Client.EstimateGas(ctx, ethereum.CallMsg{
 		From:     opts.From,
 		To:       c.Address,
 		GasPrice: big.NewInt(0), // use 0 gwei for simulation
@@ -161,10 +184,10 @@ func (c *Contract) estimateGasLimit(opts *bind.TransactOpts, input []byte) (uint
 }
 
 // Wait for a transaction to be mined and get a tx receipt
-func (c *Contract) getTransactionReceipt(tx *types.Transaction) (*types.Receipt, error) {
+func (c *Contract) getTransactionReceipt(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
 
 	// Wait for transaction to be mined
-	txReceipt, err := bind.WaitMined(context.Background(), c.Client, tx)
+	txReceipt, err := bind.WaitMined(ctx, c.Client, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -224,29 +247,44 @@ func (c *Contract) GetTransactionEvents(txReceipt *types.Receipt, eventName stri
 
 }
 
-// Normalize error messages so they're all in ASCII format
+// Normalize errors caused by reverts into a *RevertError, decoding the payload from any client's error format
 func (c *Contract) normalizeErrorMessage(err error) error {
-	if err == nil {
-		return err
+	if revertErr, ok := ParseRevertError(err, c.ABI); ok {
+		return revertErr
 	}
+	return err
+}
 
-	// Get the message in hex format, if it exists
-	reg := regexp.MustCompile(NethermindRevertRegex)
-	matches := reg.FindStringSubmatch(err.Error())
-	if matches == nil {
-		return err
+// Get a copy of the call options that uses the provided context
+func callOptsWithContext(ctx context.Context, opts *bind.CallOpts) *bind.CallOpts {
+	if opts == nil {
+		return &bind.CallOpts{Context: ctx}
 	}
-	messageIndex := reg.SubexpIndex("message")
-	if messageIndex == -1 {
-		return err
-	}
-	message := matches[messageIndex]
+	optsCopy := *opts
+	optsCopy.Context = ctx
+	return &optsCopy
+}
 
-	// Convert the hex message to ASCII
-	bytes, err2 := hex.DecodeString(message)
-	if err2 != nil {
-		return err // Return the original error if decoding failed somehow
+// Get a copy of the transaction options that uses the provided context
+func transactOptsWithContext(ctx context.Context, opts *bind.TransactOpts) *bind.TransactOpts {
+	optsCopy := *opts
+	optsCopy.Context = ctx
+	return &optsCopy
+}
+
+// Get the context carried by call options, defaulting to the background context.
+// Non-context wrappers use it so that opts.Context cancels them the same way as Contract.Call.
+func GetCallContext(opts *bind.CallOpts) context.Context {
+	if opts != nil && opts.Context != nil {
+		return opts.Context
 	}
+	return context.Background()
+}
 
-	return fmt.Errorf("Reverted: %s", string(bytes))
+// Get the context carried by transaction options, defaulting to the background context
+func getTransactContext(opts *bind.TransactOpts) context.Context {
+	if opts != nil && opts.Context != nil {
+		return opts.Context
+	}
+	return context.Background()
 }
@@ -0,0 +1,153 @@
+package rocketpool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// An ethclient that also reads state at canonical block hashes with EIP-1898, and traces and overrides storage for dry runs
+type EIP1898Client struct {
+	*ethclient.Client
+	rpcClient *rpc.Client
+}
+
+// Create an execution client for an RPC connection that supports EIP-1898 block hash reads
+func NewEIP1898Client(rpcClient *rpc.Client) *EIP1898Client {
+	return &EIP1898Client{
+		Client:    ethclient.NewClient(rpcClient),
+		rpcClient: rpcClient,
+	}
+}
+
+// Execute a contract call at the given block, which must be canonical
+func (c *EIP1898Client) CallContractAtCanonicalHash(ctx context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+	var result hexutil.Bytes
+	if err := c.rpcClient.CallContext(ctx, &result, "eth_call", toCallArg(call), rpc.BlockNumberOrHashWithHash(blockHash, true)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Get the code of an account at the given block, which must be canonical
+func (c *EIP1898Client) CodeAtCanonicalHash(ctx context.Context, contract common.Address, blockHash common.Hash) ([]byte, error) {
+	var result hexutil.Bytes
+	if err := c.rpcClient.CallContext(ctx, &result, "eth_getCode", contract, rpc.BlockNumberOrHashWithHash(blockHash, true)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Get the wei balance of an account at the given block, which must be canonical
+func (c *EIP1898Client) BalanceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	var result hexutil.Big
+	if err := c.rpcClient.CallContext(ctx, &result, "eth_getBalance", account, rpc.BlockNumberOrHashWithHash(blockHash, true)); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
+// Get the nonce of an account at the given block, which must be canonical
+func (c *EIP1898Client) NonceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (uint64, error) {
+	var result hexutil.Uint64
+	if err := c.rpcClient.CallContext(ctx, &result, "eth_getTransactionCount", account, rpc.BlockNumberOrHashWithHash(blockHash, true)); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// The accounts a call changes, as reported by the prestate tracer in diff mode
+type prestateDiff struct {
+	Pre  map[common.Address]prestateAccount `json:"pre"`
+	Post map[common.Address]prestateAccount `json:"post"`
+}
+
+// An account's storage in a prestate diff
+type prestateAccount struct {
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// Trace the storage slots a call writes at the given block with debug_traceCall and the prestate tracer
+func (c *EIP1898Client) TraceCallStorageChanges(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (StorageChanges, error) {
+	var diff prestateDiff
+	tracerConfig := map[string]interface{}{
+		"tracer":       "prestateTracer",
+		"tracerConfig": map[string]interface{}{"diffMode": true},
+	}
+	if err := c.rpcClient.CallContext(ctx, &diff, "debug_traceCall", toCallArg(call), toBlockNumArg(blockNumber), tracerConfig); err != nil {
+		return nil, err
+	}
+	changes := StorageChanges{}
+	for address, account := range diff.Post {
+		for slot, value := range account.Storage {
+			if changes[address] == nil {
+				changes[address] = map[common.Hash]common.Hash{}
+			}
+			changes[address][slot] = value
+		}
+	}
+
+	// Slots that were cleared only appear in the pre-state
+	for address, account := range diff.Pre {
+		for slot := range account.Storage {
+			if _, exists := diff.Post[address].Storage[slot]; exists {
+				continue
+			}
+			if changes[address] == nil {
+				changes[address] = map[common.Hash]common.Hash{}
+			}
+			changes[address][slot] = common.Hash{}
+		}
+	}
+	return changes, nil
+}
+
+// Execute a contract call at the given block with eth_call, replacing storage slots with a state override
+func (c *EIP1898Client) CallContractWithStorage(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int, storage StorageChanges) ([]byte, error) {
+	overrides := make(map[common.Address]interface{}, len(storage))
+	for address, slots := range storage {
+		overrides[address] = map[string]interface{}{"stateDiff": slots}
+	}
+	var result hexutil.Bytes
+	if err := c.rpcClient.CallContext(ctx, &result, "eth_call", toCallArg(call), toBlockNumArg(blockNumber), overrides); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Convert a block number to a JSON-RPC block argument, matching ethclient
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	if number.Cmp(big.NewInt(-1)) == 0 {
+		return "pending"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+// Convert a call message to eth_call arguments, matching ethclient
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
@@ -103,3 +103,29 @@ type ExecutionClient interface {
 	// no sync currently running, it returns nil.
 	SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error)
 }
+
+// Optionally implemented by execution clients that can read state at a block hash with EIP-1898, failing if the block
+// isn't canonical. Block-pinned views use it to detect reorgs without an extra header request per read.
+type CanonicalHashReader interface {
+	// CallContractAtCanonicalHash executes a contract call at the given block, which must be canonical.
+	CallContractAtCanonicalHash(ctx context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error)
+
+	// CodeAtCanonicalHash returns the code of the given account at the given block, which must be canonical.
+	CodeAtCanonicalHash(ctx context.Context, contract common.Address, blockHash common.Hash) ([]byte, error)
+
+	// BalanceAtCanonicalHash returns the wei balance of the given account at the given block, which must be canonical.
+	BalanceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error)
+
+	// NonceAtCanonicalHash returns the nonce of the given account at the given block, which must be canonical.
+	NonceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (uint64, error)
+}
+
+// Optionally implemented by execution clients that can trace the storage a call writes and run calls against modified storage.
+// Dry runs use it to read values as a transaction would leave them, without sending it.
+type StateOverrideClient interface {
+	// TraceCallStorageChanges executes a call at the given block and returns the storage slots it writes, with their new values.
+	TraceCallStorageChanges(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (StorageChanges, error)
+
+	// CallContractWithStorage executes a contract call at the given block with storage slots replaced.
+	CallContractWithStorage(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int, storage StorageChanges) ([]byte, error)
+}
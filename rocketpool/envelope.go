@@ -0,0 +1,186 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// An unsigned EIP-1559 transaction, in the JSON-RPC transaction format used by external signers
+type TransactionEnvelope struct {
+	ChainID    *hexutil.Big     `json:"chainId"`
+	From       common.Address   `json:"from"`
+	To         *common.Address  `json:"to"`
+	Nonce      hexutil.Uint64   `json:"nonce"`
+	Value      *hexutil.Big     `json:"value"`
+	Data       hexutil.Bytes    `json:"data"`
+	Gas        hexutil.Uint64   `json:"gas"`
+	GasTipCap  *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	GasFeeCap  *hexutil.Big     `json:"maxFeePerGas"`
+	AccessList types.AccessList `json:"accessList"`
+
+	// The hash the sender must sign, and the unsigned typed transaction it was computed from
+	SigningHash common.Hash   `json:"signingHash"`
+	UnsignedTx  hexutil.Bytes `json:"unsignedTx"`
+}
+
+// Build an unsigned transaction envelope from any write function instead of signing and sending it.
+// opts provides the sender and any overrides for the value, gas limit, fees and nonce; its signer is ignored.
+// Fees and the nonce default to the network's current suggestions. write must send exactly one transaction,
+// e.g. by calling node.StakeRPL(rp, amount, opts) with the options it is given.
+func BuildEnvelope(client ExecutionClient, chainID *big.Int, opts *bind.TransactOpts, write func(*bind.TransactOpts) error) (*TransactionEnvelope, error) {
+	return BuildEnvelopeContext(getTransactContext(opts), client, chainID, opts, write)
+}
+
+// Build an unsigned transaction envelope from any write function with a context
+func BuildEnvelopeContext(ctx context.Context, client ExecutionClient, chainID *big.Int, opts *bind.TransactOpts, write func(*bind.TransactOpts) error) (*TransactionEnvelope, error) {
+	if opts == nil {
+		return nil, errors.New("transaction options with a sender are required")
+	}
+	buildOpts := *opts
+	buildOpts.Context = ctx
+
+	// Get the nonce and fees
+	if buildOpts.Nonce == nil {
+		nonce, err := client.PendingNonceAt(ctx, opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("error getting nonce for %s: %w", opts.From.Hex(), err)
+		}
+		buildOpts.Nonce = big.NewInt(0).SetUint64(nonce)
+	}
+	if buildOpts.GasTipCap == nil {
+		tipCap, err := client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting suggested priority fee: %w", err)
+		}
+		buildOpts.GasTipCap = tipCap
+	}
+	if buildOpts.GasFeeCap == nil {
+		header, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting latest block header: %w", err)
+		}
+		if header.BaseFee == nil {
+			return nil, errors.New("latest block has no base fee; EIP-1559 is not active")
+		}
+		buildOpts.GasFeeCap = big.NewInt(0).Add(buildOpts.GasTipCap, big.NewInt(0).Mul(header.BaseFee, big.NewInt(2)))
+	}
+
+	// Capture the transaction at the signing step
+	tx, err := captureTransaction(&buildOpts, write)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTransactionEnvelope(tx, opts.From, chainID)
+}
+
+// Create an envelope for an unsigned EIP-1559 transaction
+func NewTransactionEnvelope(tx *types.Transaction, from common.Address, chainID *big.Int) (*TransactionEnvelope, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, fmt.Errorf("transaction has type %d but an EIP-1559 transaction is required", tx.Type())
+	}
+
+	// Rebuild the transaction with the chain ID so its encoding and signing hash are final
+	unsigned := types.NewTx(&types.DynamicFeeTx{
+		ChainID:    chainID,
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        tx.Gas(),
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+	unsignedBytes, err := unsigned.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding unsigned transaction: %w", err)
+	}
+
+	accessList := unsigned.AccessList()
+	if accessList == nil {
+		accessList = types.AccessList{}
+	}
+	return &TransactionEnvelope{
+		ChainID:     (*hexutil.Big)(chainID),
+		From:        from,
+		To:          unsigned.To(),
+		Nonce:       hexutil.Uint64(unsigned.Nonce()),
+		Value:       (*hexutil.Big)(unsigned.Value()),
+		Data:        unsigned.Data(),
+		Gas:         hexutil.Uint64(unsigned.Gas()),
+		GasTipCap:   (*hexutil.Big)(unsigned.GasTipCap()),
+		GasFeeCap:   (*hexutil.Big)(unsigned.GasFeeCap()),
+		AccessList:  accessList,
+		SigningHash: types.LatestSignerForChainID(chainID).Hash(unsigned),
+		UnsignedTx:  unsignedBytes,
+	}, nil
+}
+
+// Get the unsigned transaction described by the envelope
+func (e *TransactionEnvelope) GetTransaction() *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    e.ChainID.ToInt(),
+		Nonce:      uint64(e.Nonce),
+		GasTipCap:  e.GasTipCap.ToInt(),
+		GasFeeCap:  e.GasFeeCap.ToInt(),
+		Gas:        uint64(e.Gas),
+		To:         e.To,
+		Value:      e.Value.ToInt(),
+		Data:       e.Data,
+		AccessList: e.AccessList,
+	})
+}
+
+// Decode a signed transaction and check that it is the envelope's transaction, signed by its sender
+func (e *TransactionEnvelope) VerifySigned(signedTx []byte) (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		return nil, fmt.Errorf("error decoding signed transaction: %w", err)
+	}
+	signer := types.LatestSignerForChainID(e.ChainID.ToInt())
+	if signer.Hash(tx) != signer.Hash(e.GetTransaction()) {
+		return nil, errors.New("signed transaction does not match the envelope")
+	}
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error recovering transaction signer: %w", err)
+	}
+	if sender != e.From {
+		return nil, fmt.Errorf("transaction was signed by %s but the envelope is for %s", sender.Hex(), e.From.Hex())
+	}
+	return tx, nil
+}
+
+// Broadcast a signed transaction; if envelope is set, the transaction is checked against it first
+func Broadcast(client ExecutionClient, envelope *TransactionEnvelope, signedTx []byte) (common.Hash, error) {
+	return BroadcastContext(context.Background(), client, envelope, signedTx)
+}
+
+// Broadcast a signed transaction with a context
+func BroadcastContext(ctx context.Context, client ExecutionClient, envelope *TransactionEnvelope, signedTx []byte) (common.Hash, error) {
+	var tx *types.Transaction
+	if envelope != nil {
+		var err error
+		tx, err = envelope.VerifySigned(signedTx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+	} else {
+		tx = new(types.Transaction)
+		if err := tx.UnmarshalBinary(signedTx); err != nil {
+			return common.Hash{}, fmt.Errorf("error decoding signed transaction: %w", err)
+		}
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return common.Hash{}, fmt.Errorf("error broadcasting transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	return tx.Hash(), nil
+}
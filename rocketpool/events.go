@@ -0,0 +1,115 @@
+package rocketpool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// A contract event log decoded with the ABI of the contract that emitted it
+type ContractEvent struct {
+	Name     string                 `json:"name"`
+	Fields   map[string]interface{} `json:"fields"`
+	Log      types.Log              `json:"log"`
+	Removed  bool                   `json:"removed"`
+	Contract *Contract              `json:"-"`
+}
+
+// Unpack the event into an event struct, using the same field mapping as abigen bindings
+func (e ContractEvent) Unpack(event interface{}) error {
+	if e.Contract == nil {
+		return errors.New("event has no contract to decode it with")
+	}
+	return e.Contract.UnpackLog(e.Log, e.Name, event)
+}
+
+// Get the ABI event matching a log's first topic
+func (c *Contract) GetLogEvent(log types.Log) (*abi.Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("log has no topics")
+	}
+	event, err := c.ABI.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, fmt.Errorf("log topic %s does not match any event on the contract", log.Topics[0].Hex())
+	}
+	return event, nil
+}
+
+// Decode any event log emitted by the contract, including its indexed fields
+func (c *Contract) DecodeLog(log types.Log) (ContractEvent, error) {
+
+	// Check log address matches contract address
+	if !bytes.Equal(log.Address.Bytes(), c.Address.Bytes()) {
+		return ContractEvent{}, fmt.Errorf("log was emitted by %s, not %s", log.Address.Hex(), c.Address.Hex())
+	}
+
+	// Get ABI event
+	abiEvent, err := c.GetLogEvent(log)
+	if err != nil {
+		return ContractEvent{}, err
+	}
+
+	// Unpack the non-indexed fields
+	fields := map[string]interface{}{}
+	if len(log.Data) > 0 {
+		if err := abiEvent.Inputs.UnpackIntoMap(fields, log.Data); err != nil {
+			return ContractEvent{}, fmt.Errorf("error unpacking %s event data: %w", abiEvent.Name, err)
+		}
+	}
+
+	// Unpack the indexed fields
+	indexed := abi.Arguments{}
+	for _, input := range abiEvent.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(log.Topics)-1 != len(indexed) {
+		return ContractEvent{}, fmt.Errorf("%s event has %d indexed fields but the log has %d topics", abiEvent.Name, len(indexed), len(log.Topics)-1)
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, log.Topics[1:]); err != nil {
+		return ContractEvent{}, fmt.Errorf("error unpacking %s event topics: %w", abiEvent.Name, err)
+	}
+
+	return ContractEvent{
+		Name:     abiEvent.Name,
+		Fields:   fields,
+		Log:      log,
+		Removed:  log.Removed,
+		Contract: c,
+	}, nil
+
+}
+
+// Unpack an event log emitted by the contract into an event struct
+// event must be a pointer to an event struct
+func (c *Contract) UnpackLog(log types.Log, eventName string, event interface{}) error {
+
+	// Check event type
+	eventType := reflect.TypeOf(event)
+	if eventType == nil || eventType.Kind() != reflect.Pointer || eventType.Elem().Kind() != reflect.Struct {
+		return errors.New("Invalid event type")
+	}
+
+	// Get ABI event
+	abiEvent, ok := c.ABI.Events[eventName]
+	if !ok {
+		return fmt.Errorf("Event '%s' does not exist on contract", eventName)
+	}
+
+	// Check log first topic matches event ID
+	if len(log.Topics) == 0 || !bytes.Equal(log.Topics[0].Bytes(), abiEvent.ID.Bytes()) {
+		return fmt.Errorf("log is not a %s event", eventName)
+	}
+
+	// Unpack event
+	if err := c.Contract.UnpackLog(event, eventName, log); err != nil {
+		return fmt.Errorf("error unpacking event data: %w", err)
+	}
+	return nil
+
+}
@@ -0,0 +1,354 @@
+package rocketpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// The kind of payload a reverted call returned
+type RevertKind int
+
+const (
+	RevertKind_Unknown RevertKind = iota
+	RevertKind_Error
+	RevertKind_Panic
+	RevertKind_Custom
+)
+
+// Selectors of the builtin Solidity revert payloads
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// Descriptions of the Solidity panic codes
+var panicDescriptions = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an uninitialized function",
+}
+
+// Matches the revert payload in Nethermind error messages
+var nethermindRevertRegex = regexp.MustCompile(NethermindRevertRegex)
+
+// Message prefixes of revert errors
+const (
+	defaultRevertPrefix    = "execution reverted"
+	nethermindRevertPrefix = "Reverted"
+)
+
+// A reverted call or transaction, decoded from the client's error
+type RevertError struct {
+	Kind RevertKind
+
+	// The Error(string) reason, or the client's message if the payload couldn't be decoded
+	Reason string
+
+	// The Panic(uint256) code
+	PanicCode *big.Int
+
+	// The name and arguments of a custom error
+	ErrorName string
+	ErrorArgs []interface{}
+
+	// The raw revert payload, if the client returned one
+	Data []byte
+
+	// The original client error
+	Err error
+
+	// The prefix of the error message, if the client uses a different one than "execution reverted"
+	prefix string
+}
+
+// Get the error message
+func (e *RevertError) Error() string {
+	prefix := e.prefix
+	if prefix == "" {
+		prefix = defaultRevertPrefix
+	}
+	switch e.Kind {
+	case RevertKind_Error:
+		return fmt.Sprintf("%s: %s", prefix, e.Reason)
+	case RevertKind_Panic:
+		description, exists := panicDescriptions[e.PanicCode.Uint64()]
+		if !e.PanicCode.IsUint64() || !exists {
+			description = "unknown panic"
+		}
+		return fmt.Sprintf("%s: panic 0x%x (%s)", prefix, e.PanicCode, description)
+	case RevertKind_Custom:
+		args := make([]string, len(e.ErrorArgs))
+		for i, arg := range e.ErrorArgs {
+			args[i] = fmt.Sprint(arg)
+		}
+		return fmt.Sprintf("%s: %s(%s)", prefix, e.ErrorName, strings.Join(args, ", "))
+	}
+	if e.Reason != "" {
+		return fmt.Sprintf("%s: %s", prefix, e.Reason)
+	}
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("%s with unknown data 0x%x", prefix, e.Data)
+	}
+	return prefix
+}
+
+// Get the original client error
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// Decode a revert payload; custom errors are resolved against the provided ABIs
+func DecodeRevertData(data []byte, errorAbis ...*abi.ABI) *RevertError {
+	revertErr := &RevertError{
+		Kind: RevertKind_Unknown,
+		Data: data,
+	}
+	if len(data) < 4 {
+		return revertErr
+	}
+
+	// Builtin payloads
+	if bytes.Equal(data[:4], errorSelector) {
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revertErr.Kind = RevertKind_Error
+			revertErr.Reason = reason
+		}
+		return revertErr
+	}
+	if bytes.Equal(data[:4], panicSelector) {
+		if len(data) == 36 {
+			revertErr.Kind = RevertKind_Panic
+			revertErr.PanicCode = new(big.Int).SetBytes(data[4:])
+		}
+		return revertErr
+	}
+
+	// Custom errors
+	for _, errorAbi := range errorAbis {
+		if errorAbi == nil {
+			continue
+		}
+		for _, abiError := range errorAbi.Errors {
+			if !bytes.Equal(data[:4], abiError.ID[:4]) {
+				continue
+			}
+			args, err := abiError.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			revertErr.Kind = RevertKind_Custom
+			revertErr.ErrorName = abiError.Name
+			revertErr.ErrorArgs = args
+			return revertErr
+		}
+	}
+	return revertErr
+}
+
+// Parse a client error into a revert error if it was caused by a revert.
+// The payload is only taken from the JSON-RPC error data, which Geth, Erigon, Reth and Besu use. Nethermind embeds it
+// in the message as "Reverted 0x...", either ABI-encoded or as the raw reason text; those errors keep Nethermind's
+// "Reverted: reason" message format. Any other message is only treated as a revert if it starts with "execution reverted".
+// Custom errors are resolved against the provided ABIs.
+func ParseRevertError(err error, errorAbis ...*abi.ABI) (*RevertError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) {
+		return revertErr, true
+	}
+	message := err.Error()
+
+	// Get the payload from the error data
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if dataString, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(dataString); decodeErr == nil {
+				revertErr = DecodeRevertData(data, errorAbis...)
+				revertErr.Err = err
+				if revertErr.Kind == RevertKind_Unknown {
+					revertErr.Reason = getMessageReason(message)
+				}
+				return revertErr, true
+			}
+			if revertErr, ok := parseNethermindRevert(dataString, err, errorAbis...); ok {
+				return revertErr, true
+			}
+		}
+	}
+
+	// Check the message
+	if revertErr, ok := parseNethermindRevert(message, err, errorAbis...); ok {
+		return revertErr, true
+	}
+	if !strings.HasPrefix(message, "execution reverted") {
+		return nil, false
+	}
+	return &RevertError{
+		Kind:   RevertKind_Unknown,
+		Reason: getMessageReason(message),
+		Err:    err,
+	}, true
+}
+
+// Parse a Nethermind "Reverted 0x..." revert message
+func parseNethermindRevert(value string, err error, errorAbis ...*abi.ABI) (*RevertError, bool) {
+	matches := nethermindRevertRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, false
+	}
+	data, decodeErr := hex.DecodeString(matches[nethermindRevertRegex.SubexpIndex("message")])
+	if decodeErr != nil {
+		return nil, false
+	}
+	revertErr := DecodeRevertData(data, errorAbis...)
+	revertErr.Err = err
+	revertErr.prefix = nethermindRevertPrefix
+	if revertErr.Kind == RevertKind_Unknown {
+		// Older Nethermind versions return the reason as hex-encoded text
+		revertErr.Kind = RevertKind_Error
+		revertErr.Reason = string(data)
+	}
+	return revertErr, true
+}
+
+// Get the reason from an "execution reverted: reason" style message
+func getMessageReason(message string) string {
+	lower := strings.ToLower(message)
+	index := strings.Index(lower, "reverted:")
+	if index == -1 {
+		return ""
+	}
+	reason := strings.TrimSpace(message[index+len("reverted:"):])
+	if strings.HasPrefix(reason, "0x") {
+		return ""
+	}
+	return reason
+}
+
+// Simulate a contract method call at the pending block with the transaction's sender and value.
+// Returns the call's output, or a *RevertError if it would revert.
+func (c *Contract) Simulate(opts *bind.TransactOpts, method string, params ...interface{}) ([]byte, error) {
+	return c.SimulateContext(getTransactContext(opts), opts, method, params...)
+}
+
+// Simulate a contract method call with a context
+func (c *Contract) SimulateContext(ctx context.Context, opts *bind.TransactOpts, method string, params ...interface{}) ([]byte, error) {
+	input, err := c.ABI.Pack(method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding input data: %w", err)
+	}
+	return c.SimulateCalldataContext(ctx, opts, input)
+}
+
+// Simulate a call to the contract with raw calldata at the pending block
+func (c *Contract) SimulateCalldata(opts *bind.TransactOpts, data []byte) ([]byte, error) {
+	return c.SimulateCalldataContext(getTransactContext(opts), opts, data)
+}
+
+// Simulate a call to the contract with raw calldata with a context
+func (c *Contract) SimulateCalldataContext(ctx context.Context, opts *bind.TransactOpts, data []byte) ([]byte, error) {
+	return simulateCall(ctx, c.Client, ethereum.CallMsg{
+		From:  opts.From,
+		To:    c.Address,
+		Gas:   opts.GasLimit,
+		Value: opts.Value,
+		Data:  data,
+	}, c.ABI)
+}
+
+// Simulate the transaction built by any write function at the pending block, without sending it.
+// write must send exactly one transaction, e.g. by calling node.StakeRPL(rp, amount, opts) with the options it is given.
+// Custom errors are resolved against the provided ABIs. Returns the call's output, or a *RevertError if it would revert.
+func SimulateTransaction(client ExecutionClient, opts *bind.TransactOpts, write func(*bind.TransactOpts) error, errorAbis ...*abi.ABI) ([]byte, error) {
+	return SimulateTransactionContext(getTransactContext(opts), client, opts, write, errorAbis...)
+}
+
+// Simulate the transaction built by any write function with a context
+func SimulateTransactionContext(ctx context.Context, client ExecutionClient, opts *bind.TransactOpts, write func(*bind.TransactOpts) error, errorAbis ...*abi.ABI) ([]byte, error) {
+	if opts == nil {
+		return nil, errors.New("transaction options with a sender are required")
+	}
+	simulateOpts := *opts
+	simulateOpts.Context = ctx
+
+	// Skip gas estimation so a revert is reported by the simulation rather than the estimate
+	if simulateOpts.GasLimit == 0 {
+		simulateOpts.GasLimit = MaxGasLimit
+	}
+	tx, err := captureTransaction(&simulateOpts, write)
+	if err != nil {
+		return nil, err
+	}
+	if tx.To() == nil {
+		return nil, errors.New("contract deployments can't be simulated")
+	}
+
+	var gas uint64
+	if opts.GasLimit != 0 {
+		gas = tx.Gas()
+	}
+	return simulateCall(ctx, client, ethereum.CallMsg{
+		From:  opts.From,
+		To:    tx.To(),
+		Gas:   gas,
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}, errorAbis...)
+}
+
+// Run an eth_call at the pending block and decode any revert
+func simulateCall(ctx context.Context, client ExecutionClient, call ethereum.CallMsg, errorAbis ...*abi.ABI) ([]byte, error) {
+	output, err := client.CallContract(ctx, call, big.NewInt(-1))
+	if err != nil {
+		if revertErr, ok := ParseRevertError(err, errorAbis...); ok {
+			return nil, revertErr
+		}
+		return nil, fmt.Errorf("error simulating call to %s: %w", call.To.Hex(), err)
+	}
+	return output, nil
+}
+
+// Run a write function with a signer that captures its transaction instead of signing and sending it
+func captureTransaction(opts *bind.TransactOpts, write func(*bind.TransactOpts) error) (*types.Transaction, error) {
+	from := opts.From
+	var captured []*types.Transaction
+	opts.NoSend = true
+	opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != from {
+			return nil, fmt.Errorf("transaction is from %s but the options are for %s", address.Hex(), from.Hex())
+		}
+		captured = append(captured, tx)
+		return tx, nil
+	}
+	if err := write(opts); err != nil {
+		return nil, err
+	}
+	if len(captured) != 1 {
+		return nil, fmt.Errorf("write function built %d transactions but exactly one is required", len(captured))
+	}
+	return captured[0], nil
+}
@@ -1,7 +1,9 @@
 package rocketpool
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"strings"
 	"sync"
 	"time"
@@ -15,39 +17,27 @@ import (
 	"github.com/rocket-pool/rocketpool-go/contracts"
 )
 
-// Cache settings
-const CacheTTL = 300 // 5 minutes
-
-// Cached data types
-type cachedAddress struct {
-	address *common.Address
-	time    int64
-}
-type cachedABI struct {
-	abi  *abi.ABI
-	time int64
-}
-type cachedContract struct {
-	contract *Contract
-	time     int64
-}
-
 // Rocket Pool contract manager
 type RocketPool struct {
 	Client                ExecutionClient
 	RocketStorage         *contracts.RocketStorage
 	RocketStorageContract *Contract
 	VersionManager        *VersionManager
-	addresses             map[string]cachedAddress
-	abis                  map[string]cachedABI
-	contracts             map[string]cachedContract
-	addressesLock         sync.RWMutex
-	abisLock              sync.RWMutex
-	contractsLock         sync.RWMutex
+	cache                 Cache
+	abis                  *abiCache
+	contracts             *contractCache
+	lastSync              time.Time
+	syncLock              sync.Mutex
 }
 
 // Create new contract manager
 func NewRocketPool(client ExecutionClient, rocketStorageAddress common.Address) (*RocketPool, error) {
+	return NewRocketPoolWithCache(client, rocketStorageAddress, nil)
+}
+
+// Create new contract manager with a contract cache
+// If cache is nil, contract addresses and ABIs are cached in memory
+func NewRocketPoolWithCache(client ExecutionClient, rocketStorageAddress common.Address, cache Cache) (*RocketPool, error) {
 
 	// Initialize RocketStorage contract
 	rocketStorage, err := contracts.NewRocketStorage(rocketStorageAddress, client)
@@ -72,9 +62,12 @@ func NewRocketPool(client ExecutionClient, rocketStorageAddress common.Address)
 		Client:                client,
 		RocketStorage:         rocketStorage,
 		RocketStorageContract: contract,
-		addresses:             make(map[string]cachedAddress),
-		abis:                  make(map[string]cachedABI),
-		contracts:             make(map[string]cachedContract),
+		cache:                 cache,
+		abis:                  newAbiCache(),
+		contracts:             newContractCache(),
+	}
+	if rp.cache == nil {
+		rp.cache = NewMemoryCache()
 	}
 	rp.VersionManager = NewVersionManager(rp)
 
@@ -84,38 +77,34 @@ func NewRocketPool(client ExecutionClient, rocketStorageAddress common.Address)
 
 // Load Rocket Pool contract addresses
 func (rp *RocketPool) GetAddress(contractName string, opts *bind.CallOpts) (*common.Address, error) {
+	return rp.GetAddressContext(GetCallContext(opts), contractName, opts)
+}
 
-	// Check for cached address
-	if opts == nil {
-		if cached, ok := rp.getCachedAddress(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
-				return cached.address, nil
-			} else {
-				rp.deleteCachedAddress(contractName)
-			}
-		}
-	}
+// Load Rocket Pool contract addresses with a context
+func (rp *RocketPool) GetAddressContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*common.Address, error) {
 
 	// Get address
-	address, err := rp.RocketStorage.GetAddress(opts, crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)))
+	value, err := rp.getCachedValue(ctx, CacheValueType_Address, contractName, opts, func(callOpts *bind.CallOpts) (string, error) {
+		address, err := rp.RocketStorage.GetAddress(callOpts, crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)))
+		if err != nil {
+			return "", fmt.Errorf("error loading contract %s address: %w", contractName, err)
+		}
+		return address.Hex(), nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error loading contract %s address: %w", contractName, err)
-	}
-
-	// Cache address
-	if opts == nil {
-		rp.setCachedAddress(contractName, cachedAddress{
-			address: &address,
-			time:    time.Now().Unix(),
-		})
+		return nil, err
 	}
 
 	// Return
+	address := common.HexToAddress(value)
 	return &address, nil
 
 }
 
 func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string) ([]*common.Address, error) {
+	return rp.GetAddressesContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetAddressesContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*common.Address, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -125,7 +114,7 @@ func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string)
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			address, err := rp.GetAddress(contractName, opts)
+			address, err := rp.GetAddressContext(ctx, contractName, opts)
 			if err == nil {
 				addresses[ci] = address
 			}
@@ -145,43 +134,26 @@ func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string)
 
 // Load Rocket Pool contract ABIs
 func (rp *RocketPool) GetABI(contractName string, opts *bind.CallOpts) (*abi.ABI, error) {
+	return rp.GetABIContext(GetCallContext(opts), contractName, opts)
+}
 
-	// Check for cached ABI
-	if opts == nil {
-		if cached, ok := rp.getCachedABI(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
-				return cached.abi, nil
-			} else {
-				rp.deleteCachedABI(contractName)
-			}
-		}
-	}
+// Load Rocket Pool contract ABIs with a context
+func (rp *RocketPool) GetABIContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*abi.ABI, error) {
 
 	// Get ABI
-	abiEncoded, err := rp.RocketStorage.GetString(opts, crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)))
+	abiEncoded, err := rp.getEncodedABI(ctx, contractName, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error loading contract %s ABI: %w", contractName, err)
+		return nil, err
 	}
 
 	// Decode ABI
-	abi, err := DecodeAbi(abiEncoded)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding contract %s ABI: %w", contractName, err)
-	}
-
-	// Cache ABI
-	if opts == nil {
-		rp.setCachedABI(contractName, cachedABI{
-			abi:  abi,
-			time: time.Now().Unix(),
-		})
-	}
-
-	// Return
-	return abi, nil
+	return rp.abis.get(contractName, abiEncoded)
 
 }
 func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*abi.ABI, error) {
+	return rp.GetABIsContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetABIsContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*abi.ABI, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -191,7 +163,7 @@ func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			abi, err := rp.GetABI(contractName, opts)
+			abi, err := rp.GetABIContext(ctx, contractName, opts)
 			if err == nil {
 				abis[ci] = abi
 			}
@@ -211,32 +183,26 @@ func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*
 
 // Load Rocket Pool contracts
 func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Contract, error) {
+	return rp.GetContractContext(GetCallContext(opts), contractName, opts)
+}
 
-	// Check for cached contract
-	if opts == nil {
-		if cached, ok := rp.getCachedContract(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
-				return cached.contract, nil
-			} else {
-				rp.deleteCachedContract(contractName)
-			}
-		}
-	}
+// Load Rocket Pool contracts with a context
+func (rp *RocketPool) GetContractContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*Contract, error) {
 
 	// Data
 	var wg errgroup.Group
 	var address *common.Address
-	var abi *abi.ABI
+	var abiEncoded string
 
 	// Load data
 	wg.Go(func() error {
 		var err error
-		address, err = rp.GetAddress(contractName, opts)
+		address, err = rp.GetAddressContext(ctx, contractName, opts)
 		return err
 	})
 	wg.Go(func() error {
 		var err error
-		abi, err = rp.GetABI(contractName, opts)
+		abiEncoded, err = rp.getEncodedABI(ctx, contractName, opts)
 		return err
 	})
 
@@ -245,25 +211,33 @@ func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Co
 		return nil, err
 	}
 
+	// Check for a contract already bound from the same values
+	key := contractKey{address: *address, abiEncoded: abiEncoded}
+	if contract, exists := rp.contracts.get(contractName, key); exists {
+		return contract, nil
+	}
+
 	// Create contract
+	abi, err := rp.abis.get(contractName, abiEncoded)
+	if err != nil {
+		return nil, err
+	}
 	contract := &Contract{
 		Contract: bind.NewBoundContract(*address, *abi, rp.Client, rp.Client, rp.Client),
 		Address:  address,
 		ABI:      abi,
 		Client:   rp.Client,
 	}
-
-	// Cache contract
-	rp.setCachedContract(contractName, cachedContract{
-		contract: contract,
-		time:     time.Now().Unix(),
-	})
+	rp.contracts.set(contractName, key, contract)
 
 	// Return
 	return contract, nil
 
 }
 func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string) ([]*Contract, error) {
+	return rp.GetContractsContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetContractsContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*Contract, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -273,7 +247,7 @@ func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string)
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			contract, err := rp.GetContract(contractName, opts)
+			contract, err := rp.GetContractContext(ctx, contractName, opts)
 			if err == nil {
 				contracts[ci] = contract
 			}
@@ -293,9 +267,14 @@ func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string)
 
 // Create a Rocket Pool contract instance
 func (rp *RocketPool) MakeContract(contractName string, address common.Address, opts *bind.CallOpts) (*Contract, error) {
+	return rp.MakeContractContext(GetCallContext(opts), contractName, address, opts)
+}
+
+// Create a Rocket Pool contract instance with a context
+func (rp *RocketPool) MakeContractContext(ctx context.Context, contractName string, address common.Address, opts *bind.CallOpts) (*Contract, error) {
 
 	// Load ABI
-	abi, err := rp.GetABI(contractName, opts)
+	abi, err := rp.GetABIContext(ctx, contractName, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -310,56 +289,80 @@ func (rp *RocketPool) MakeContract(contractName string, address common.Address,
 
 }
 
-// Address cache control
-func (rp *RocketPool) getCachedAddress(contractName string) (cachedAddress, bool) {
-	rp.addressesLock.RLock()
-	defer rp.addressesLock.RUnlock()
-	value, ok := rp.addresses[contractName]
-	return value, ok
-}
-func (rp *RocketPool) setCachedAddress(contractName string, value cachedAddress) {
-	rp.addressesLock.Lock()
-	defer rp.addressesLock.Unlock()
-	rp.addresses[contractName] = value
-}
-func (rp *RocketPool) deleteCachedAddress(contractName string) {
-	rp.addressesLock.Lock()
-	defer rp.addressesLock.Unlock()
-	delete(rp.addresses, contractName)
+// Get a contract's encoded ABI from the cache, loading and caching it on a miss
+func (rp *RocketPool) getEncodedABI(ctx context.Context, contractName string, opts *bind.CallOpts) (string, error) {
+	return rp.getCachedValue(ctx, CacheValueType_ABI, contractName, opts, func(callOpts *bind.CallOpts) (string, error) {
+		abiEncoded, err := rp.RocketStorage.GetString(callOpts, crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)))
+		if err != nil {
+			return "", fmt.Errorf("error loading contract %s ABI: %w", contractName, err)
+		}
+		return abiEncoded, nil
+	})
 }
 
-// ABI cache control
-func (rp *RocketPool) getCachedABI(contractName string) (cachedABI, bool) {
-	rp.abisLock.RLock()
-	defer rp.abisLock.RUnlock()
-	value, ok := rp.abis[contractName]
-	return value, ok
-}
-func (rp *RocketPool) setCachedABI(contractName string, value cachedABI) {
-	rp.abisLock.Lock()
-	defer rp.abisLock.Unlock()
-	rp.abis[contractName] = value
-}
-func (rp *RocketPool) deleteCachedABI(contractName string) {
-	rp.abisLock.Lock()
-	defer rp.abisLock.Unlock()
-	delete(rp.abis, contractName)
-}
+// Get a contract value from the cache, loading and caching it on a miss
+// Current values are loaded at the latest block so the block range they are valid from is known
+func (rp *RocketPool) getCachedValue(ctx context.Context, valueType CacheValueType, contractName string, opts *bind.CallOpts, load func(*bind.CallOpts) (string, error)) (string, error) {
+
+	// Pending state and block tags can't be cached
+	callOpts := callOptsWithContext(ctx, opts)
+	if callOpts.BlockNumber == nil {
+		callOpts.BlockNumber = rp.getPinnedBlockNumber()
+	}
+	if callOpts.Pending || (callOpts.BlockNumber != nil && callOpts.BlockNumber.Sign() < 0) {
+		return load(callOpts)
+	}
+
+	// Check for a cached value, applying any upgrades since the last sync to current values first
+	var blockNumber *uint64
+	if callOpts.BlockNumber != nil {
+		number := callOpts.BlockNumber.Uint64()
+		blockNumber = &number
+	} else if err := rp.syncCacheIfStale(ctx); err != nil {
+		return "", err
+	}
+	value, exists, err := rp.cache.Get(valueType, contractName, blockNumber)
+	if err != nil {
+		return "", fmt.Errorf("error reading contract %s %s from cache: %w", contractName, valueType, err)
+	}
+	if exists {
+		return value, nil
+	}
+
+	// Pin current lookups to the latest block
+	current := (blockNumber == nil)
+	if current {
+		latestBlock, err := rp.Client.BlockNumber(ctx)
+		if err != nil {
+			return "", fmt.Errorf("error getting latest block number: %w", err)
+		}
+		blockNumber = &latestBlock
+		callOpts.BlockNumber = big.NewInt(0).SetUint64(latestBlock)
+	}
+
+	// Load and cache the value
+	value, err = load(callOpts)
+	if err != nil {
+		return "", err
+	}
+	if err := rp.cache.Set(valueType, contractName, value, *blockNumber, current); err != nil {
+		return "", fmt.Errorf("error caching contract %s %s: %w", contractName, valueType, err)
+	}
+
+	// Upgrades before the first current lookup are already reflected in the cache
+	if current {
+		checkpoint, err := rp.cache.GetCheckpoint()
+		if err != nil {
+			return "", fmt.Errorf("error getting contract cache checkpoint: %w", err)
+		}
+		if checkpoint == 0 {
+			if err := rp.cache.SetCheckpoint(*blockNumber); err != nil {
+				return "", fmt.Errorf("error setting contract cache checkpoint: %w", err)
+			}
+		}
+	}
+
+	// Return
+	return value, nil
 
-// Contract cache control
-func (rp *RocketPool) getCachedContract(contractName string) (cachedContract, bool) {
-	rp.contractsLock.RLock()
-	defer rp.contractsLock.RUnlock()
-	value, ok := rp.contracts[contractName]
-	return value, ok
-}
-func (rp *RocketPool) setCachedContract(contractName string, value cachedContract) {
-	rp.contractsLock.Lock()
-	defer rp.contractsLock.Unlock()
-	rp.contracts[contractName] = value
-}
-func (rp *RocketPool) deleteCachedContract(contractName string) {
-	rp.contractsLock.Lock()
-	defer rp.contractsLock.Unlock()
-	delete(rp.contracts, contractName)
 }
@@ -0,0 +1,66 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Returned when a dry run needs a client that implements StateOverrideClient
+var ErrStateOverridesNotSupported = errors.New("execution client can't trace storage changes or override state")
+
+// Storage slot values by contract address
+type StorageChanges map[common.Address]map[common.Hash]common.Hash
+
+// Trace the storage a call to the contract with raw calldata would write at the latest block, without sending a transaction
+func (c *Contract) TraceCalldataStorageChanges(opts *bind.TransactOpts, data []byte) (StorageChanges, error) {
+	return c.TraceCalldataStorageChangesContext(getTransactContext(opts), opts, data)
+}
+
+// Trace the storage a call to the contract with raw calldata would write with a context
+func (c *Contract) TraceCalldataStorageChangesContext(ctx context.Context, opts *bind.TransactOpts, data []byte) (StorageChanges, error) {
+	client, ok := c.Client.(StateOverrideClient)
+	if !ok {
+		return nil, ErrStateOverridesNotSupported
+	}
+	changes, err := client.TraceCallStorageChanges(ctx, ethereum.CallMsg{
+		From:  opts.From,
+		To:    c.Address,
+		Gas:   opts.GasLimit,
+		Value: opts.Value,
+		Data:  data,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error tracing storage changes: %w", err)
+	}
+	return changes, nil
+}
+
+// Call a contract method with storage slots replaced, e.g. to read a value as a traced call would leave it
+func (c *Contract) CallWithStorage(opts *bind.CallOpts, storage StorageChanges, result interface{}, method string, params ...interface{}) error {
+	return c.CallWithStorageContext(GetCallContext(opts), opts, storage, result, method, params...)
+}
+
+// Call a contract method with storage slots replaced with a context
+func (c *Contract) CallWithStorageContext(ctx context.Context, opts *bind.CallOpts, storage StorageChanges, result interface{}, method string, params ...interface{}) error {
+	client, ok := c.Client.(StateOverrideClient)
+	if !ok {
+		return ErrStateOverridesNotSupported
+	}
+	if opts == nil {
+		opts = &bind.CallOpts{}
+	}
+	input, err := c.ABI.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	output, err := client.CallContractWithStorage(ctx, ethereum.CallMsg{From: opts.From, To: c.Address, Data: input}, opts.BlockNumber, storage)
+	if err != nil {
+		return err
+	}
+	return c.ABI.UnpackIntoInterface(result, method, output)
+}
@@ -2,11 +2,9 @@ package rocketpool
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/hashicorp/go-version"
 )
 
@@ -47,40 +45,36 @@ func getLegacyContract(rp *RocketPool, contractName string, m LegacyVersionWrapp
 		return rp.GetContract(contractName, opts)
 	}
 
-	// Check for cached contract
-	if cached, ok := rp.getCachedContract(legacyName); ok {
-		if time.Now().Unix()-cached.time <= CacheTTL {
-			return cached.contract, nil
-		} else {
-			rp.deleteCachedContract(legacyName)
-		}
-	}
-
 	// Try to get the legacy address from RocketStorage first
 	emptyAddress := common.Address{}
-	address, err := rp.RocketStorage.GetAddress(nil, crypto.Keccak256Hash([]byte("contract.address"), []byte(legacyName)))
+	address, err := rp.GetAddress(legacyName, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error loading v%s contract %s address: %w", m.GetVersion().String(), contractName, err)
 	}
 
-	if address == emptyAddress {
+	if *address == emptyAddress {
 		// Not found, so the legacy contract is still on the network - try loading the original contract name instead
 		return rp.GetContract(contractName, opts)
 	}
 
 	// If we're here, we have a legacy contract
 	abiEncoded := m.GetEncodedABI(contractName)
-	abi, err := DecodeAbi(abiEncoded)
+	key := contractKey{address: *address, abiEncoded: abiEncoded}
+	if contract, exists := rp.contracts.get(legacyName, key); exists {
+		return contract, nil
+	}
+	abi, err := rp.abis.get(legacyName, abiEncoded)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding contract %s ABI: %w", contractName, err)
+		return nil, err
 	}
 
 	contract := &Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
+		Contract: bind.NewBoundContract(*address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:  address,
 		ABI:      abi,
 		Client:   rp.Client,
 	}
+	rp.contracts.set(legacyName, key, contract)
 
 	return contract, nil
 
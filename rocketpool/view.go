@@ -0,0 +1,283 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/rocket-pool/rocketpool-go/contracts"
+)
+
+// Returned when the block a view is pinned to is no longer part of the canonical chain
+var ErrPinnedBlockReorged = errors.New("pinned block is no longer canonical")
+
+// Returned when a transaction is sent through a block-pinned view
+var ErrReadOnlyView = errors.New("block-pinned views are read-only")
+
+// Get a read-only view of Rocket Pool pinned to the given block.
+// Reads through the view fail with ErrPinnedBlockReorged once the block is reorged. Clients that implement
+// CanonicalHashReader (e.g. EIP1898Client) detect this in the read itself; other clients need a header request per read.
+func (rp *RocketPool) AtBlock(blockNumber uint64) (*RocketPool, error) {
+	return rp.AtBlockContext(context.Background(), blockNumber)
+}
+
+// Get a read-only view of Rocket Pool pinned to the given block with a context
+func (rp *RocketPool) AtBlockContext(ctx context.Context, blockNumber uint64) (*RocketPool, error) {
+	client := rp.getBaseClient()
+	header, err := client.HeaderByNumber(ctx, big.NewInt(0).SetUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %d: %w", blockNumber, err)
+	}
+	return rp.newView(client, header)
+}
+
+// Get a read-only view of Rocket Pool pinned to the block with the given hash
+func (rp *RocketPool) AtBlockHash(blockHash common.Hash) (*RocketPool, error) {
+	return rp.AtBlockHashContext(context.Background(), blockHash)
+}
+
+// Get a read-only view of Rocket Pool pinned to the block with the given hash with a context
+func (rp *RocketPool) AtBlockHashContext(ctx context.Context, blockHash common.Hash) (*RocketPool, error) {
+	client := rp.getBaseClient()
+	header, err := client.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %s: %w", blockHash.Hex(), err)
+	}
+	view, err := rp.newView(client, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// A known hash may already have been reorged out
+	if err := view.CheckPinnedBlockContext(ctx); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// Get the block a view is pinned to; returns false if this isn't a block-pinned view
+func (rp *RocketPool) GetPinnedBlock() (uint64, common.Hash, bool) {
+	pinned, ok := rp.Client.(*pinnedClient)
+	if !ok {
+		return 0, common.Hash{}, false
+	}
+	return pinned.blockNumber.Uint64(), pinned.blockHash, true
+}
+
+// Check that the block a view is pinned to is still canonical
+func (rp *RocketPool) CheckPinnedBlock() error {
+	return rp.CheckPinnedBlockContext(context.Background())
+}
+
+// Check that the block a view is pinned to is still canonical with a context
+func (rp *RocketPool) CheckPinnedBlockContext(ctx context.Context) error {
+	pinned, ok := rp.Client.(*pinnedClient)
+	if !ok {
+		return nil
+	}
+	return pinned.checkCanonical(ctx)
+}
+
+// Create a view of Rocket Pool that reads through a client pinned to the given header
+func (rp *RocketPool) newView(client ExecutionClient, header *types.Header) (*RocketPool, error) {
+	pinned := &pinnedClient{
+		ExecutionClient: client,
+		blockNumber:     header.Number,
+		blockHash:       header.Hash(),
+	}
+
+	// Rebind RocketStorage to the pinned client
+	rocketStorageAddress := *rp.RocketStorageContract.Address
+	rocketStorage, err := contracts.NewRocketStorage(rocketStorageAddress, pinned)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Rocket Pool storage contract: %w", err)
+	}
+	rsAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		return nil, err
+	}
+	contract := &Contract{
+		Contract: bind.NewBoundContract(rocketStorageAddress, rsAbi, pinned, pinned, pinned),
+		Address:  &rocketStorageAddress,
+		ABI:      &rsAbi,
+		Client:   pinned,
+	}
+
+	// Share the contract cache and decoded ABIs; pinned lookups are always historical
+	view := &RocketPool{
+		Client:                pinned,
+		RocketStorage:         rocketStorage,
+		RocketStorageContract: contract,
+		cache:                 rp.cache,
+		abis:                  rp.abis,
+		contracts:             newContractCache(),
+	}
+	view.VersionManager = NewVersionManager(view)
+	return view, nil
+}
+
+// Get the client this instance wraps, unwrapping any pinned view
+func (rp *RocketPool) getBaseClient() ExecutionClient {
+	if pinned, ok := rp.Client.(*pinnedClient); ok {
+		return pinned.ExecutionClient
+	}
+	return rp.Client
+}
+
+// Get the block number a pinned view reads at, or nil if this isn't a pinned view
+func (rp *RocketPool) getPinnedBlockNumber() *big.Int {
+	if pinned, ok := rp.Client.(*pinnedClient); ok {
+		return big.NewInt(0).Set(pinned.blockNumber)
+	}
+	return nil
+}
+
+// An execution client that reads the latest state from a pinned block and refuses to send transactions
+type pinnedClient struct {
+	ExecutionClient
+	blockNumber *big.Int
+	blockHash   common.Hash
+}
+
+// Check that the pinned block is still canonical
+func (c *pinnedClient) checkCanonical(ctx context.Context) error {
+	header, err := c.ExecutionClient.HeaderByNumber(ctx, c.blockNumber)
+	if err != nil {
+		return fmt.Errorf("error getting header for pinned block %s: %w", c.blockNumber.String(), err)
+	}
+	if header.Hash() != c.blockHash {
+		return fmt.Errorf("%w: block %s was %s but is now %s", ErrPinnedBlockReorged, c.blockNumber.String(), c.blockHash.Hex(), header.Hash().Hex())
+	}
+	return nil
+}
+
+// Get the block to read at, substituting the pinned block for the latest one
+func (c *pinnedClient) getReadBlock(blockNumber *big.Int) (*big.Int, bool) {
+	if blockNumber == nil || blockNumber.Cmp(c.blockNumber) == 0 {
+		return c.blockNumber, true
+	}
+	return blockNumber, false
+}
+
+// Get the reader for EIP-1898 reads at the pinned block, if the client supports them
+func (c *pinnedClient) getHashReader(pinned bool) (CanonicalHashReader, bool) {
+	if !pinned {
+		return nil, false
+	}
+	reader, ok := c.ExecutionClient.(CanonicalHashReader)
+	return reader, ok
+}
+
+// Check why a canonical hash read failed, returning ErrPinnedBlockReorged if the pinned block was reorged
+func (c *pinnedClient) checkHashRead(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if canonicalErr := c.checkCanonical(ctx); errors.Is(canonicalErr, ErrPinnedBlockReorged) {
+		return canonicalErr
+	}
+	return err
+}
+
+// Check that the pinned block was still canonical after a read by number, so the result can't come from another fork
+func (c *pinnedClient) checkNumberRead(ctx context.Context, pinned bool, err error) error {
+	if err != nil || !pinned {
+		return err
+	}
+	return c.checkCanonical(ctx)
+}
+
+// Reads at the pinned block select it by hash with EIP-1898 if the client supports it, which fails once the block is reorged.
+// Otherwise they select it by number and check that the block is still canonical afterwards.
+func (c *pinnedClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	blockNumber, pinned := c.getReadBlock(blockNumber)
+	if reader, ok := c.getHashReader(pinned); ok {
+		code, err := reader.CodeAtCanonicalHash(ctx, contract, c.blockHash)
+		return code, c.checkHashRead(ctx, err)
+	}
+	code, err := c.ExecutionClient.CodeAt(ctx, contract, blockNumber)
+	if err := c.checkNumberRead(ctx, pinned, err); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func (c *pinnedClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	blockNumber, pinned := c.getReadBlock(blockNumber)
+	if reader, ok := c.getHashReader(pinned); ok {
+		result, err := reader.CallContractAtCanonicalHash(ctx, call, c.blockHash)
+		return result, c.checkHashRead(ctx, err)
+	}
+	result, err := c.ExecutionClient.CallContract(ctx, call, blockNumber)
+	if err := c.checkNumberRead(ctx, pinned, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *pinnedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	number, pinned := c.getReadBlock(number)
+	header, err := c.ExecutionClient.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if pinned && header.Hash() != c.blockHash {
+		return nil, fmt.Errorf("%w: block %s was %s but is now %s", ErrPinnedBlockReorged, c.blockNumber.String(), c.blockHash.Hex(), header.Hash().Hex())
+	}
+	return header, nil
+}
+
+func (c *pinnedClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	blockNumber, pinned := c.getReadBlock(blockNumber)
+	if reader, ok := c.getHashReader(pinned); ok {
+		balance, err := reader.BalanceAtCanonicalHash(ctx, account, c.blockHash)
+		return balance, c.checkHashRead(ctx, err)
+	}
+	balance, err := c.ExecutionClient.BalanceAt(ctx, account, blockNumber)
+	if err := c.checkNumberRead(ctx, pinned, err); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (c *pinnedClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	blockNumber, pinned := c.getReadBlock(blockNumber)
+	if reader, ok := c.getHashReader(pinned); ok {
+		nonce, err := reader.NonceAtCanonicalHash(ctx, account, c.blockHash)
+		return nonce, c.checkHashRead(ctx, err)
+	}
+	nonce, err := c.ExecutionClient.NonceAt(ctx, account, blockNumber)
+	if err := c.checkNumberRead(ctx, pinned, err); err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+// The latest block of a pinned view is the pinned block
+func (c *pinnedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.blockNumber.Uint64(), nil
+}
+
+// Log queries without an upper bound stop at the pinned block
+func (c *pinnedClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if query.BlockHash == nil && query.ToBlock == nil {
+		query.ToBlock = c.blockNumber
+	}
+	return c.ExecutionClient.FilterLogs(ctx, query)
+}
+
+func (c *pinnedClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return ErrReadOnlyView
+}
+
+func (c *pinnedClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, fmt.Errorf("cannot subscribe to new logs through a view pinned to block %s", c.blockNumber.String())
+}
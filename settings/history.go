@@ -0,0 +1,163 @@
+package settings
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// The name of the event settings contracts emit when a setting changes
+const SettingUpdatedEventName = "SettingUpdated"
+
+// A setting that differs between two snapshots
+type SettingChange struct {
+	Setting Setting      `json:"setting"`
+	Old     SettingValue `json:"old"`
+	New     SettingValue `json:"new"`
+}
+
+// A change to a setting found in the event history
+type SettingUpdate struct {
+	BlockNumber uint64       `json:"blockNumber"`
+	TxHash      common.Hash  `json:"txHash"`
+	Old         SettingValue `json:"old"`
+	New         SettingValue `json:"new"`
+}
+
+// Check if two values of the same setting are equal
+func (v SettingValue) Equals(other SettingValue) bool {
+	if v.Available != other.Available {
+		return false
+	}
+	if !v.Available {
+		return true
+	}
+	switch v.Setting.Type {
+	case types.ProposalSettingType_Bool:
+		return v.Bool == other.Bool
+	case types.ProposalSettingType_Address:
+		return v.Address == other.Address
+	default:
+		if v.Uint == nil || other.Uint == nil {
+			return v.Uint == other.Uint
+		}
+		return v.Uint.Cmp(other.Uint) == 0
+	}
+}
+
+// Get the settings that changed between two blocks
+func GetSettingsDiff(rp *rocketpool.RocketPool, multicallerAddress common.Address, fromBlock *big.Int, toBlock *big.Int) ([]SettingChange, error) {
+	oldValues, err := GetAllSettings(rp, multicallerAddress, &bind.CallOpts{BlockNumber: fromBlock})
+	if err != nil {
+		return nil, fmt.Errorf("error getting settings at block %s: %w", fromBlock.String(), err)
+	}
+	newValues, err := GetAllSettings(rp, multicallerAddress, &bind.CallOpts{BlockNumber: toBlock})
+	if err != nil {
+		return nil, fmt.Errorf("error getting settings at block %s: %w", toBlock.String(), err)
+	}
+	return DiffSettings(oldValues, newValues), nil
+}
+
+// Compare two snapshots of setting values.
+// Settings are matched by contract and path; settings only present in one snapshot are compared against an unavailable value.
+func DiffSettings(oldValues []SettingValue, newValues []SettingValue) []SettingChange {
+	type settingKey struct {
+		contractName string
+		path         string
+	}
+
+	oldLookup := make(map[settingKey]SettingValue, len(oldValues))
+	for _, value := range oldValues {
+		oldLookup[settingKey{value.Setting.ContractName, value.Setting.Path}] = value
+	}
+
+	changes := []SettingChange{}
+	seen := make(map[settingKey]bool, len(newValues))
+	for _, newValue := range newValues {
+		key := settingKey{newValue.Setting.ContractName, newValue.Setting.Path}
+		seen[key] = true
+		oldValue, exists := oldLookup[key]
+		if !exists {
+			oldValue = SettingValue{Setting: newValue.Setting}
+		}
+		if !oldValue.Equals(newValue) {
+			changes = append(changes, SettingChange{Setting: newValue.Setting, Old: oldValue, New: newValue})
+		}
+	}
+	for _, oldValue := range oldValues {
+		key := settingKey{oldValue.Setting.ContractName, oldValue.Setting.Path}
+		if !seen[key] && oldValue.Available {
+			changes = append(changes, SettingChange{Setting: oldValue.Setting, Old: oldValue, New: SettingValue{Setting: oldValue.Setting}})
+		}
+	}
+	return changes
+}
+
+// Reconstruct the history of a setting between two blocks (inclusive) from the SettingUpdated events of every deployment of its contract.
+// The setting is read at each block with an event, so the history doesn't depend on the event's fields.
+// Blocks before the contract or its getter was deployed read as unavailable values.
+func GetSettingHistory(rp *rocketpool.RocketPool, contractName string, path string, fromBlock *big.Int, toBlock *big.Int, intervalSize *big.Int, opts *bind.CallOpts) ([]SettingUpdate, error) {
+	if _, exists := GetSettingDefinition(contractName, path); !exists {
+		return nil, fmt.Errorf("setting %s is not registered for contract %s", path, contractName)
+	}
+	contract, err := getSettingContract(rp, contractName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil {
+		return []SettingUpdate{}, nil
+	}
+	event, exists := contract.ABI.Events[SettingUpdatedEventName]
+	if !exists {
+		return nil, fmt.Errorf("contract %s does not have a %s event", contractName, SettingUpdatedEventName)
+	}
+
+	// Get the update events across all deployments
+	logs, err := eth.FilterContractLogs(rp, contractName, eth.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Topics:    [][]common.Hash{{event.ID}},
+	}, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s events for %s: %w", SettingUpdatedEventName, contractName, err)
+	}
+
+	// Get the starting value
+	var previous SettingValue
+	if fromBlock != nil && fromBlock.Sign() > 0 {
+		previous, err = GetSetting(rp, contractName, path, &bind.CallOpts{BlockNumber: big.NewInt(0).Sub(fromBlock, big.NewInt(1))})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the value after each block with an event
+	updates := []SettingUpdate{}
+	var lastBlock uint64
+	for i, log := range logs {
+		if i > 0 && log.BlockNumber == lastBlock {
+			continue
+		}
+		lastBlock = log.BlockNumber
+		value, err := GetSetting(rp, contractName, path, &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(log.BlockNumber)})
+		if err != nil {
+			return nil, err
+		}
+		if !value.Equals(previous) {
+			updates = append(updates, SettingUpdate{
+				BlockNumber: log.BlockNumber,
+				TxHash:      log.TxHash,
+				Old:         previous,
+				New:         value,
+			})
+		}
+		previous = value
+	}
+	return updates, nil
+}
@@ -14,6 +14,8 @@ import (
 // Config
 const (
 	InflationSettingsContractName string = "rocketDAOProtocolSettingsInflation"
+	InflationRateSettingPath      string = "rpl.inflation.interval.rate"
+	InflationStartTimeSettingPath string = "rpl.inflation.interval.start"
 )
 
 // RPL inflation rate per interval
@@ -106,7 +106,20 @@ func GetRewardsClaimersPercTotal(rp *rocketpool.RocketPool, opts *bind.CallOpts)
 	return eth.WeiToEth(*value), nil
 }
 
-// Rewards claim interval time
+// The number of inflation intervals in a rewards claim interval
+func GetRewardsClaimIntervalPeriods(rp *rocketpool.RocketPool, opts *bind.CallOpts) (uint64, error) {
+	rewardsSettingsContract, err := getRewardsSettingsContract(rp, opts)
+	if err != nil {
+		return 0, err
+	}
+	value := new(*big.Int)
+	if err := rewardsSettingsContract.Call(opts, value, "getRewardsClaimIntervalPeriods"); err != nil {
+		return 0, fmt.Errorf("error getting rewards claim interval periods: %w", err)
+	}
+	return (*value).Uint64(), nil
+}
+
+// Rewards claim interval time, derived from the number of periods
 func GetRewardsClaimIntervalTime(rp *rocketpool.RocketPool, opts *bind.CallOpts) (time.Duration, error) {
 	rewardsSettingsContract, err := getRewardsSettingsContract(rp, opts)
 	if err != nil {
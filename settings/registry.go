@@ -0,0 +1,441 @@
+package settings
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/dao/security"
+	trustednodedao "github.com/rocket-pool/rocketpool-go/dao/trustednode"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+)
+
+// The DAO that owns a setting
+type SettingDao string
+
+const (
+	SettingDao_Protocol  SettingDao = "protocol"
+	SettingDao_OracleDao SettingDao = "oracleDao"
+)
+
+// The unit of a setting's value
+type SettingUnit string
+
+const (
+	SettingUnit_None       SettingUnit = "none"       // Flags
+	SettingUnit_Count      SettingUnit = "count"      // Plain integers
+	SettingUnit_Wei        SettingUnit = "wei"        // ETH or RPL amounts
+	SettingUnit_Percentage SettingUnit = "percentage" // Fractions between 0 and 1, scaled by 1e18
+	SettingUnit_Ratio      SettingUnit = "ratio"      // Unbounded fractions, scaled by 1e18
+	SettingUnit_Duration   SettingUnit = "duration"   // Seconds
+	SettingUnit_Timestamp  SettingUnit = "timestamp"  // Unix seconds
+	SettingUnit_Blocks     SettingUnit = "blocks"
+)
+
+// A DAO setting
+type Setting struct {
+	Dao             SettingDao                `json:"dao"`
+	ContractName    string                    `json:"contractName"`
+	Path            string                    `json:"path"`
+	Getter          string                    `json:"getter"`
+	Type            types.ProposalSettingType `json:"type"`
+	Unit            SettingUnit               `json:"unit"`
+	SecurityCouncil bool                      `json:"securityCouncil"` // Whether the security council can change it
+
+	// The guardrails the setting's contract enforces on uint values, if it has them
+	Min *big.Int `json:"min,omitempty"`
+	Max *big.Int `json:"max,omitempty"`
+}
+
+// A setting's value at a block
+type SettingValue struct {
+	Setting   Setting        `json:"setting"`
+	Available bool           `json:"available"` // False if the getter doesn't exist in the deployed contract
+	Uint      *big.Int       `json:"uint,omitempty"`
+	Bool      bool           `json:"bool"`
+	Address   common.Address `json:"address"`
+}
+
+// Every setting that can be changed by a DAO proposal
+var registry = []Setting{
+	// Protocol DAO auction settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.CreateLotEnabledSettingPath, Getter: "getCreateLotEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.BidOnLotEnabledSettingPath, Getter: "getBidOnLotEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.LotMinimumEthValueSettingPath, Getter: "getLotMinimumEthValue", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.LotMaximumEthValueSettingPath, Getter: "getLotMaximumEthValue", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.LotDurationSettingPath, Getter: "getLotDuration", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.LotStartingPriceRatioSettingPath, Getter: "getStartingPriceRatio", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.AuctionSettingsContractName, Path: psettings.LotReservePriceRatioSettingPath, Getter: "getReservePriceRatio", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false},
+
+	// Protocol DAO deposit settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.DepositEnabledSettingPath, Getter: "getDepositEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.AssignDepositsEnabledSettingPath, Getter: "getAssignDepositsEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.MinimumDepositSettingPath, Getter: "getMinimumDeposit", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.MaximumDepositPoolSizeSettingPath, Getter: "getMaximumDepositPoolSize", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.MaximumDepositAssignmentsSettingPath, Getter: "getMaximumDepositAssignments", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Count, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.MaximumSocializedDepositAssignmentsSettingPath, Getter: "getMaximumDepositSocialisedAssignments", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Count, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.DepositSettingsContractName, Path: psettings.DepositFeeSettingPath, Getter: "getDepositFee", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Max: big.NewInt(1e16)},
+
+	// Protocol DAO inflation settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.InflationSettingsContractName, Path: psettings.InflationRateSettingPath, Getter: "getInflationIntervalRate", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Ratio, SecurityCouncil: false, Max: big.NewInt(1000133680617113500)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.InflationSettingsContractName, Path: psettings.InflationStartTimeSettingPath, Getter: "getInflationIntervalStartTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Timestamp, SecurityCouncil: false},
+
+	// Protocol DAO minipool settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolSubmitWithdrawableEnabledSettingPath, Getter: "getSubmitWithdrawableEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolLaunchTimeoutSettingPath, Getter: "getLaunchTimeout", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(12 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.BondReductionEnabledSettingPath, Getter: "getBondReductionEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MaximumMinipoolCountSettingPath, Getter: "getMaximumCount", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Count, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolUserDistributeWindowStartSettingPath, Getter: "getUserDistributeWindowStart", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolUserDistributeWindowLengthSettingPath, Getter: "getUserDistributeWindowLength", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+
+	// Protocol DAO network settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.NodeConsensusThresholdSettingPath, Getter: "getNodeConsensusThreshold", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(51e16)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.SubmitBalancesEnabledSettingPath, Getter: "getSubmitBalancesEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.SubmitBalancesFrequencySettingPath, Getter: "getSubmitBalancesFrequency", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.SubmitPricesEnabledSettingPath, Getter: "getSubmitPricesEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.SubmitPricesFrequencySettingPath, Getter: "getSubmitPricesFrequency", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.MinimumNodeFeeSettingPath, Getter: "getMinimumNodeFee", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(5e16), Max: big.NewInt(2e17)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.TargetNodeFeeSettingPath, Getter: "getTargetNodeFee", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(5e16), Max: big.NewInt(2e17)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.MaximumNodeFeeSettingPath, Getter: "getMaximumNodeFee", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(5e16), Max: big.NewInt(2e17)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.NodeFeeDemandRangeSettingPath, Getter: "getNodeFeeDemandRange", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.TargetRethCollateralRateSettingPath, Getter: "getTargetRethCollateralRate", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Max: big.NewInt(5e17)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.NetworkPenaltyThresholdSettingPath, Getter: "getNodePenaltyThreshold", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(51e16)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.NetworkPenaltyPerRateSettingPath, Getter: "getPerPenaltyRate", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NetworkSettingsContractName, Path: psettings.SubmitRewardsEnabledSettingPath, Getter: "getSubmitRewardsEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+
+	// Protocol DAO node settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeRegistrationEnabledSettingPath, Getter: "getRegistrationEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.SmoothingPoolRegistrationEnabledSettingPath, Getter: "getSmoothingPoolRegistrationEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeDepositEnabledSettingPath, Getter: "getDepositEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.VacantMinipoolsEnabledSettingPath, Getter: "getVacantMinipoolsEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: true},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.MinimumPerMinipoolStakeSettingPath, Getter: "getMinimumPerMinipoolStake", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Ratio, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.MaximumPerMinipoolStakeSettingPath, Getter: "getMaximumPerMinipoolStake", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Ratio, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.NodeSettingsContractName, Path: psettings.MaximumStakeForVotingPowerSettingPath, Getter: "getMaximumStakeForVotingPower", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Ratio, SecurityCouncil: false, Min: big.NewInt(1e18)},
+
+	// Protocol DAO proposals settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.VotePhase1TimeSettingPath, Getter: "getVotePhase1Time", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(7 * 24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.VotePhase2TimeSettingPath, Getter: "getVotePhase2Time", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(7 * 24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.VoteDelayTimeSettingPath, Getter: "getVoteDelayTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(7 * 24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ExecuteTimeSettingPath, Getter: "getExecuteTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(7 * 24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ProposalBondSettingPath, Getter: "getProposalBond", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ChallengeBondSettingPath, Getter: "getChallengeBond", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ChallengePeriodSettingPath, Getter: "getChallengePeriod", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(30 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ProposalQuorumSettingPath, Getter: "getProposalQuorum", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(15e16), Max: big.NewInt(75e16)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ProposalVetoQuorumSettingPath, Getter: "getProposalVetoQuorum", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(51e16), Max: big.NewInt(75e16)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.ProposalsSettingsContractName, Path: psettings.ProposalMaxBlockAgeSettingPath, Getter: "getProposalMaxBlockAge", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Blocks, SecurityCouncil: false, Min: big.NewInt(128), Max: big.NewInt(7200)},
+
+	// Protocol DAO rewards settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.RewardsSettingsContractName, Path: psettings.RewardsClaimIntervalPeriodsSettingPath, Getter: "getRewardsClaimIntervalPeriods", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Count, SecurityCouncil: false},
+
+	// Protocol DAO security settings
+	{Dao: SettingDao_Protocol, ContractName: psettings.SecuritySettingsContractName, Path: psettings.SecurityMembersQuorumSettingPath, Getter: "getQuorum", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false, Min: big.NewInt(51e16), Max: big.NewInt(75e16)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.SecuritySettingsContractName, Path: psettings.SecurityMembersLeaveTimeSettingPath, Getter: "getLeaveTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Max: big.NewInt(14*24*60*60 - 1)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.SecuritySettingsContractName, Path: psettings.SecurityProposalVoteTimeSettingPath, Getter: "getVoteTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.SecuritySettingsContractName, Path: psettings.SecurityProposalExecuteTimeSettingPath, Getter: "getExecuteTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(24 * 60 * 60)},
+	{Dao: SettingDao_Protocol, ContractName: psettings.SecuritySettingsContractName, Path: psettings.SecurityProposalActionTimeSettingPath, Getter: "getActionTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false, Min: big.NewInt(24 * 60 * 60)},
+
+	// Oracle DAO members settings
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.QuorumSettingPath, Getter: "getQuorum", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.RPLBondSettingPath, Getter: "getRPLBond", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.MinipoolUnbondedMaxSettingPath, Getter: "getMinipoolUnbondedMax", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Count, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.MinipoolUnbondedMinFeeSettingPath, Getter: "getMinipoolUnbondedMinFee", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Percentage, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.ChallengeCooldownSettingPath, Getter: "getChallengeCooldown", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.ChallengeWindowSettingPath, Getter: "getChallengeWindow", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MembersSettingsContractName, Path: tnsettings.ChallengeCostSettingPath, Getter: "getChallengeCost", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Wei, SecurityCouncil: false},
+
+	// Oracle DAO minipool settings
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.ScrubPeriodPath, Getter: "getScrubPeriod", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.PromotionScrubPeriodPath, Getter: "getPromotionScrubPeriod", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.ScrubPenaltyEnabledPath, Getter: "getScrubPenaltyEnabled", Type: types.ProposalSettingType_Bool, Unit: SettingUnit_None, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.BondReductionWindowStartPath, Getter: "getBondReductionWindowStart", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.BondReductionWindowLengthPath, Getter: "getBondReductionWindowLength", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+
+	// Oracle DAO proposals settings
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.ProposalsSettingsContractName, Path: tnsettings.CooldownTimeSettingPath, Getter: "getCooldownTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.ProposalsSettingsContractName, Path: tnsettings.VoteTimeSettingPath, Getter: "getVoteTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.ProposalsSettingsContractName, Path: tnsettings.VoteDelayTimeSettingPath, Getter: "getVoteDelayTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.ProposalsSettingsContractName, Path: tnsettings.ExecuteTimeSettingPath, Getter: "getExecuteTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+	{Dao: SettingDao_OracleDao, ContractName: tnsettings.ProposalsSettingsContractName, Path: tnsettings.ActionTimeSettingPath, Getter: "getActionTime", Type: types.ProposalSettingType_Uint256, Unit: SettingUnit_Duration, SecurityCouncil: false},
+}
+
+// Get all of the registered settings
+func GetSettings() []Setting {
+	settings := make([]Setting, len(registry))
+	copy(settings, registry)
+	return settings
+}
+
+// Get a registered setting by its contract and path
+func GetSettingDefinition(contractName string, path string) (Setting, bool) {
+	for _, setting := range registry {
+		if setting.ContractName == contractName && setting.Path == path {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Get a setting the security council can change by its namespace and path
+func GetSecurityCouncilSettingDefinition(namespace string, path string) (Setting, bool) {
+	for _, setting := range registry {
+		settingNamespace, _, _ := strings.Cut(setting.Path, ".")
+		if setting.SecurityCouncil && settingNamespace == namespace && setting.Path == path {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Get a setting's value.
+// The value is unavailable if the setting's contract isn't deployed at the block, or its ABI doesn't have the getter yet.
+func GetSetting(rp *rocketpool.RocketPool, contractName string, path string, opts *bind.CallOpts) (SettingValue, error) {
+	return getSetting(rp, contractName, path, opts, func(contract *rocketpool.Contract, result interface{}, getter string) error {
+		return contract.Call(opts, result, getter)
+	})
+}
+
+// Get a setting's value with storage slots replaced, e.g. as a traced proposal execution would leave it.
+// The client must implement rocketpool.StateOverrideClient.
+func GetSettingWithStorage(rp *rocketpool.RocketPool, contractName string, path string, storage rocketpool.StorageChanges, opts *bind.CallOpts) (SettingValue, error) {
+	return getSetting(rp, contractName, path, opts, func(contract *rocketpool.Contract, result interface{}, getter string) error {
+		return contract.CallWithStorage(opts, storage, result, getter)
+	})
+}
+
+// Get a setting's value, calling its getter with the provided function
+func getSetting(rp *rocketpool.RocketPool, contractName string, path string, opts *bind.CallOpts, call func(contract *rocketpool.Contract, result interface{}, getter string) error) (SettingValue, error) {
+	setting, exists := GetSettingDefinition(contractName, path)
+	if !exists {
+		return SettingValue{}, fmt.Errorf("setting %s is not registered for contract %s", path, contractName)
+	}
+	contract, err := getSettingContract(rp, contractName, opts)
+	if err != nil {
+		return SettingValue{}, err
+	}
+	value := SettingValue{Setting: setting}
+	if !hasGetter(contract, setting) {
+		return value, nil
+	}
+	if err := call(contract, getSettingOutput(&value), setting.Getter); err != nil {
+		return SettingValue{}, fmt.Errorf("error getting setting %s: %w", path, err)
+	}
+	value.Available = true
+	return value, nil
+}
+
+// Get the values of all registered settings with a single multicall.
+// Settings whose contract isn't deployed at the block, or whose getter is missing from the contract's ABI, are unavailable.
+func GetAllSettings(rp *rocketpool.RocketPool, multicallerAddress common.Address, opts *bind.CallOpts) ([]SettingValue, error) {
+	if opts == nil {
+		opts = &bind.CallOpts{}
+	}
+	mc, err := multicall.NewMultiCaller(rp.Client, multicallerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error creating multicaller: %w", err)
+	}
+
+	// Add the getter calls for the settings the deployed contracts have
+	contracts := map[string]*rocketpool.Contract{}
+	values := make([]SettingValue, len(registry))
+	called := []int{}
+	for i, setting := range registry {
+		values[i].Setting = setting
+		contract, exists := contracts[setting.ContractName]
+		if !exists {
+			contract, err = getSettingContract(rp, setting.ContractName, opts)
+			if err != nil {
+				return nil, err
+			}
+			contracts[setting.ContractName] = contract
+		}
+		if !hasGetter(contract, setting) {
+			continue
+		}
+		if err := mc.AddCall(contract, getSettingOutput(&values[i]), setting.Getter); err != nil {
+			return nil, fmt.Errorf("error adding call for setting %s: %w", setting.Path, err)
+		}
+		called = append(called, i)
+	}
+	if len(called) == 0 {
+		return values, nil
+	}
+
+	// Run the multicall, allowing getters that revert to fail
+	results, err := mc.FlexibleCall(false, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	for i, result := range results {
+		values[called[i]].Available = result.Success
+	}
+	return values, nil
+}
+
+// Estimate the gas of ProposeSetting
+func EstimateProposeSettingGas(rp *rocketpool.RocketPool, setting Setting, value interface{}, blockNumber uint32, treeNodes []types.VotingTreeNode, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := CheckSettingValue(setting, value); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	message := fmt.Sprintf("set %s", setting.Path)
+	switch setting.Dao {
+	case SettingDao_Protocol:
+		switch setting.Type {
+		case types.ProposalSettingType_Uint256:
+			return protocol.EstimateProposeSetUintGas(rp, message, setting.ContractName, setting.Path, value.(*big.Int), blockNumber, treeNodes, opts)
+		case types.ProposalSettingType_Bool:
+			return protocol.EstimateProposeSetBoolGas(rp, message, setting.ContractName, setting.Path, value.(bool), blockNumber, treeNodes, opts)
+		case types.ProposalSettingType_Address:
+			return protocol.EstimateProposeSetAddressGas(rp, message, setting.ContractName, setting.Path, value.(common.Address), blockNumber, treeNodes, opts)
+		}
+	case SettingDao_OracleDao:
+		switch setting.Type {
+		case types.ProposalSettingType_Uint256:
+			return trustednodedao.EstimateProposeSetUintGas(rp, message, setting.ContractName, setting.Path, value.(*big.Int), opts)
+		case types.ProposalSettingType_Bool:
+			return trustednodedao.EstimateProposeSetBoolGas(rp, message, setting.ContractName, setting.Path, value.(bool), opts)
+		}
+	}
+	return rocketpool.GasInfo{}, fmt.Errorf("setting %s of type %d can't be proposed by the %s DAO", setting.Path, setting.Type, setting.Dao)
+}
+
+// Submit a proposal to change a setting to the DAO that owns it.
+// The block number and voting tree nodes are only used by Protocol DAO proposals.
+func ProposeSetting(rp *rocketpool.RocketPool, setting Setting, value interface{}, blockNumber uint32, treeNodes []types.VotingTreeNode, opts *bind.TransactOpts) (uint64, common.Hash, error) {
+	if err := CheckSettingValue(setting, value); err != nil {
+		return 0, common.Hash{}, err
+	}
+	message := fmt.Sprintf("set %s", setting.Path)
+	switch setting.Dao {
+	case SettingDao_Protocol:
+		switch setting.Type {
+		case types.ProposalSettingType_Uint256:
+			return protocol.ProposeSetUint(rp, message, setting.ContractName, setting.Path, value.(*big.Int), blockNumber, treeNodes, opts)
+		case types.ProposalSettingType_Bool:
+			return protocol.ProposeSetBool(rp, message, setting.ContractName, setting.Path, value.(bool), blockNumber, treeNodes, opts)
+		case types.ProposalSettingType_Address:
+			return protocol.ProposeSetAddress(rp, message, setting.ContractName, setting.Path, value.(common.Address), blockNumber, treeNodes, opts)
+		}
+	case SettingDao_OracleDao:
+		switch setting.Type {
+		case types.ProposalSettingType_Uint256:
+			return trustednodedao.ProposeSetUint(rp, message, setting.ContractName, setting.Path, value.(*big.Int), opts)
+		case types.ProposalSettingType_Bool:
+			return trustednodedao.ProposeSetBool(rp, message, setting.ContractName, setting.Path, value.(bool), opts)
+		}
+	}
+	return 0, common.Hash{}, fmt.Errorf("setting %s of type %d can't be proposed by the %s DAO", setting.Path, setting.Type, setting.Dao)
+}
+
+// Estimate the gas of ProposeSettingAsSecurityCouncil
+func EstimateProposeSettingAsSecurityCouncilGas(rp *rocketpool.RocketPool, setting Setting, value interface{}, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	namespace, err := checkSecurityCouncilSetting(setting, value)
+	if err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	message := fmt.Sprintf("set %s", setting.Path)
+	if setting.Type == types.ProposalSettingType_Bool {
+		return security.EstimateProposeSetBoolGas(rp, message, namespace, setting.Path, value.(bool), opts)
+	}
+	return security.EstimateProposeSetUintGas(rp, message, namespace, setting.Path, value.(*big.Int), opts)
+}
+
+// Submit a security council proposal to change a setting
+func ProposeSettingAsSecurityCouncil(rp *rocketpool.RocketPool, setting Setting, value interface{}, opts *bind.TransactOpts) (uint64, common.Hash, error) {
+	namespace, err := checkSecurityCouncilSetting(setting, value)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+	message := fmt.Sprintf("set %s", setting.Path)
+	if setting.Type == types.ProposalSettingType_Bool {
+		return security.ProposeSetBool(rp, message, namespace, setting.Path, value.(bool), opts)
+	}
+	return security.ProposeSetUint(rp, message, namespace, setting.Path, value.(*big.Int), opts)
+}
+
+// Check that a value has the right type for a setting and is within the bounds of its unit
+func CheckSettingValue(setting Setting, value interface{}) error {
+	switch setting.Type {
+	case types.ProposalSettingType_Uint256:
+		uintValue, ok := value.(*big.Int)
+		if !ok || uintValue == nil {
+			return fmt.Errorf("setting %s requires a *big.Int value", setting.Path)
+		}
+		if uintValue.Sign() < 0 {
+			return fmt.Errorf("setting %s can't be negative", setting.Path)
+		}
+		if setting.Unit == SettingUnit_Percentage && uintValue.Cmp(eth.EthToWei(1)) > 0 {
+			return fmt.Errorf("setting %s is a percentage and can't be more than 100%%", setting.Path)
+		}
+		if setting.Min != nil && uintValue.Cmp(setting.Min) < 0 {
+			return fmt.Errorf("setting %s can't be less than %s", setting.Path, SettingValue{Setting: setting, Uint: setting.Min}.String())
+		}
+		if setting.Max != nil && uintValue.Cmp(setting.Max) > 0 {
+			return fmt.Errorf("setting %s can't be more than %s", setting.Path, SettingValue{Setting: setting, Uint: setting.Max}.String())
+		}
+	case types.ProposalSettingType_Bool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("setting %s requires a bool value", setting.Path)
+		}
+	case types.ProposalSettingType_Address:
+		if _, ok := value.(common.Address); !ok {
+			return fmt.Errorf("setting %s requires a common.Address value", setting.Path)
+		}
+	default:
+		return fmt.Errorf("setting %s has unknown type %d", setting.Path, setting.Type)
+	}
+	return nil
+}
+
+// Check a security council setting change and get the setting's namespace
+func checkSecurityCouncilSetting(setting Setting, value interface{}) (string, error) {
+	if !setting.SecurityCouncil {
+		return "", fmt.Errorf("setting %s can't be changed by the security council", setting.Path)
+	}
+	if err := CheckSettingValue(setting, value); err != nil {
+		return "", err
+	}
+	namespace, _, _ := strings.Cut(setting.Path, ".")
+	return namespace, nil
+}
+
+// Get a settings contract, or nil if it isn't deployed at the block
+func getSettingContract(rp *rocketpool.RocketPool, contractName string, opts *bind.CallOpts) (*rocketpool.Contract, error) {
+	address, err := rp.GetAddress(contractName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if *address == (common.Address{}) {
+		return nil, nil
+	}
+	return rp.GetContract(contractName, opts)
+}
+
+// Check if a settings contract is deployed and has a setting's getter
+func hasGetter(contract *rocketpool.Contract, setting Setting) bool {
+	if contract == nil {
+		return false
+	}
+	_, exists := contract.ABI.Methods[setting.Getter]
+	return exists
+}
+
+// Get the output pointer for a setting's getter
+func getSettingOutput(value *SettingValue) interface{} {
+	switch value.Setting.Type {
+	case types.ProposalSettingType_Bool:
+		return &value.Bool
+	case types.ProposalSettingType_Address:
+		return &value.Address
+	default:
+		return &value.Uint
+	}
+}
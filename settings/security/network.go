@@ -23,6 +23,14 @@ func EstimateProposeSubmitBalancesEnabledGas(rp *rocketpool.RocketPool, value bo
 	return security.EstimateProposeSetBoolGas(rp, fmt.Sprintf("set %s", psettings.SubmitBalancesEnabledSettingPath), networkNamespace, psettings.SubmitBalancesEnabledSettingPath, value, opts)
 }
 
+// Network price submissions currently enabled
+func ProposeSubmitPricesEnabled(rp *rocketpool.RocketPool, value bool, opts *bind.TransactOpts) (uint64, common.Hash, error) {
+	return security.ProposeSetBool(rp, fmt.Sprintf("set %s", psettings.SubmitPricesEnabledSettingPath), networkNamespace, psettings.SubmitPricesEnabledSettingPath, value, opts)
+}
+func EstimateProposeSubmitPricesEnabledGas(rp *rocketpool.RocketPool, value bool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	return security.EstimateProposeSetBoolGas(rp, fmt.Sprintf("set %s", psettings.SubmitPricesEnabledSettingPath), networkNamespace, psettings.SubmitPricesEnabledSettingPath, value, opts)
+}
+
 // Rewards submissions currently enabled
 func ProposeSubmitRewardsEnabled(rp *rocketpool.RocketPool, value bool, opts *bind.TransactOpts) (uint64, common.Hash, error) {
 	return security.ProposeSetBool(rp, fmt.Sprintf("set %s", psettings.SubmitRewardsEnabledSettingPath), networkNamespace, psettings.SubmitRewardsEnabledSettingPath, value, opts)
@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// Get a setting value as a Go value in its unit:
+// bool and common.Address settings as themselves, counts and wei amounts as *big.Int, percentages and ratios as float64 fractions,
+// durations as time.Duration, timestamps as time.Time and block counts as uint64
+func (v SettingValue) GetTypedValue() interface{} {
+	switch v.Setting.Type {
+	case types.ProposalSettingType_Bool:
+		return v.Bool
+	case types.ProposalSettingType_Address:
+		return v.Address
+	}
+	if v.Uint == nil {
+		return nil
+	}
+	switch v.Setting.Unit {
+	case SettingUnit_Percentage, SettingUnit_Ratio:
+		return eth.WeiToEth(v.Uint)
+	case SettingUnit_Duration:
+		return time.Duration(v.Uint.Uint64()) * time.Second
+	case SettingUnit_Timestamp:
+		return time.Unix(v.Uint.Int64(), 0)
+	case SettingUnit_Blocks:
+		return v.Uint.Uint64()
+	default:
+		return big.NewInt(0).Set(v.Uint)
+	}
+}
+
+// Get a human-readable representation of a setting value in its unit
+func (v SettingValue) String() string {
+	switch v.Setting.Type {
+	case types.ProposalSettingType_Bool:
+		return fmt.Sprintf("%t", v.Bool)
+	case types.ProposalSettingType_Address:
+		return v.Address.Hex()
+	}
+	if v.Uint == nil {
+		return "<nil>"
+	}
+	switch v.Setting.Unit {
+	case SettingUnit_Wei:
+		return FormatFixedPoint(v.Uint)
+	case SettingUnit_Percentage:
+		return FormatFixedPoint(big.NewInt(0).Mul(v.Uint, big.NewInt(100))) + "%"
+	case SettingUnit_Ratio:
+		return FormatFixedPoint(v.Uint) + "x"
+	case SettingUnit_Duration:
+		return (time.Duration(v.Uint.Uint64()) * time.Second).String()
+	case SettingUnit_Timestamp:
+		return time.Unix(v.Uint.Int64(), 0).UTC().Format(time.RFC3339)
+	case SettingUnit_Blocks:
+		return fmt.Sprintf("%s blocks", v.Uint.String())
+	default:
+		return v.Uint.String()
+	}
+}
+
+// Format a value scaled by 1e18 (such as a wei amount) as an exact decimal
+func FormatFixedPoint(value *big.Int) string {
+	formatted := big.NewRat(0, 1).SetFrac(value, eth.EthToWei(1)).FloatString(18)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
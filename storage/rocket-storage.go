@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -58,8 +59,13 @@ func ConfirmWithdrawalAddress(rp *rocketpool.RocketPool, nodeAddress common.Addr
 
 // Get the number of the block that Rocket Pool was deployed on
 func GetDeployBlock(rp *rocketpool.RocketPool) (*big.Int, error) {
+	return GetDeployBlockContext(context.Background(), rp)
+}
+
+// Get the number of the block that Rocket Pool was deployed on with a context
+func GetDeployBlockContext(ctx context.Context, rp *rocketpool.RocketPool) (*big.Int, error) {
 	deployBlockHash := crypto.Keccak256Hash([]byte("deploy.block"))
-	deployBlock, err := rp.RocketStorage.GetUint(nil, deployBlockHash)
+	deployBlock, err := rp.RocketStorage.GetUint(&bind.CallOpts{Context: ctx}, deployBlockHash)
 	if err != nil {
 		return nil, fmt.Errorf("error getting Rocket Pool deployment block: %w", err)
 	}
@@ -0,0 +1,210 @@
+package payload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/dao/payload"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/settings"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+)
+
+func TestBuildSettingsPayload(t *testing.T) {
+	daoAbi := getAbi(t)
+
+	// A single change uses the setting type's method
+	payloadBytes, err := payload.BuildSettingsPayloadWithABI(daoAbi, []payload.SettingChangeRequest{
+		{ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeDepositEnabledSettingPath, Value: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := payload.DecodeProposalPayloadWithABI(daoAbi, payload.ProtocolDaoProposalsContractName, payloadBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Method != "proposalSettingBool" || len(decoded.Settings) != 1 || decoded.Settings[0].New.Bool {
+		t.Errorf("Incorrect single setting payload %+v", decoded)
+	}
+
+	// Multiple changes use the multi-setting method
+	payloadBytes, err = payload.BuildSettingsPayloadWithABI(daoAbi, []payload.SettingChangeRequest{
+		{ContractName: psettings.DepositSettingsContractName, Path: psettings.DepositFeeSettingPath, Value: big.NewInt(5e15)},
+		{ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolLaunchTimeoutSettingPath, Value: big.NewInt(24 * 60 * 60)},
+		{ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeRegistrationEnabledSettingPath, Value: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = payload.DecodeProposalPayloadWithABI(daoAbi, payload.ProtocolDaoProposalsContractName, payloadBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Method != "proposalSettingMulti" || len(decoded.Settings) != 3 {
+		t.Fatalf("Incorrect multi-setting payload %+v", decoded)
+	}
+	if decoded.Settings[0].New.String() != "0.5%" || decoded.Settings[1].New.GetTypedValue() != 24*time.Hour || !decoded.Settings[2].New.Bool {
+		t.Errorf("Incorrect multi-setting values %+v", decoded.Settings)
+	}
+
+	// Invalid changes are rejected before encoding
+	invalid := map[string][]payload.SettingChangeRequest{
+		"no changes":         {},
+		"unregistered":       {{ContractName: psettings.DepositSettingsContractName, Path: "deposit.unknown", Value: big.NewInt(1)}},
+		"oracle DAO setting": {{ContractName: tnsettings.MinipoolSettingsContractName, Path: tnsettings.ScrubPeriodPath, Value: big.NewInt(1)}},
+		"wrong type":         {{ContractName: psettings.DepositSettingsContractName, Path: psettings.DepositFeeSettingPath, Value: true}},
+		"above maximum":      {{ContractName: psettings.DepositSettingsContractName, Path: psettings.DepositFeeSettingPath, Value: big.NewInt(2e16)}},
+		"below minimum":      {{ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolLaunchTimeoutSettingPath, Value: big.NewInt(60 * 60)}},
+		"duplicate": {
+			{ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeDepositEnabledSettingPath, Value: true},
+			{ContractName: psettings.NodeSettingsContractName, Path: psettings.NodeDepositEnabledSettingPath, Value: false},
+		},
+	}
+	for name, changes := range invalid {
+		if _, err := payload.BuildSettingsPayloadWithABI(daoAbi, changes); err == nil {
+			t.Errorf("Built a payload with an invalid change: %s", name)
+		}
+	}
+
+}
+
+func TestGetSettingDryRuns(t *testing.T) {
+	daoAbi := getAbi(t)
+
+	decoded := decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSettingUint", psettings.DepositSettingsContractName, psettings.DepositFeeSettingPath, big.NewInt(5e15))
+	current := settings.SettingValue{Setting: decoded.Settings[0].New.Setting, Available: true, Uint: big.NewInt(5e14)}
+	decoded.Settings[0].Current = &current
+	executed := []settings.SettingValue{decoded.Settings[0].New}
+
+	dryRuns := payload.GetSettingDryRuns(decoded, executed)
+	if len(dryRuns) != 1 || !dryRuns[0].Changed || !dryRuns[0].Applied {
+		t.Fatalf("Incorrect dry runs %+v", dryRuns)
+	}
+	if dryRuns[0].Current.String() != "0.05%" || dryRuns[0].Requested.String() != "0.5%" {
+		t.Errorf("Incorrect deposit fee change from %s to %s", dryRuns[0].Current.String(), dryRuns[0].Requested.String())
+	}
+
+	// Setting the current value is not a change
+	current.Uint = big.NewInt(5e15)
+	if dryRuns = payload.GetSettingDryRuns(decoded, executed); dryRuns[0].Changed {
+		t.Error("Setting the current value was reported as a change")
+	}
+
+	// Execution that leaves the current value is not a change, even if another value was requested
+	current.Uint = big.NewInt(5e14)
+	executed[0] = current
+	if dryRuns = payload.GetSettingDryRuns(decoded, executed); dryRuns[0].Changed || dryRuns[0].Applied {
+		t.Errorf("Incorrect dry run for an ignored change %+v", dryRuns[0])
+	}
+
+}
+
+const minipoolSettingsAbi = `[{"type":"function","name":"getLaunchTimeout","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+
+var (
+	proposalsAddress        = common.HexToAddress("0x2000000000000000000000000000000000000001")
+	proposalAddress         = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	minipoolSettingsAddress = common.HexToAddress("0x2000000000000000000000000000000000000003")
+	launchTimeoutSlot       = crypto.Keccak256Hash([]byte("dao.protocol.setting.minipool"), []byte(psettings.MinipoolLaunchTimeoutSettingPath))
+)
+
+// A fake client that traces a fixed set of storage changes and applies them to calls with storage
+type overrideClient struct {
+	*fakeclient.Client
+	changes rocketpool.StorageChanges
+	traces  []ethereum.CallMsg
+	storage rocketpool.StorageChanges
+}
+
+func (c *overrideClient) TraceCallStorageChanges(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (rocketpool.StorageChanges, error) {
+	c.traces = append(c.traces, call)
+	return c.changes, nil
+}
+
+func (c *overrideClient) CallContractWithStorage(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int, storage rocketpool.StorageChanges) ([]byte, error) {
+	c.storage = storage
+	defer func() { c.storage = nil }()
+	return c.Client.CallContract(ctx, call, blockNumber)
+}
+
+// Create a fake client for a network with the Protocol DAO proposals contracts and a minipool launch timeout of 72 hours.
+// The launch timeout is read from the fake RocketStorage slot when calls replace it.
+func newOverrideClient(t *testing.T) *overrideClient {
+	client := &overrideClient{Client: fakeclient.New(t)}
+	client.LatestBlock = 100
+	client.SetContract(payload.ProtocolDaoProposalsContractName, proposalsAddress, proposalsAbi)
+	client.SetContract("rocketDAOProtocolProposal", proposalAddress, proposalsAbi)
+	client.SetContract(psettings.MinipoolSettingsContractName, minipoolSettingsAddress, minipoolSettingsAbi)
+	client.SetCallHandler(proposalsAddress, proposalsAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+		return []interface{}{}, nil
+	})
+	client.SetCallHandler(minipoolSettingsAddress, minipoolSettingsAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+		if value, exists := client.storage[fakeclient.StorageAddress][launchTimeoutSlot]; exists {
+			return []interface{}{value.Big()}, nil
+		}
+		return []interface{}{big.NewInt(72 * 60 * 60)}, nil
+	})
+	return client
+}
+
+func TestDryRunProposalPayload(t *testing.T) {
+
+	// The proposal requests 48 hours, but execution stores 36 hours
+	client := newOverrideClient(t)
+	client.changes = rocketpool.StorageChanges{
+		fakeclient.StorageAddress: {launchTimeoutSlot: common.BigToHash(big.NewInt(36 * 60 * 60))},
+	}
+	rp := fakeclient.NewRocketPool(t, client)
+	dryRun, err := payload.BuildSettingsProposal(rp, []payload.SettingChangeRequest{
+		{ContractName: psettings.MinipoolSettingsContractName, Path: psettings.MinipoolLaunchTimeoutSettingPath, Value: big.NewInt(48 * 60 * 60)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dryRun.Settings) != 1 {
+		t.Fatalf("Incorrect dry run %+v", dryRun)
+	}
+
+	// The post-execution value is read back from the getter, not copied from the request
+	setting := dryRun.Settings[0]
+	if setting.Current.GetTypedValue() != 72*time.Hour || setting.Requested.GetTypedValue() != 48*time.Hour || setting.Executed.GetTypedValue() != 36*time.Hour {
+		t.Errorf("Incorrect launch timeout from %v to %v, requested %v", setting.Current.GetTypedValue(), setting.Executed.GetTypedValue(), setting.Requested.GetTypedValue())
+	}
+	if !setting.Changed || setting.Applied {
+		t.Errorf("Incorrect change flags %+v", setting)
+	}
+
+	// The execution is traced as rocketDAOProtocolProposal calling the proposals contract
+	if len(client.traces) != 1 || client.traces[0].From != proposalAddress || *client.traces[0].To != proposalsAddress || !bytes.Equal(client.traces[0].Data, dryRun.Payload) {
+		t.Errorf("Incorrect execution traces %+v", client.traces)
+	}
+
+	// Execution that stores the requested value applies it
+	client.changes[fakeclient.StorageAddress][launchTimeoutSlot] = common.BigToHash(big.NewInt(48 * 60 * 60))
+	dryRun, err = payload.DryRunProposalPayload(rp, dryRun.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dryRun.Settings[0].Changed || !dryRun.Settings[0].Applied {
+		t.Errorf("Incorrect dry run for an applied change %+v", dryRun.Settings[0])
+	}
+
+	// Clients that can't trace and override storage can't dry-run
+	plainClient := newOverrideClient(t).Client
+	if _, err := payload.DryRunProposalPayload(fakeclient.NewRocketPool(t, plainClient), dryRun.Payload); !errors.Is(err, rocketpool.ErrStateOverridesNotSupported) {
+		t.Errorf("Expected an unsupported client error, got %v", err)
+	}
+
+}
@@ -0,0 +1,249 @@
+package payload
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+
+	"github.com/rocket-pool/rocketpool-go/dao/payload"
+	"github.com/rocket-pool/rocketpool-go/settings"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// The payload methods of the proposals contracts
+const proposalsAbi = `[
+	{"type":"function","name":"proposalSettingUint","inputs":[{"name":"_settingContractName","type":"string"},{"name":"_settingPath","type":"string"},{"name":"_value","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingBool","inputs":[{"name":"_settingContractName","type":"string"},{"name":"_settingPath","type":"string"},{"name":"_value","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingAddress","inputs":[{"name":"_settingContractName","type":"string"},{"name":"_settingPath","type":"string"},{"name":"_value","type":"address"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingMulti","inputs":[{"name":"_settingContractNames","type":"string[]"},{"name":"_settingPaths","type":"string[]"},{"name":"_types","type":"uint8[]"},{"name":"_data","type":"bytes[]"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingRewardsClaimers","inputs":[{"name":"_trustedNodePercent","type":"uint256"},{"name":"_protocolPercent","type":"uint256"},{"name":"_nodePercent","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalTreasuryOneTimeSpend","inputs":[{"name":"_invoiceID","type":"string"},{"name":"_recipientAddress","type":"address"},{"name":"_amount","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalTreasuryNewContract","inputs":[{"name":"_contractName","type":"string"},{"name":"_recipientAddress","type":"address"},{"name":"_amountPerPeriod","type":"uint256"},{"name":"_periodLength","type":"uint256"},{"name":"_startTime","type":"uint256"},{"name":"_numPeriods","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalTreasuryUpdateContract","inputs":[{"name":"_contractName","type":"string"},{"name":"_recipientAddress","type":"address"},{"name":"_amountPerPeriod","type":"uint256"},{"name":"_periodLength","type":"uint256"},{"name":"_numPeriods","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalSecurityInvite","inputs":[{"name":"_id","type":"string"},{"name":"_memberAddress","type":"address"}],"outputs":[]},
+	{"type":"function","name":"proposalSecurityKickMulti","inputs":[{"name":"_memberAddresses","type":"address[]"}],"outputs":[]},
+	{"type":"function","name":"proposalSecurityReplace","inputs":[{"name":"_existingMemberAddress","type":"address"},{"name":"_newMemberId","type":"string"},{"name":"_newMemberAddress","type":"address"}],"outputs":[]},
+	{"type":"function","name":"proposalKick","inputs":[{"name":"_nodeAddress","type":"address"},{"name":"_rplFine","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalUpgrade","inputs":[{"name":"_type","type":"string"},{"name":"_name","type":"string"},{"name":"_contractAbi","type":"string"},{"name":"_contractAddress","type":"address"}],"outputs":[]},
+	{"type":"function","name":"getProposalCount","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+// The rocketDAOSecurityProposals ABI, whose member methods share names with the Oracle DAO's
+const securityProposalsAbi = `[
+	{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"propose","stateMutability":"nonpayable","inputs":[{"name":"_proposalMessage","type":"string"},{"name":"_payload","type":"bytes"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"vote","stateMutability":"nonpayable","inputs":[{"name":"_proposalID","type":"uint256"},{"name":"_support","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"execute","stateMutability":"nonpayable","inputs":[{"name":"_proposalID","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingUint","stateMutability":"nonpayable","inputs":[{"name":"_settingNameSpace","type":"string"},{"name":"_settingPath","type":"string"},{"name":"_value","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"proposalSettingBool","stateMutability":"nonpayable","inputs":[{"name":"_settingNameSpace","type":"string"},{"name":"_settingPath","type":"string"},{"name":"_value","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"proposalInvite","stateMutability":"nonpayable","inputs":[{"name":"_id","type":"string"},{"name":"_memberAddress","type":"address"}],"outputs":[]},
+	{"type":"function","name":"proposalKick","stateMutability":"nonpayable","inputs":[{"name":"_memberAddress","type":"address"}],"outputs":[]},
+	{"type":"function","name":"proposalKickMulti","stateMutability":"nonpayable","inputs":[{"name":"_memberAddresses","type":"address[]"}],"outputs":[]},
+	{"type":"function","name":"proposalReplace","stateMutability":"nonpayable","inputs":[{"name":"_existingMemberAddress","type":"address"},{"name":"_newMemberId","type":"string"},{"name":"_newMemberAddress","type":"address"}],"outputs":[]}
+]`
+
+var (
+	address1 = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	address2 = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+func TestDecodeSettingPayloads(t *testing.T) {
+	daoAbi := getAbi(t)
+
+	// Single uint setting
+	decoded := decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSettingUint", psettings.DepositSettingsContractName, psettings.DepositFeeSettingPath, big.NewInt(5e14))
+	if decoded.Kind != payload.ProposalPayloadKind_Setting || len(decoded.Settings) != 1 {
+		t.Fatalf("Incorrect setting payload %+v", decoded)
+	}
+	setting := decoded.Settings[0]
+	if !setting.Known || setting.New.Setting.Unit != settings.SettingUnit_Percentage || setting.Current != nil {
+		t.Errorf("Incorrect deposit fee setting %+v", setting)
+	}
+	if setting.New.String() != "0.05%" {
+		t.Errorf("Incorrect deposit fee string %s", setting.New.String())
+	}
+
+	// Security council settings are identified by their namespace
+	decoded = decode(t, daoAbi, payload.SecurityDaoProposalsContractName, "proposalSettingBool", "deposit", psettings.DepositEnabledSettingPath, false)
+	setting = decoded.Settings[0]
+	if !setting.Known || setting.New.Setting.ContractName != psettings.DepositSettingsContractName || setting.New.Bool {
+		t.Errorf("Incorrect security council setting %+v", setting)
+	}
+
+	// Unregistered settings are still decoded
+	decoded = decode(t, daoAbi, payload.OracleDaoProposalsContractName, "proposalSettingAddress", "rocketUnknownSettings", "unknown.path", address1)
+	setting = decoded.Settings[0]
+	if setting.Known || setting.New.Setting.Dao != settings.SettingDao_OracleDao || setting.New.Address != address1 {
+		t.Errorf("Incorrect unknown setting %+v", setting)
+	}
+
+	// Multi-setting payloads
+	data := [][]byte{
+		math.U256Bytes(big.NewInt(int64(24 * 60 * 60))),
+		math.PaddedBigBytes(common.Big1, 32),
+		common.LeftPadBytes(address2.Bytes(), 32),
+	}
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSettingMulti",
+		[]string{tnsettings.MinipoolSettingsContractName, psettings.NodeSettingsContractName, "rocketUnknownSettings"},
+		[]string{tnsettings.ScrubPeriodPath, psettings.NodeRegistrationEnabledSettingPath, "unknown.address"},
+		[]uint8{uint8(types.ProposalSettingType_Uint256), uint8(types.ProposalSettingType_Bool), uint8(types.ProposalSettingType_Address)},
+		data,
+	)
+	if len(decoded.Settings) != 3 {
+		t.Fatalf("Incorrect multi-setting count %d", len(decoded.Settings))
+	}
+	if decoded.Settings[0].New.GetTypedValue() != 24*time.Hour || decoded.Settings[0].New.String() != "24h0m0s" {
+		t.Errorf("Incorrect scrub period %s", decoded.Settings[0].New.String())
+	}
+	if !decoded.Settings[1].Known || !decoded.Settings[1].New.Bool {
+		t.Errorf("Incorrect registration flag %+v", decoded.Settings[1])
+	}
+	if decoded.Settings[2].Known || decoded.Settings[2].New.Address != address2 {
+		t.Errorf("Incorrect address setting %+v", decoded.Settings[2])
+	}
+
+	// Malformed multi-setting values are rejected
+	payloadBytes, err := daoAbi.Pack("proposalSettingMulti", []string{"a"}, []string{"b"}, []uint8{uint8(types.ProposalSettingType_Bool)}, [][]byte{math.U256Bytes(big.NewInt(2))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := payload.DecodeProposalPayloadWithABI(daoAbi, payload.ProtocolDaoProposalsContractName, payloadBytes); err == nil {
+		t.Error("Decoded a bool setting with an invalid value")
+	}
+
+}
+
+func TestDecodeTreasuryPayloads(t *testing.T) {
+	daoAbi := getAbi(t)
+
+	// One-time spend
+	decoded := decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalTreasuryOneTimeSpend", "invoice-1", address1, eth.EthToWei(500))
+	spend := decoded.TreasurySpend
+	if decoded.Kind != payload.ProposalPayloadKind_TreasuryOneTimeSpend || spend == nil {
+		t.Fatalf("Incorrect one-time spend payload %+v", decoded)
+	}
+	if spend.InvoiceID != "invoice-1" || spend.Recipient != address1 || spend.GetTotalAmount().Cmp(eth.EthToWei(500)) != 0 {
+		t.Errorf("Incorrect one-time spend %+v", spend)
+	}
+
+	// Recurring spends
+	startTime := time.Unix(1700000000, 0)
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalTreasuryNewContract", "grants", address2, eth.EthToWei(100), big.NewInt(28*24*60*60), big.NewInt(startTime.Unix()), big.NewInt(12))
+	spend = decoded.TreasurySpend
+	if decoded.Kind != payload.ProposalPayloadKind_TreasuryNewContract || spend.ContractName != "grants" || spend.PeriodLength != 28*24*time.Hour || !spend.StartTime.Equal(startTime) || spend.NumberOfPeriods != 12 {
+		t.Errorf("Incorrect new recurring spend %+v", spend)
+	}
+	if spend.GetTotalAmount().Cmp(eth.EthToWei(1200)) != 0 {
+		t.Errorf("Incorrect recurring spend total %s", spend.GetTotalAmount().String())
+	}
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalTreasuryUpdateContract", "grants", address2, eth.EthToWei(50), big.NewInt(7*24*60*60), big.NewInt(4))
+	if decoded.Kind != payload.ProposalPayloadKind_TreasuryUpdateContract || decoded.TreasurySpend.PeriodLength != 7*24*time.Hour || decoded.TreasurySpend.NumberOfPeriods != 4 {
+		t.Errorf("Incorrect recurring spend update %+v", decoded.TreasurySpend)
+	}
+
+	// Rewards split
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSettingRewardsClaimers", eth.EthToWei(0.02), eth.EthToWei(0.28), eth.EthToWei(0.7))
+	if decoded.Kind != payload.ProposalPayloadKind_RewardsClaimers || decoded.RewardsClaimers.NodeOperatorPercentage.Cmp(eth.EthToWei(0.7)) != 0 {
+		t.Errorf("Incorrect rewards split %+v", decoded.RewardsClaimers)
+	}
+
+}
+
+func TestDecodeMemberPayloads(t *testing.T) {
+	daoAbi := getAbi(t)
+
+	decoded := decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSecurityInvite", "member-1", address1)
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityInvite || len(decoded.Members) != 1 || decoded.Members[0].ID != "member-1" || decoded.Members[0].Address != address1 {
+		t.Errorf("Incorrect security invite %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSecurityKickMulti", []common.Address{address1, address2})
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityKick || len(decoded.Members) != 2 || decoded.Members[1].Address != address2 {
+		t.Errorf("Incorrect security kick %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.ProtocolDaoProposalsContractName, "proposalSecurityReplace", address1, "member-2", address2)
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityReplace || decoded.Members[0].Address != address1 || decoded.Replacement == nil || decoded.Replacement.ID != "member-2" {
+		t.Errorf("Incorrect security replace %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.OracleDaoProposalsContractName, "proposalKick", address1, eth.EthToWei(1000))
+	if decoded.Kind != payload.ProposalPayloadKind_OracleDaoKick || decoded.Members[0].Address != address1 || decoded.RplFine.Cmp(eth.EthToWei(1000)) != 0 {
+		t.Errorf("Incorrect oDAO kick %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.OracleDaoProposalsContractName, "proposalUpgrade", "upgradeContract", "rocketNodeManager", "abi", address2)
+	if decoded.Kind != payload.ProposalPayloadKind_Upgrade || decoded.Upgrade.ContractName != "rocketNodeManager" || decoded.Upgrade.ContractAddress != address2 {
+		t.Errorf("Incorrect upgrade %+v", decoded.Upgrade)
+	}
+
+	// Methods that aren't proposal actions are rejected
+	payloadBytes, err := daoAbi.Pack("getProposalCount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := payload.DecodeProposalPayloadWithABI(daoAbi, payload.OracleDaoProposalsContractName, payloadBytes); err == nil {
+		t.Error("Decoded a payload for an unknown method")
+	}
+
+}
+
+func TestDecodeSecurityMemberPayloads(t *testing.T) {
+	daoAbi := parseAbi(t, securityProposalsAbi)
+
+	decoded := decode(t, daoAbi, payload.SecurityDaoProposalsContractName, "proposalInvite", "member-1", address1)
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityInvite || len(decoded.Members) != 1 || decoded.Members[0].ID != "member-1" || decoded.Members[0].Address != address1 || decoded.Members[0].Url != "" {
+		t.Errorf("Incorrect security invite %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.SecurityDaoProposalsContractName, "proposalReplace", address1, "member-2", address2)
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityReplace || decoded.Members[0].Address != address1 || decoded.Replacement == nil || decoded.Replacement.ID != "member-2" || decoded.Replacement.Address != address2 {
+		t.Errorf("Incorrect security replace %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.SecurityDaoProposalsContractName, "proposalKick", address1)
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityKick || len(decoded.Members) != 1 || decoded.Members[0].Address != address1 || decoded.RplFine != nil {
+		t.Errorf("Incorrect security kick %+v", decoded)
+	}
+
+	decoded = decode(t, daoAbi, payload.SecurityDaoProposalsContractName, "proposalKickMulti", []common.Address{address1, address2})
+	if decoded.Kind != payload.ProposalPayloadKind_SecurityKick || len(decoded.Members) != 2 || decoded.Members[1].Address != address2 {
+		t.Errorf("Incorrect security multi-kick %+v", decoded)
+	}
+
+}
+
+func getAbi(t *testing.T) *abi.ABI {
+	return parseAbi(t, proposalsAbi)
+}
+
+func parseAbi(t *testing.T, abiString string) *abi.ABI {
+	daoAbi, err := abi.JSON(strings.NewReader(abiString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &daoAbi
+}
+
+func decode(t *testing.T, daoAbi *abi.ABI, daoName string, method string, args ...interface{}) payload.DecodedProposalPayload {
+	payloadBytes, err := daoAbi.Pack(method, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := payload.DecodeProposalPayloadWithABI(daoAbi, daoName, payloadBytes)
+	if err != nil {
+		t.Fatalf("Could not decode %s payload: %s", method, err.Error())
+	}
+	if decoded.Method != method || decoded.DaoName != daoName {
+		t.Errorf("Incorrect %s payload method %s", method, decoded.Method)
+	}
+	return decoded
+}
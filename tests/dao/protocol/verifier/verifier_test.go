@@ -0,0 +1,210 @@
+package verifier
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol/verifier"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol/votingtree"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+const (
+	snapshotBlock uint32 = 1000
+	depthPerRound uint64 = 2
+)
+
+var (
+	honestProposer    = common.HexToAddress("0x2000000000000000000000000000000000000001")
+	dishonestProposer = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	challenger        = common.HexToAddress("0x2000000000000000000000000000000000000003")
+)
+
+// Get a voting tree provider with a single 6-node snapshot
+func getTreeProvider() *verifier.StaticVotingTreeProvider {
+	votingInfo := make([]types.NodeVotingInfo, 6)
+	for i := range votingInfo {
+		address := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		votingInfo[i] = types.NodeVotingInfo{
+			NodeAddress: address,
+			VotingPower: eth.EthToWei(float64(10 * (i + 1))),
+			Delegate:    address,
+		}
+	}
+	votingInfo[5].Delegate = votingInfo[2].NodeAddress
+	return &verifier.StaticVotingTreeProvider{
+		Snapshots:     map[uint32][]types.NodeVotingInfo{snapshotBlock: votingInfo},
+		DepthPerRound: depthPerRound,
+	}
+}
+
+// Record the fixtures for an honest proposal (1) and a dishonest proposal (2) that inflated one node's power
+func getRecordedSource(t *testing.T, tree *votingtree.ProposalVotingTree) *verifier.RecordedDataSource {
+	start := time.Unix(1700000000, 0)
+
+	// Honest proposal
+	root, pollard, err := tree.GetProposalPollard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	honest := protocol.RootSubmitted{
+		ProposalID:  big.NewInt(1),
+		Proposer:    honestProposer,
+		BlockNumber: snapshotBlock,
+		Index:       big.NewInt(1),
+		Root:        root,
+		TreeNodes:   pollard,
+		Timestamp:   start,
+	}
+
+	// Dishonest proposal with an inflated node at index 6
+	badPollard, _ := tree.GetPollardForIndex(1)
+	badPollard[2].Sum = big.NewInt(0).Add(badPollard[2].Sum, eth.EthToWei(1000))
+	badRoot, err := votingtree.ComputePollardRoot(badPollard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dishonest := protocol.RootSubmitted{
+		ProposalID:  big.NewInt(2),
+		Proposer:    dishonestProposer,
+		BlockNumber: snapshotBlock,
+		Index:       big.NewInt(1),
+		Root:        badRoot,
+		TreeNodes:   badPollard,
+		Timestamp:   start.Add(time.Minute),
+	}
+
+	// The honest proposal was challenged at index 5
+	challengedNode, _ := tree.GetNode(5)
+	source := &verifier.RecordedDataSource{
+		RootSubmittedEvents: []protocol.RootSubmitted{dishonest, honest},
+		ChallengeSubmittedEvents: []protocol.ChallengeSubmitted{
+			{
+				ProposalID: big.NewInt(1),
+				Challenger: challenger,
+				Index:      big.NewInt(5),
+				Timestamp:  start.Add(time.Hour),
+			},
+		},
+		ChallengeStates: []verifier.RecordedChallengeState{
+			{ProposalID: 1, Index: 5, State: types.ChallengeState_Challenged},
+		},
+		Nodes: []verifier.RecordedNode{
+			{ProposalID: 1, Index: 5, Node: challengedNode},
+		},
+	}
+
+	// Round-trip the fixtures through a file like a recorded capture
+	bytes, err := json.Marshal(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := os.WriteFile(path, bytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := verifier.LoadRecordedDataSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestVerifySubmissions(t *testing.T) {
+
+	provider := getTreeProvider()
+	tree, err := provider.GetVotingTree(snapshotBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := getRecordedSource(t, tree)
+	v := verifier.NewVerifier(provider, source)
+
+	results, err := v.VerifySubmissions([]uint64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Incorrect result count %d", len(results))
+	}
+
+	// Results are ordered by proposal
+	if !results[0].IsValid || results[0].Challenge != nil {
+		t.Error("Honest proposal was flagged")
+	}
+	bad := results[1]
+	if bad.IsValid {
+		t.Fatal("Dishonest proposal was not flagged")
+	}
+	if bad.MismatchIndex != 6 {
+		t.Errorf("Incorrect mismatch index %d", bad.MismatchIndex)
+	}
+	expected, _ := tree.GetNode(6)
+	if !votingtree.NodesEqual(bad.ExpectedNode, expected) {
+		t.Error("Incorrect expected node")
+	}
+
+	// The challenge witness must prove the proposer's node against the proposer's root
+	if bad.Challenge == nil {
+		t.Fatal("Missing challenge for dishonest proposal")
+	}
+	if bad.Challenge.ProposalID != 2 || bad.Challenge.Index != 6 {
+		t.Errorf("Incorrect challenge target %d / %d", bad.Challenge.ProposalID, bad.Challenge.Index)
+	}
+	if !votingtree.VerifyWitness(bad.Challenge.Node, 6, bad.Challenge.Witness, bad.Submission.Root, 1) {
+		t.Error("Challenge witness does not verify against the submitted root")
+	}
+
+}
+
+func TestVerifyChallenges(t *testing.T) {
+
+	provider := getTreeProvider()
+	tree, err := provider.GetVotingTree(snapshotBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := getRecordedSource(t, tree)
+	v := verifier.NewVerifier(provider, source)
+
+	// Challenges against another proposer are ignored
+	results, err := v.VerifyChallenges([]uint64{1, 2}, &dishonestProposer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no challenges for the dishonest proposer, got %d", len(results))
+	}
+
+	// The honest proposer must respond with the pollard below index 5
+	results, err = v.VerifyChallenges([]uint64{1, 2}, &honestProposer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Incorrect challenge count %d", len(results))
+	}
+	result := results[0]
+	if !result.IsRespondable || result.Response == nil || result.NodeMismatched {
+		t.Fatal("Open challenge has no response")
+	}
+	if result.Response.Index != 5 || len(result.Response.TreeNodes) != 2 {
+		t.Errorf("Incorrect response for index %d with %d nodes", result.Response.Index, len(result.Response.TreeNodes))
+	}
+	challengedNode, _ := tree.GetNode(5)
+	pollardRoot, err := votingtree.ComputePollardRoot(result.Response.TreeNodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !votingtree.NodesEqual(challengedNode, pollardRoot) {
+		t.Error("Response pollard does not hash to the challenged node")
+	}
+
+}
@@ -0,0 +1,289 @@
+package votingtree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol/votingtree"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// Build a snapshot of 5 nodes where node 3 delegates to node 0 and node 4 delegates to node 1
+func getTestSnapshot() []types.NodeVotingInfo {
+	addresses := []common.Address{
+		common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		common.HexToAddress("0x1000000000000000000000000000000000000002"),
+		common.HexToAddress("0x1000000000000000000000000000000000000003"),
+		common.HexToAddress("0x1000000000000000000000000000000000000004"),
+		common.HexToAddress("0x1000000000000000000000000000000000000005"),
+	}
+	return []types.NodeVotingInfo{
+		{NodeAddress: addresses[0], VotingPower: eth.EthToWei(10), Delegate: addresses[0]},
+		{NodeAddress: addresses[1], VotingPower: eth.EthToWei(20), Delegate: addresses[1]},
+		{NodeAddress: addresses[2], VotingPower: eth.EthToWei(30), Delegate: common.Address{}},
+		{NodeAddress: addresses[3], VotingPower: eth.EthToWei(40), Delegate: addresses[0]},
+		{NodeAddress: addresses[4], VotingPower: eth.EthToWei(50), Delegate: addresses[1]},
+	}
+}
+
+func TestHashing(t *testing.T) {
+
+	// keccak256(abi.encodePacked(uint256(0)))
+	zeroLeaf := votingtree.GetLeafNode(big.NewInt(0))
+	if zeroLeaf.Hash != common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563") {
+		t.Errorf("Incorrect zero leaf hash %s", zeroLeaf.Hash.Hex())
+	}
+
+	// keccak256(abi.encodePacked(uint256(1)))
+	oneLeaf := votingtree.GetLeafNode(big.NewInt(1))
+	if oneLeaf.Hash != common.HexToHash("0xb10e2d527612073b26eecdfd717e6a320cf44b4afac2b0732d9fcbe2b7fa0cf6") {
+		t.Errorf("Incorrect one leaf hash %s", oneLeaf.Hash.Hex())
+	}
+
+	// Parents sum their children
+	parent := votingtree.GetParentNode(zeroLeaf, oneLeaf)
+	if parent.Sum.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Incorrect parent sum %s", parent.Sum.String())
+	}
+	if parent.Hash == votingtree.GetParentNode(oneLeaf, zeroLeaf).Hash {
+		t.Error("Parent hash does not depend on child order")
+	}
+
+}
+
+func TestIndices(t *testing.T) {
+
+	depths := map[uint64]uint64{1: 0, 2: 1, 3: 1, 4: 2, 7: 2, 8: 3, 1023: 9, 1024: 10}
+	for index, depth := range depths {
+		if value := votingtree.GetDepthFromIndex(index); value != depth {
+			t.Errorf("Incorrect depth %d for index %d, expected %d", value, index, depth)
+		}
+	}
+
+	leafDepths := map[uint64]uint64{1: 0, 2: 1, 3: 2, 4: 2, 5: 3, 8: 3, 9: 4, 2000: 11}
+	for count, depth := range leafDepths {
+		if value := votingtree.GetDepthForLeafCount(count); value != depth {
+			t.Errorf("Incorrect depth %d for %d leaves, expected %d", value, count, depth)
+		}
+	}
+
+}
+
+func TestNetworkTree(t *testing.T) {
+
+	tree, err := votingtree.NewProposalVotingTree(getTestSnapshot(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 nodes are padded to 8 leaves
+	if tree.GetNetworkDepth() != 3 || tree.GetMaxDepth() != 6 {
+		t.Fatalf("Incorrect depths %d / %d", tree.GetNetworkDepth(), tree.GetMaxDepth())
+	}
+	if len(tree.NetworkTree.Nodes) != 15 {
+		t.Fatalf("Incorrect node count %d", len(tree.NetworkTree.Nodes))
+	}
+
+	// Check the delegated voting power
+	expectedPower := []float64{50, 70, 30, 0, 0}
+	for i, power := range tree.GetDelegatedVotingPower() {
+		if power.Cmp(eth.EthToWei(expectedPower[i])) != 0 {
+			t.Errorf("Incorrect delegated power %s for node %d", power.String(), i)
+		}
+	}
+
+	// Check the padding leaves
+	for i := uint64(5); i < 8; i++ {
+		leaf, err := tree.GetNode(tree.GetNetworkLeafIndex(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaf.Sum.Sign() != 0 || leaf.Hash != votingtree.GetLeafHash(big.NewInt(0)) {
+			t.Errorf("Padding leaf %d is not empty", i)
+		}
+	}
+
+	// Check the root
+	root := tree.GetRoot()
+	if root.Sum.Cmp(eth.EthToWei(150)) != 0 {
+		t.Errorf("Incorrect root sum %s", root.Sum.String())
+	}
+	if root.Hash != common.HexToHash("0x0b655c0db9d69133d6040f2179eff21b1808ba22a5d6306084d67c2743b22f28") {
+		t.Errorf("Incorrect root hash %s", root.Hash.Hex())
+	}
+
+	// Building the tree again must be deterministic
+	tree2, err := votingtree.NewProposalVotingTree(getTestSnapshot(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !votingtree.NodesEqual(tree.GetRoot(), tree2.GetRoot()) {
+		t.Error("Tree creation is not deterministic")
+	}
+
+}
+
+func TestNodeTrees(t *testing.T) {
+
+	tree, err := votingtree.NewProposalVotingTree(getTestSnapshot(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each node tree's root must match the network leaf it expands
+	for i := uint64(0); i < 8; i++ {
+		nodeTree, err := tree.GetNodeTree(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := tree.GetNode(tree.GetNetworkLeafIndex(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nodeTree.GetRoot().Sum.Cmp(leaf.Sum) != 0 {
+			t.Errorf("Node tree %d has sum %s but the network leaf has %s", i, nodeTree.GetRoot().Sum.String(), leaf.Sum.String())
+		}
+	}
+
+	// Node 1's tree holds the power of nodes 1 and 4; its leaves start at index 9 * 8 = 72
+	expected := []float64{0, 20, 0, 0, 50, 0, 0, 0}
+	for i, power := range expected {
+		leaf, err := tree.GetNode(72 + uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaf.Sum.Cmp(eth.EthToWei(power)) != 0 {
+			t.Errorf("Incorrect phase 2 leaf %d sum %s", i, leaf.Sum.String())
+		}
+	}
+
+	// Indices beyond the max depth are invalid
+	if _, err := tree.GetNode(128); err == nil {
+		t.Error("Expected an error for an out-of-range index")
+	}
+
+}
+
+func TestPollards(t *testing.T) {
+
+	tree, err := votingtree.NewProposalVotingTree(getTestSnapshot(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The proposal pollard is the 4 nodes at depth 2
+	root, pollard, err := tree.GetProposalPollard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pollard) != 4 {
+		t.Fatalf("Incorrect proposal pollard size %d", len(pollard))
+	}
+	pollardRoot, err := votingtree.ComputePollardRoot(pollard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !votingtree.NodesEqual(root, pollardRoot) {
+		t.Error("Proposal pollard does not hash to the root")
+	}
+
+	// A challenge at depth 2 is answered with a shortened pollard that ends at the network leaves
+	pollard, err = tree.GetPollardForIndex(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pollard) != 2 {
+		t.Fatalf("Incorrect pollard size %d for index 5", len(pollard))
+	}
+	challenged, _ := tree.GetNode(5)
+	pollardRoot, _ = votingtree.ComputePollardRoot(pollard)
+	if !votingtree.NodesEqual(challenged, pollardRoot) {
+		t.Error("Pollard for index 5 does not hash to the challenged node")
+	}
+
+	// A challenge on a network leaf is answered with the top of its node tree, which only matches the leaf's sum
+	pollard, err = tree.GetPollardForIndex(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pollard) != 4 {
+		t.Fatalf("Incorrect pollard size %d for index 9", len(pollard))
+	}
+	challenged, _ = tree.GetNode(9)
+	nodeTree, _ := tree.GetNodeTree(1)
+	pollardRoot, _ = votingtree.ComputePollardRoot(pollard)
+	if !votingtree.NodesEqual(nodeTree.GetRoot(), pollardRoot) || challenged.Sum.Cmp(pollardRoot.Sum) != 0 {
+		t.Error("Pollard for index 9 does not hash to the node tree root")
+	}
+
+	// Check the round boundaries
+	challengeable := map[uint64]bool{2: false, 4: true, 9: true, 18: false, 36: true, 72: true, 73: true, 37: true}
+	for index, expected := range challengeable {
+		if value := tree.IsChallengeableIndex(index); value != expected {
+			t.Errorf("Index %d challengeable = %t, expected %t", index, value, expected)
+		}
+	}
+
+}
+
+func TestWitnesses(t *testing.T) {
+
+	tree, err := votingtree.NewProposalVotingTree(getTestSnapshot(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check witnesses for every challengeable index
+	for index := uint64(2); index < 128; index++ {
+		if !tree.IsChallengeableIndex(index) {
+			continue
+		}
+		rootIndex, root, err := tree.GetPollardRoot(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witness, err := tree.GetWitness(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, _ := tree.GetNode(index)
+		if !votingtree.VerifyWitness(node, index, witness, root, rootIndex) {
+			t.Errorf("Witness for index %d does not verify against index %d", index, rootIndex)
+		}
+	}
+
+	// Witnesses built from a submitted pollard must match the ones from the full tree
+	_, pollard, err := tree.GetProposalPollard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index := uint64(4); index < 8; index++ {
+		fromPollard, err := votingtree.GetWitnessFromPollard(pollard, 1, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromTree, err := tree.GetWitness(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fromPollard) != len(fromTree) {
+			t.Fatalf("Witness lengths differ for index %d", index)
+		}
+		for i := range fromPollard {
+			if !votingtree.NodesEqual(fromPollard[i], fromTree[i]) {
+				t.Errorf("Witness node %d differs for index %d", i, index)
+			}
+		}
+	}
+
+	// A tampered node must not verify
+	witness, _ := tree.GetWitness(6)
+	node, _ := tree.GetNode(6)
+	node.Sum = big.NewInt(0).Add(node.Sum, big.NewInt(1))
+	if votingtree.VerifyWitness(node, 6, witness, tree.GetRoot(), 1) {
+		t.Error("Tampered node passed verification")
+	}
+
+}
@@ -0,0 +1,105 @@
+package rewardstree
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/rewards/rewardstree"
+)
+
+// Get a parsed rewards file with generated proofs
+func getVerifiedFile(t *testing.T) *rewardstree.RewardsFile {
+	file, err := rewardstree.ParseRewardsFile([]byte(v1File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := file.GenerateProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.MerkleRoot = tree.GetRoot()
+	return file
+}
+
+func TestTreeStores(t *testing.T) {
+
+	file := getVerifiedFile(t)
+	file.RewardsFileVersion = 3
+
+	// Directory store
+	store := rewardstree.NewDirectoryStore(t.TempDir(), "mainnet")
+	if _, err := store.GetRewardsFile(4); !errors.Is(err, rewardstree.ErrRewardsFileNotFound) {
+		t.Errorf("Incorrect error for a missing file: %v", err)
+	}
+	if err := store.SaveRewardsFile(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := store.GetRewardsFile(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.MerkleRoot != file.MerkleRoot || loaded.Verify() != nil {
+		t.Error("Loaded file does not match the saved file")
+	}
+
+	// Memory store
+	memoryStore := rewardstree.NewMemoryStore(file)
+	if _, err := memoryStore.GetRewardsFile(3); !errors.Is(err, rewardstree.ErrRewardsFileNotFound) {
+		t.Errorf("Incorrect error for a missing file: %v", err)
+	}
+	if loaded, err := memoryStore.GetRewardsFile(4); err != nil || loaded != file {
+		t.Error("Incorrect file from the memory store")
+	}
+
+}
+
+func TestIntervalRewards(t *testing.T) {
+
+	file := getVerifiedFile(t)
+
+	// Rewards are checked against the on-chain root
+	if _, _, err := rewardstree.GetIntervalRewards(file, node1, common.HexToHash("0x01")); err == nil {
+		t.Error("Accepted a file with a different root")
+	}
+	rewards, exists, err := rewardstree.GetIntervalRewards(file, node1, file.MerkleRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists || rewards.Index != 4 || rewards.AmountRPL.Cmp(big.NewInt(230)) != 0 || rewards.AmountETH.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("Incorrect interval rewards %+v", rewards)
+	}
+
+	// Nodes without rewards have nothing to claim
+	if _, exists, err := rewardstree.GetIntervalRewards(file, node4, file.MerkleRoot); err != nil || exists {
+		t.Error("Got rewards for a node without rewards")
+	}
+
+	// Missing proofs are generated
+	file.NodeRewards[0].MerkleProof = []common.Hash{}
+	rewards, exists, err = rewardstree.GetIntervalRewards(file, node1, file.MerkleRoot)
+	if err != nil || !exists || !rewardstree.VerifyProof(file.MerkleRoot, file.NodeRewards[0].GetLeafHash(), rewards.MerkleProof) {
+		t.Errorf("Proof was not generated: %v", err)
+	}
+
+	// Plan a claim without restaking
+	unclaimed := &rewardstree.UnclaimedRewards{
+		NodeAddress: node1,
+		Intervals:   []rewardstree.IntervalRewards{rewards},
+		TotalRPL:    rewards.AmountRPL,
+		TotalETH:    rewards.AmountETH,
+	}
+	plan, err := unclaimed.PlanClaim(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Indices) != 1 || plan.Indices[0].Uint64() != 4 || plan.StakeAmount.Sign() != 0 || len(plan.MerkleProofs[0]) != 2 {
+		t.Errorf("Incorrect claim plan %+v", plan)
+	}
+	if _, err := unclaimed.PlanClaim(nil, big.NewInt(2e18), nil); err == nil {
+		t.Error("Planned a claim with a restake fraction above 1")
+	}
+
+}
@@ -0,0 +1,187 @@
+package rewardstree
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/rewards/rewardstree"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+var (
+	intervalStart = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	intervalEnd   = intervalStart.Add(28 * 24 * time.Hour)
+	intervalMid   = intervalStart.Add(14 * 24 * time.Hour)
+
+	nodeA   = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	nodeB   = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	nodeC   = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	memberD = common.HexToAddress("0x5000000000000000000000000000000000000005")
+
+	minipool1 = common.HexToAddress("0xa000000000000000000000000000000000000001")
+	minipool2 = common.HexToAddress("0xa000000000000000000000000000000000000002")
+	minipool3 = common.HexToAddress("0xa000000000000000000000000000000000000003")
+	minipool4 = common.HexToAddress("0xa000000000000000000000000000000000000004")
+)
+
+// Get a network snapshot at the end of the interval
+func getNetworkState() *state.NetworkState {
+	return &state.NetworkState{
+		ElBlockNumber: 16200000,
+		NetworkDetails: &state.NetworkDetails{
+			PendingRPLRewards:                 big.NewInt(10000),
+			NodeOperatorRewardsPercent:        eth.EthToWei(0.7),
+			TrustedNodeOperatorRewardsPercent: eth.EthToWei(0.05),
+			ProtocolDaoRewardsPercent:         eth.EthToWei(0.25),
+			SmoothingPoolBalance:              big.NewInt(1000),
+		},
+		NodeDetails: []state.NativeNodeDetails{
+			{NodeAddress: nodeC, Exists: true, RegistrationTime: big.NewInt(0), RewardNetwork: big.NewInt(0), EffectiveRPLStake: big.NewInt(0)},
+			{NodeAddress: nodeB, Exists: true, RegistrationTime: big.NewInt(intervalMid.Unix()), RewardNetwork: big.NewInt(1), EffectiveRPLStake: big.NewInt(300)},
+			{NodeAddress: nodeA, Exists: true, RegistrationTime: big.NewInt(0), RewardNetwork: big.NewInt(0), EffectiveRPLStake: big.NewInt(100), SmoothingPoolRegistrationState: true},
+		},
+		MinipoolDetails: []state.NativeMinipoolDetails{
+			{MinipoolAddress: minipool2, NodeAddress: nodeA, Status: types.Staking, NodeFee: eth.EthToWei(0.14), NodeDepositBalance: eth.EthToWei(8), UserDepositBalance: eth.EthToWei(24)},
+			{MinipoolAddress: minipool1, NodeAddress: nodeA, Status: types.Staking, NodeFee: eth.EthToWei(0.1), NodeDepositBalance: eth.EthToWei(16), UserDepositBalance: eth.EthToWei(16)},
+			{MinipoolAddress: minipool3, NodeAddress: nodeB, Status: types.Staking, NodeFee: eth.EthToWei(0.1), NodeDepositBalance: eth.EthToWei(16), UserDepositBalance: eth.EthToWei(16)},
+			{MinipoolAddress: minipool4, NodeAddress: nodeA, Status: types.Staking, NodeFee: eth.EthToWei(0.1), NodeDepositBalance: eth.EthToWei(16), UserDepositBalance: eth.EthToWei(16)},
+		},
+		OracleDaoMemberDetails: []state.OracleDaoMemberDetails{
+			{Address: memberD, Exists: true, JoinedTime: intervalMid},
+			{Address: nodeA, Exists: true, JoinedTime: time.Unix(0, 0)},
+		},
+	}
+}
+
+// Get the beacon inputs for the interval; minipool 4 has exited
+func getBeaconInputs() *rewardstree.BeaconInputs {
+	return &rewardstree.BeaconInputs{
+		Attestations: map[common.Address]rewardstree.AttestationPerformance{
+			minipool1: {Successful: 100},
+			minipool2: {Successful: 100, Missed: 5},
+			minipool3: {Successful: 100},
+			minipool4: {Successful: 100},
+		},
+		Balances: map[common.Address]*big.Int{
+			minipool1: eth.EthToWei(32),
+			minipool2: eth.EthToWei(32),
+			minipool3: eth.EthToWei(32),
+		},
+	}
+}
+
+func TestGenerateRewards(t *testing.T) {
+
+	settings := rewardstree.IntervalSettings{
+		Index:           5,
+		IntervalsPassed: 1,
+		Network:         "mainnet",
+		StartTime:       intervalStart,
+		EndTime:         intervalEnd,
+	}
+	generated, err := rewardstree.GenerateRewards(getNetworkState(), getBeaconInputs(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the node allocations
+	expected := map[common.Address][3]int64{
+		nodeA:   {2800, 333, 451},
+		nodeB:   {4200, 0, 0},
+		memberD: {0, 166, 0},
+	}
+	if len(generated.File.NodeRewards) != len(expected) {
+		t.Fatalf("Incorrect node reward count %d", len(generated.File.NodeRewards))
+	}
+	for address, amounts := range expected {
+		rewards, exists := generated.File.GetNodeRewards(address)
+		if !exists {
+			t.Fatalf("Node %s has no rewards", address.Hex())
+		}
+		if rewards.CollateralRpl.Int64() != amounts[0] || rewards.OracleDaoRpl.Int64() != amounts[1] || rewards.SmoothingPoolEth.Int64() != amounts[2] {
+			t.Errorf("Incorrect rewards for node %s: %+v", address.Hex(), rewards)
+		}
+	}
+
+	// Generated files aren't labelled with a Smartnode ruleset
+	if generated.File.RulesetVersion != 0 {
+		t.Errorf("Generated file has ruleset version %d", generated.File.RulesetVersion)
+	}
+	data, err := generated.File.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "rulesetVersion") {
+		t.Error("Serialized generated file claims a ruleset version")
+	}
+
+	// Check the totals
+	totals := generated.File.TotalRewards
+	if totals.ProtocolDaoRpl.Int64() != 2501 || totals.PoolStakerSmoothingPoolEth.Int64() != 549 {
+		t.Errorf("Incorrect treasury RPL %s or pool staker ETH %s", totals.ProtocolDaoRpl.String(), totals.PoolStakerSmoothingPoolEth.String())
+	}
+	networks := generated.File.NetworkRewards
+	if len(networks) != 2 || networks[0].CollateralRpl.Int64() != 2800 || networks[1].CollateralRpl.Int64() != 4200 ||
+		networks[0].OracleDaoRpl.Int64() != 499 || networks[0].SmoothingPoolEth.Int64() != 451 || networks[1].SmoothingPoolEth.Sign() != 0 {
+		t.Errorf("Incorrect per-network totals %+v", networks)
+	}
+
+	// Every RPL token is allocated
+	total := big.NewInt(0).Set(totals.ProtocolDaoRpl)
+	total.Add(total, totals.TotalCollateralRpl)
+	total.Add(total, totals.TotalOracleDaoRpl)
+	if total.Int64() != 10000 {
+		t.Errorf("Allocated %s RPL instead of 10000", total.String())
+	}
+
+	// The file verifies and survives serialization
+	if err := generated.File.Verify(); err != nil {
+		t.Error(err)
+	}
+	data, err = generated.File.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := rewardstree.ParseRewardsFile(data)
+	if err != nil || parsed.Verify() != nil || parsed.MerkleRoot != generated.File.MerkleRoot {
+		t.Errorf("Serialized file does not verify: %v", err)
+	}
+
+	// The output doesn't depend on the order of the inputs
+	networkState := getNetworkState()
+	nodes := networkState.NodeDetails
+	nodes[0], nodes[2] = nodes[2], nodes[0]
+	minipools := networkState.MinipoolDetails
+	minipools[0], minipools[1] = minipools[1], minipools[0]
+	regenerated, err := rewardstree.GenerateRewards(networkState, getBeaconInputs(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regenerated.File.MerkleRoot != generated.File.MerkleRoot {
+		t.Error("Generated roots differ for reordered inputs")
+	}
+
+	// Explicit smoothing pool membership overrides the snapshot
+	beacon := getBeaconInputs()
+	beacon.SmoothingPoolMembers = map[common.Address]bool{nodeB: true}
+	generated, err = rewardstree.GenerateRewards(getNetworkState(), beacon, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewards, _ := generated.File.GetNodeRewards(nodeB); rewards.SmoothingPoolEth.Int64() != 550 || generated.File.TotalRewards.PoolStakerSmoothingPoolEth.Int64() != 450 {
+		t.Errorf("Incorrect smoothing pool rewards %+v", rewards)
+	}
+
+	// Invalid intervals are rejected
+	settings.EndTime = intervalStart
+	if _, err := rewardstree.GenerateRewards(getNetworkState(), getBeaconInputs(), settings); err == nil {
+		t.Error("Generated rewards for an empty interval")
+	}
+
+}
@@ -0,0 +1,218 @@
+package rewardstree
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/rewards/rewardstree"
+)
+
+const v1File = `{
+	"rewardsFileVersion": 1,
+	"index": 4,
+	"network": "mainnet",
+	"startTime": "2022-12-01T00:00:00Z",
+	"endTime": "2022-12-29T00:00:00Z",
+	"consensusStartBlock": 5000000,
+	"consensusEndBlock": 5201600,
+	"executionStartBlock": 16000000,
+	"executionEndBlock": 16200000,
+	"intervalsPassed": 1,
+	"merkleRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+	"totalRewards": {
+		"protocolDaoRpl": "1000",
+		"totalCollateralRpl": "600",
+		"totalOracleDaoRpl": "30",
+		"totalSmoothingPoolEth": "90",
+		"poolStakerSmoothingPoolEth": "40",
+		"nodeOperatorSmoothingPoolEth": "50"
+	},
+	"networkRewards": {
+		"0": {"collateralRpl": "600", "oracleDaoRpl": "30", "smoothingPoolEth": "50"}
+	},
+	"nodeRewards": {
+		"0x3000000000000000000000000000000000000003": {"rewardNetwork": 0, "collateralRpl": "300", "oracleDaoRpl": "0", "smoothingPoolEth": "0", "merkleProof": []},
+		"0x1000000000000000000000000000000000000001": {"rewardNetwork": 0, "collateralRpl": "200", "oracleDaoRpl": "30", "smoothingPoolEth": "50", "merkleProof": []},
+		"0x2000000000000000000000000000000000000002": {"rewardNetwork": 0, "collateralRpl": "100", "oracleDaoRpl": "0", "smoothingPoolEth": "0", "merkleProof": []},
+		"0x4000000000000000000000000000000000000004": {"rewardNetwork": 0, "collateralRpl": "0", "oracleDaoRpl": "0", "smoothingPoolEth": "0", "merkleProof": []}
+	}
+}`
+
+var (
+	node1 = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	node4 = common.HexToAddress("0x4000000000000000000000000000000000000004")
+)
+
+func TestLeafHash(t *testing.T) {
+	data := rewardstree.GetLeafData(node1, 1, big.NewInt(230), big.NewInt(50))
+	if len(data) != 116 {
+		t.Fatalf("Incorrect leaf data length %d", len(data))
+	}
+	if common.BytesToAddress(data[:20]) != node1 {
+		t.Error("Incorrect leaf address")
+	}
+	if new(big.Int).SetBytes(data[20:52]).Uint64() != 1 || new(big.Int).SetBytes(data[52:84]).Uint64() != 230 || new(big.Int).SetBytes(data[84:]).Uint64() != 50 {
+		t.Error("Incorrect leaf amounts")
+	}
+	if rewardstree.GetLeafHash(node1, 1, big.NewInt(230), big.NewInt(50)) == rewardstree.GetLeafHash(node1, 0, big.NewInt(230), big.NewInt(50)) {
+		t.Error("Leaf hash does not depend on the network")
+	}
+}
+
+func TestRewardsFile(t *testing.T) {
+
+	// Parse the keyed layout
+	file, err := rewardstree.ParseRewardsFile([]byte(v1File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.NodeRewards) != 4 || file.NodeRewards[0].Address != node1 {
+		t.Fatalf("Incorrect node rewards %+v", file.NodeRewards)
+	}
+	rewards, exists := file.GetNodeRewards(node1)
+	if !exists || rewards.GetTotalRpl().Cmp(big.NewInt(230)) != 0 || rewards.SmoothingPoolEth.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("Incorrect rewards for node 1 %+v", rewards)
+	}
+	if file.TotalRewards.ProtocolDaoRpl.Cmp(big.NewInt(1000)) != 0 || file.NetworkRewards[0].OracleDaoRpl.Cmp(big.NewInt(30)) != 0 {
+		t.Error("Incorrect totals")
+	}
+
+	// The placeholder root doesn't match
+	if err := file.Verify(); err == nil {
+		t.Error("Verified a file with the wrong root")
+	}
+
+	// Generate the tree and proofs
+	tree, err := file.GenerateProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.MerkleRoot = tree.GetRoot()
+	if err := file.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Levels[0]) != 4 || tree.Levels[0][3] != (common.Hash{}) {
+		t.Errorf("Tree leaves were not padded with zero hashes")
+	}
+	for _, rewards := range file.NodeRewards[:3] {
+		if len(rewards.MerkleProof) != 2 || !rewardstree.VerifyProof(file.MerkleRoot, rewards.GetLeafHash(), rewards.MerkleProof) {
+			t.Errorf("Invalid proof for node %s", rewards.Address.Hex())
+		}
+	}
+	if _, err := tree.GetProof(node4); err == nil {
+		t.Error("Generated a proof for a node without rewards")
+	}
+
+	// A different amount fails verification
+	if rewardstree.VerifyProof(file.MerkleRoot, rewardstree.GetLeafHash(node1, 0, big.NewInt(231), big.NewInt(50)), file.NodeRewards[0].MerkleProof) {
+		t.Error("Verified a proof for the wrong amount")
+	}
+
+	// Round trip through both layouts
+	for _, version := range []uint64{1, 3} {
+		file.RewardsFileVersion = version
+		data, err := file.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version == 3 && !strings.Contains(string(data), `"address"`) {
+			t.Errorf("Version %d file does not use the list layout", version)
+		}
+		parsed, err := rewardstree.ParseRewardsFile(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parsed.Verify(); err != nil {
+			t.Errorf("Version %d round trip: %s", version, err)
+		}
+		if parsed.MerkleRoot != file.MerkleRoot || !parsed.StartTime.Equal(file.StartTime) || parsed.TotalRewards.TotalSmoothingPoolEth.Cmp(big.NewInt(90)) != 0 {
+			t.Errorf("Version %d round trip changed the file", version)
+		}
+	}
+
+	// Tampered proofs fail verification
+	file.NodeRewards[1].MerkleProof[0] = common.HexToHash("0x01")
+	if err := file.Verify(); err == nil {
+		t.Error("Verified a file with a tampered proof")
+	}
+
+}
+
+func TestRewardsFileFields(t *testing.T) {
+
+	file, err := rewardstree.ParseRewardsFile([]byte(v1File))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each layout has exactly the canonical entry fields, including mainnet's network ID of 0
+	expected := map[uint64][2][]string{
+		1: {
+			{"collateralRpl", "oracleDaoRpl", "smoothingPoolEth"},
+			{"collateralRpl", "merkleProof", "oracleDaoRpl", "rewardNetwork", "smoothingPoolEth"},
+		},
+		3: {
+			{"collateralRpl", "network", "oracleDaoRpl", "smoothingPoolEth"},
+			{"address", "collateralRpl", "merkleProof", "network", "oracleDaoRpl", "smoothingPoolEth"},
+		},
+	}
+	for version, fields := range expected {
+		file.RewardsFileVersion = version
+		data, err := file.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw struct {
+			NetworkRewards json.RawMessage `json:"networkRewards"`
+			NodeRewards    json.RawMessage `json:"nodeRewards"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Fatal(err)
+		}
+		var networkEntry, nodeEntry map[string]json.RawMessage
+		if version == 1 {
+			var networkRewards, nodeRewards map[string]map[string]json.RawMessage
+			if err := json.Unmarshal(raw.NetworkRewards, &networkRewards); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(raw.NodeRewards, &nodeRewards); err != nil {
+				t.Fatal(err)
+			}
+			networkEntry = networkRewards["0"]
+			nodeEntry = nodeRewards[node1.Hex()]
+		} else {
+			var networkRewards, nodeRewards []map[string]json.RawMessage
+			if err := json.Unmarshal(raw.NetworkRewards, &networkRewards); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(raw.NodeRewards, &nodeRewards); err != nil {
+				t.Fatal(err)
+			}
+			networkEntry = networkRewards[0]
+			nodeEntry = nodeRewards[0]
+		}
+		if keys := getKeys(networkEntry); !reflect.DeepEqual(keys, fields[0]) {
+			t.Errorf("Version %d network rewards have fields %v", version, keys)
+		}
+		if keys := getKeys(nodeEntry); !reflect.DeepEqual(keys, fields[1]) {
+			t.Errorf("Version %d node rewards have fields %v", version, keys)
+		}
+	}
+
+}
+
+// Get the sorted keys of a JSON object
+func getKeys(object map[string]json.RawMessage) []string {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+const contractName string = "rocketNodeManager"
+
+// Get a pointer to a block number
+func block(number uint64) *uint64 {
+	return &number
+}
+
+// Check the value a cache holds at a block
+func checkValue(t *testing.T, cache rocketpool.Cache, blockNumber *uint64, expected string) {
+	t.Helper()
+	value, exists, err := cache.Get(rocketpool.CacheValueType_Address, contractName, blockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected == "" {
+		if exists {
+			t.Errorf("Expected no value at block %v but got %s", blockNumber, value)
+		}
+		return
+	}
+	if !exists {
+		t.Errorf("Expected %s at block %v but got nothing", expected, blockNumber)
+	} else if value != expected {
+		t.Errorf("Expected %s at block %v but got %s", expected, blockNumber, value)
+	}
+}
+
+// Check the block range and upgrade behavior of a cache
+func testCache(t *testing.T, cache rocketpool.Cache) {
+
+	// A current value is valid from the block it was seen at
+	if err := cache.Set(rocketpool.CacheValueType_Address, contractName, "v1", 100, true); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "v1")
+	checkValue(t, cache, block(150), "v1")
+	checkValue(t, cache, block(99), "")
+
+	// Historical lookups of the same value extend its range
+	if err := cache.Set(rocketpool.CacheValueType_Address, contractName, "v1", 50, false); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, block(60), "v1")
+
+	// Upgrades of other contracts are ignored
+	if err := cache.Invalidate(crypto.Keccak256Hash([]byte("rocketMinipoolManager")), 200); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "v1")
+
+	// An upgrade closes the current value at the block before it
+	if err := cache.Invalidate(crypto.Keccak256Hash([]byte(contractName)), 200); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "")
+	checkValue(t, cache, block(199), "v1")
+	checkValue(t, cache, block(200), "")
+
+	// The new value becomes current
+	if err := cache.Set(rocketpool.CacheValueType_Address, contractName, "v2", 210, true); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "v2")
+	checkValue(t, cache, block(199), "v1")
+
+	// Late upgrade events don't close values seen after the upgrade
+	if err := cache.Invalidate(crypto.Keccak256Hash([]byte(contractName)), 205); err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "v2")
+
+	// Values are kept per type
+	value, exists, err := cache.Get(rocketpool.CacheValueType_ABI, contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Errorf("Address cached as ABI %s", value)
+	}
+
+}
+
+func TestMemoryCache(t *testing.T) {
+	testCache(t, rocketpool.NewMemoryCache())
+}
+
+func TestDiskCache(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "contracts.json")
+	cache, err := rocketpool.NewDiskCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCache(t, cache)
+	if err := cache.SetCheckpoint(250); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache survives a restart
+	reloaded, err := rocketpool.NewDiskCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	checkValue(t, reloaded, nil, "v2")
+	checkValue(t, reloaded, block(199), "v1")
+	checkpoint, err := reloaded.GetCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != 250 {
+		t.Errorf("Incorrect checkpoint %d", checkpoint)
+	}
+
+}
+
+func TestDiskCacheCompaction(t *testing.T) {
+
+	// Caches saved as a single snapshot are still loaded
+	path := filepath.Join(t.TempDir(), "contracts.json")
+	snapshot := `{"entries":{"address":{"rocketNodeManager":[{"value":"v1","fromBlock":100,"toBlock":100,"current":true,"closed":false}]}},"checkpoint":100}`
+	if err := os.WriteFile(path, []byte(snapshot), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := rocketpool.NewDiskCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, cache, nil, "v1")
+
+	// Changes are appended rather than rewriting the cache, until enough build up to compact it
+	for i := uint64(101); i <= 1100; i++ {
+		if err := cache.SetCheckpoint(i); err != nil {
+			t.Fatal(err)
+		}
+		lines := countLines(t, path)
+		if i < 1099 && lines != int(i-99) {
+			t.Fatalf("Cache has %d lines after %d changes", lines, i-100)
+		}
+		if i == 1100 && lines > 2 {
+			t.Fatalf("Cache was not compacted: %d lines", lines)
+		}
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted writes are discarded
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"checkpo`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	reloaded, err := rocketpool.NewDiskCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	checkValue(t, reloaded, nil, "v1")
+	checkpoint, err := reloaded.GetCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != 1100 {
+		t.Errorf("Incorrect checkpoint %d", checkpoint)
+	}
+
+}
+
+// Count the lines in a file
+func countLines(t *testing.T, path string) int {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(bytes), "\n")
+}
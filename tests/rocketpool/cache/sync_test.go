@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+)
+
+const (
+	upgradeAbi = `[
+		{"type":"event","name":"ContractUpgraded","inputs":[{"name":"name","type":"bytes32","indexed":true},{"name":"oldAddress","type":"address","indexed":true},{"name":"newAddress","type":"address","indexed":true},{"name":"time","type":"uint256","indexed":false}]},
+		{"type":"event","name":"ABIUpgraded","inputs":[{"name":"name","type":"bytes32","indexed":true},{"name":"time","type":"uint256","indexed":false}]}
+	]`
+	nodeManagerAbiV1 = `[{"type":"function","name":"getNodeCount","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+	nodeManagerAbiV2 = `[{"type":"function","name":"getNodeCount","inputs":[],"outputs":[{"name":"","type":"uint256"}]},{"type":"function","name":"getNodeAt","inputs":[{"name":"_index","type":"uint256"}],"outputs":[{"name":"","type":"address"}]}]`
+)
+
+var (
+	upgradeAddress     = common.HexToAddress("0x2000000000000000000000000000000000000001")
+	nodeManagerAddress = common.HexToAddress("0x3000000000000000000000000000000000000001")
+	newNodeManager     = common.HexToAddress("0x3000000000000000000000000000000000000002")
+)
+
+// Create a fake client with the upgrade contract
+func newClient(t *testing.T) *fakeclient.Client {
+	client := fakeclient.New(t)
+	client.SetContract("rocketDAONodeTrustedUpgrade", upgradeAddress, upgradeAbi)
+	return client
+}
+
+// Create an upgrade log for a contract
+func upgradeLog(t *testing.T, eventName string, contractName string, blockNumber uint64) types.Log {
+	parsed, err := abi.JSON(strings.NewReader(upgradeAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Log{
+		Address:     upgradeAddress,
+		Topics:      []common.Hash{parsed.Events[eventName].ID, crypto.Keccak256Hash([]byte(contractName))},
+		BlockNumber: blockNumber,
+	}
+}
+
+func TestCurrentLookupSyncsUpgrades(t *testing.T) {
+
+	// A cache from an earlier run, holding the node manager as of block 100
+	client := newClient(t)
+	client.SetContract(contractName, nodeManagerAddress, nodeManagerAbiV1)
+	cache := rocketpool.NewMemoryCache()
+	if err := cache.Set(rocketpool.CacheValueType_Address, contractName, nodeManagerAddress.Hex(), 100, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetCheckpoint(100); err != nil {
+		t.Fatal(err)
+	}
+
+	// The contract was upgraded while nothing was watching
+	client.SetContract(contractName, newNodeManager, nodeManagerAbiV1)
+	client.Logs = []types.Log{upgradeLog(t, "ContractUpgraded", contractName, 150)}
+	client.LatestBlock = 200
+
+	// The first current lookup applies the upgrade before trusting the cache
+	rp, err := rocketpool.NewRocketPoolWithCache(client, fakeclient.StorageAddress, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := rp.GetAddress(contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *address != newNodeManager {
+		t.Errorf("Got pre-upgrade address %s", address.Hex())
+	}
+	checkpoint, err := cache.GetCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != 200 {
+		t.Errorf("Incorrect checkpoint %d after sync", checkpoint)
+	}
+
+}
+
+func TestDecodedContractsAreCached(t *testing.T) {
+
+	client := newClient(t)
+	client.SetContract(contractName, nodeManagerAddress, nodeManagerAbiV1)
+	client.LatestBlock = 100
+	rp := fakeclient.NewRocketPool(t, client)
+
+	// Repeated lookups reuse the decoded ABI and bound contract without calling RocketStorage
+	contract, err := rp.GetContract(contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := len(client.GetCalls())
+	again, err := rp.GetContract(contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != contract {
+		t.Error("Contract was bound again")
+	}
+	decodedAbi, err := rp.GetABI(contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedAbi != contract.ABI {
+		t.Error("ABI was decoded again")
+	}
+	if len(client.GetCalls()) != calls {
+		t.Errorf("Cached lookups made %d RocketStorage calls", len(client.GetCalls())-calls)
+	}
+
+	// An upgrade drops them
+	client.SetContract(contractName, nodeManagerAddress, nodeManagerAbiV2)
+	client.LatestBlock = 110
+	if err := rp.ProcessUpgradeLog(upgradeLog(t, "ABIUpgraded", contractName, 105)); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, err := rp.GetContract(contractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upgraded == contract {
+		t.Fatal("Contract was not rebound after an upgrade")
+	}
+	if _, exists := upgraded.ABI.Methods["getNodeAt"]; !exists {
+		t.Error("Upgraded contract has the old ABI")
+	}
+
+}
+
+func TestLegacyContractsAreCached(t *testing.T) {
+
+	// The rewards pool hasn't been upgraded yet, so there's no legacy contract
+	rewardsPoolAddress := common.HexToAddress("0x4000000000000000000000000000000000000001")
+	legacyAddress := common.HexToAddress("0x4000000000000000000000000000000000000002")
+	client := newClient(t)
+	client.SetContract("rocketRewardsPool", rewardsPoolAddress, nodeManagerAbiV1)
+	client.LatestBlock = 100
+	rp := fakeclient.NewRocketPool(t, client)
+	contract, err := rp.VersionManager.V1_0_0.GetContract("rocketRewardsPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *contract.Address != rewardsPoolAddress {
+		t.Errorf("Got %s instead of the current contract", contract.Address.Hex())
+	}
+
+	// The upgrade moves the old contract to its legacy name
+	client.SetContract("rocketRewardsPool", newNodeManager, nodeManagerAbiV1)
+	client.SetContract("rocketRewardsPool.v1", legacyAddress, nodeManagerAbiV1)
+	client.LatestBlock = 110
+	if err := rp.ProcessUpgradeLog(upgradeLog(t, "ContractUpgraded", "rocketRewardsPool", 105)); err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := rp.VersionManager.V1_0_0.GetContract("rocketRewardsPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *legacy.Address != legacyAddress {
+		t.Fatalf("Got %s instead of the legacy contract", legacy.Address.Hex())
+	}
+
+	// Repeated lookups reuse the legacy contract without calling RocketStorage
+	calls := len(client.GetCalls())
+	again, err := rp.VersionManager.V1_0_0.GetContract("rocketRewardsPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != legacy {
+		t.Error("Legacy contract was bound again")
+	}
+	if len(client.GetCalls()) != calls {
+		t.Errorf("Cached legacy lookup made %d RocketStorage calls", len(client.GetCalls())-calls)
+	}
+
+	// Historical lookups read at the requested block
+	if _, err := rp.VersionManager.V1_0_0.GetContract("rocketRewardsPool", &bind.CallOpts{BlockNumber: big.NewInt(50)}); err != nil {
+		t.Fatal(err)
+	}
+	callBlocks := client.GetCallBlocks()
+	if len(callBlocks) == calls || callBlocks[calls] == nil || callBlocks[calls].Uint64() != 50 {
+		t.Errorf("Historical legacy lookup wasn't made at block 50: %v", callBlocks[calls:])
+	}
+
+}
@@ -0,0 +1,82 @@
+package callcontext
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+)
+
+const nodeManagerAbi = `[{"type":"function","name":"getNodeCount","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+
+var (
+	nodeManagerAddress = common.HexToAddress("0x3000000000000000000000000000000000000001")
+	multicallAddress   = common.HexToAddress("0x4000000000000000000000000000000000000001")
+	balancesAddress    = common.HexToAddress("0x5000000000000000000000000000000000000001")
+)
+
+func TestCancelledCallOpts(t *testing.T) {
+
+	client := fakeclient.New(t)
+	client.LatestBlock = 100
+	client.SetContract("rocketNodeManager", nodeManagerAddress, nodeManagerAbi)
+	client.SetCallHandler(nodeManagerAddress, nodeManagerAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+		return []interface{}{big.NewInt(3)}, nil
+	})
+	rp := fakeclient.NewRocketPool(t, client)
+	contract, err := rp.GetContract("rocketNodeManager", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := &bind.CallOpts{Context: ctx}
+
+	// Contract calls
+	count := new(*big.Int)
+	if err := contract.Call(nil, count, "getNodeCount"); err != nil || (*count).Int64() != 3 {
+		t.Fatalf("Incorrect node count %v: %v", *count, err)
+	}
+	if err := contract.Call(opts, count, "getNodeCount"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled call returned %v", err)
+	}
+
+	// Multicalls
+	mc, err := multicall.NewMultiCaller(client, multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.AddCall(contract, count, "getNodeCount"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mc.FlexibleCall(false, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled multicall returned %v", err)
+	}
+	balances, err := multicall.NewBalanceBatcher(client, balancesAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := balances.GetEthBalances([]common.Address{nodeManagerAddress}, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled balance batch returned %v", err)
+	}
+
+	// Log scans
+	if _, err := eth.FilterContractLogs(rp, "rocketNodeManager", eth.FilterQuery{}, nil, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled log scan returned %v", err)
+	}
+	if _, err := eth.FilterContractEvents(rp, "rocketNodeManager", nil, eth.FilterQuery{}, nil, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled event scan returned %v", err)
+	}
+	if len(client.GetFilterQueries()) != 0 {
+		t.Errorf("Cancelled scans made %d log queries", len(client.GetFilterQueries()))
+	}
+
+}
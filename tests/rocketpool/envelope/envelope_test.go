@@ -0,0 +1,202 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// A contract whose every call emits Ping()
+const pingerAbi = `[{"type":"function","name":"ping","inputs":[],"outputs":[],"stateMutability":"nonpayable"},{"type":"event","name":"Ping","inputs":[],"anonymous":false}]`
+
+// Init code that deploys `PUSH32 keccak("Ping()") PUSH1 0 PUSH1 0 LOG1 STOP`
+var pingerBytecode = "0x6027600c60003960276000f3" + "7f" + crypto.Keccak256Hash([]byte("Ping()")).Hex()[2:] + "60006000a100"
+
+var chainID = big.NewInt(1337)
+
+// Adds the client functions the simulated backend doesn't provide
+type simulatedClient struct {
+	*backends.SimulatedBackend
+}
+
+func (c *simulatedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+func (c *simulatedClient) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// Create a simulated chain with a funded account and a deployed pinger
+func setup(t *testing.T) (*simulatedClient, *ecdsa.PrivateKey, *rocketpool.Contract) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: eth.EthToWei(100)},
+	}
+	backend := backends.NewSimulatedBackend(alloc, 30000000)
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+	client := &simulatedClient{SimulatedBackend: backend}
+
+	parsed, err := abi.JSON(strings.NewReader(pingerAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, bound, err := bind.DeployContract(opts, parsed, hexutil.MustDecode(pingerBytecode), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+
+	return client, key, &rocketpool.Contract{
+		Contract: bound,
+		Address:  &address,
+		ABI:      &parsed,
+		Client:   client,
+	}
+}
+
+// Sign an envelope offline, through its JSON form
+func signEnvelope(t *testing.T, envelope *rocketpool.TransactionEnvelope, key *ecdsa.PrivateKey) []byte {
+	serialized, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := new(rocketpool.TransactionEnvelope)
+	if err := json.Unmarshal(serialized, received); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := types.SignTx(received.GetTransaction(), types.LatestSignerForChainID(received.ChainID.ToInt()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedBytes, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedBytes
+}
+
+func TestContractEnvelope(t *testing.T) {
+
+	client, key, contract := setup(t)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	// Build without a signer
+	envelope, err := rocketpool.BuildEnvelope(client, chainID, &bind.TransactOpts{From: from}, func(opts *bind.TransactOpts) error {
+		_, err := contract.Transact(opts, "ping")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.From != from || *envelope.To != *contract.Address || envelope.Nonce != 1 {
+		t.Errorf("Incorrect envelope sender, target or nonce: %+v", envelope)
+	}
+	if envelope.Gas == 0 || envelope.GasFeeCap.ToInt().Sign() == 0 {
+		t.Error("Envelope is missing its gas limit or fees")
+	}
+	expectedData, _ := contract.ABI.Pack("ping")
+	if !bytes.Equal(envelope.Data, expectedData) {
+		t.Errorf("Incorrect calldata %s", envelope.Data.String())
+	}
+
+	// Nothing was sent while building
+	if nonce, err := client.PendingNonceAt(context.Background(), from); err != nil || nonce != 1 {
+		t.Fatalf("Transaction was sent while building (nonce %d)", nonce)
+	}
+
+	// Broadcast the signed bytes
+	signedBytes := signEnvelope(t, envelope, key)
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(signedBytes); err != nil {
+		t.Fatal(err)
+	}
+	if types.LatestSignerForChainID(chainID).Hash(signed) != envelope.SigningHash {
+		t.Error("Signed transaction hash doesn't match the envelope's signing hash")
+	}
+	hash, err := rocketpool.Broadcast(client, envelope, signedBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+	receipt, err := client.TransactionReceipt(context.Background(), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || len(receipt.Logs) != 1 {
+		t.Error("Broadcast transaction didn't ping")
+	}
+
+}
+
+func TestTransferEnvelope(t *testing.T) {
+
+	client, key, _ := setup(t)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	toAddress := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Build a plain transfer
+	envelope, err := rocketpool.BuildEnvelope(client, chainID, &bind.TransactOpts{From: from, Value: big.NewInt(5)}, func(opts *bind.TransactOpts) error {
+		_, err := eth.SendTransaction(client, toAddress, chainID, nil, false, opts)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Value.ToInt().Int64() != 5 || *envelope.To != toAddress {
+		t.Errorf("Incorrect envelope %+v", envelope)
+	}
+
+	// Transactions that don't match the envelope or its sender are rejected
+	otherKey, _ := crypto.GenerateKey()
+	if _, err := rocketpool.Broadcast(client, envelope, signEnvelope(t, envelope, otherKey)); err == nil {
+		t.Error("Transaction from another sender was broadcast")
+	}
+	tampered := *envelope
+	tampered.Value = (*hexutil.Big)(big.NewInt(6))
+	if _, err := rocketpool.Broadcast(client, envelope, signEnvelope(t, &tampered, key)); err == nil {
+		t.Error("Tampered transaction was broadcast")
+	}
+
+	// Write functions must build exactly one transaction
+	if _, err := rocketpool.BuildEnvelope(client, chainID, &bind.TransactOpts{From: from}, func(opts *bind.TransactOpts) error {
+		return nil
+	}); err == nil {
+		t.Error("Envelope was built without a transaction")
+	}
+
+	if _, err := rocketpool.Broadcast(client, envelope, signEnvelope(t, envelope, key)); err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+	if balance, err := client.BalanceAt(context.Background(), toAddress, nil); err != nil || balance.Int64() != 5 {
+		t.Errorf("Incorrect balance %v", balance)
+	}
+
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+const upgradeAbi = `[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"bytes32","name":"name","type":"bytes32"},{"indexed":true,"internalType":"address","name":"oldAddress","type":"address"},{"indexed":true,"internalType":"address","name":"newAddress","type":"address"},{"indexed":false,"internalType":"uint256","name":"time","type":"uint256"}],"name":"ContractUpgraded","type":"event"}]`
+
+// Mirrors the ContractUpgraded event the way an abigen binding would
+type contractUpgraded struct {
+	Name       [32]byte
+	OldAddress common.Address
+	NewAddress common.Address
+	Time       *big.Int
+}
+
+// Get a contract bound to the upgrade ABI without a client
+func getContract(t *testing.T, address common.Address) *rocketpool.Contract {
+	parsed, err := abi.JSON(strings.NewReader(upgradeAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rocketpool.Contract{
+		Contract: bind.NewBoundContract(address, parsed, nil, nil, nil),
+		Address:  &address,
+		ABI:      &parsed,
+	}
+}
+
+// Get a synthetic ContractUpgraded log
+func getUpgradeLog(t *testing.T, contract *rocketpool.Contract, name string, oldAddress common.Address, newAddress common.Address, time int64) types.Log {
+	event := contract.ABI.Events["ContractUpgraded"]
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(time))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Log{
+		Address: *contract.Address,
+		Topics: []common.Hash{
+			event.ID,
+			crypto.Keccak256Hash([]byte(name)),
+			common.BytesToHash(oldAddress.Bytes()),
+			common.BytesToHash(newAddress.Bytes()),
+		},
+		Data:        data,
+		BlockNumber: 100,
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+
+	contract := getContract(t, common.HexToAddress("0x1000000000000000000000000000000000000001"))
+	oldAddress := common.HexToAddress("0x2000000000000000000000000000000000000001")
+	newAddress := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	log := getUpgradeLog(t, contract, "rocketNodeManager", oldAddress, newAddress, 1700000000)
+
+	// Decode into a generic event
+	event, err := contract.DecodeLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Name != "ContractUpgraded" {
+		t.Errorf("Incorrect event name %s", event.Name)
+	}
+	if event.Removed {
+		t.Error("Event was marked as removed")
+	}
+	if fieldAddress, ok := event.Fields["newAddress"].(common.Address); !ok || fieldAddress != newAddress {
+		t.Errorf("Incorrect indexed field %v", event.Fields["newAddress"])
+	}
+	if fieldTime, ok := event.Fields["time"].(*big.Int); !ok || fieldTime.Int64() != 1700000000 {
+		t.Errorf("Incorrect data field %v", event.Fields["time"])
+	}
+
+	// Unpack into a typed struct
+	var upgraded contractUpgraded
+	if err := event.Unpack(&upgraded); err != nil {
+		t.Fatal(err)
+	}
+	if upgraded.OldAddress != oldAddress || upgraded.NewAddress != newAddress {
+		t.Errorf("Incorrect addresses %s / %s", upgraded.OldAddress.Hex(), upgraded.NewAddress.Hex())
+	}
+	if upgraded.Name != crypto.Keccak256Hash([]byte("rocketNodeManager")) {
+		t.Errorf("Incorrect name hash %x", upgraded.Name)
+	}
+	if upgraded.Time.Int64() != 1700000000 {
+		t.Errorf("Incorrect time %s", upgraded.Time.String())
+	}
+
+	// Reorg removals are passed through
+	log.Removed = true
+	if event, err := contract.DecodeLog(log); err != nil {
+		t.Fatal(err)
+	} else if !event.Removed {
+		t.Error("Removed log was not marked as removed")
+	}
+
+}
+
+func TestDecodeLogErrors(t *testing.T) {
+
+	contract := getContract(t, common.HexToAddress("0x1000000000000000000000000000000000000001"))
+	log := getUpgradeLog(t, contract, "rocketNodeManager", common.Address{}, common.Address{}, 0)
+
+	// Logs from another contract are rejected
+	other := getContract(t, common.HexToAddress("0x1000000000000000000000000000000000000002"))
+	if _, err := other.DecodeLog(log); err == nil {
+		t.Error("Log from another contract was decoded")
+	}
+
+	// Unknown events are rejected
+	unknown := log
+	unknown.Topics = append([]common.Hash{crypto.Keccak256Hash([]byte("Unknown()"))}, log.Topics[1:]...)
+	if _, err := contract.DecodeLog(unknown); err == nil {
+		t.Error("Unknown event was decoded")
+	}
+
+	// Typed unpacking requires a struct pointer
+	var upgraded contractUpgraded
+	if err := contract.UnpackLog(log, "ContractUpgraded", upgraded); err == nil {
+		t.Error("Unpacked into a non-pointer")
+	}
+	if err := contract.UnpackLog(log, "Unknown", &upgraded); err == nil {
+		t.Error("Unpacked into an unknown event")
+	}
+
+}
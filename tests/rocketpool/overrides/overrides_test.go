@@ -0,0 +1,106 @@
+package overrides
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+var (
+	contractAddress = common.HexToAddress("0x3000000000000000000000000000000000000001")
+	otherAddress    = common.HexToAddress("0x3000000000000000000000000000000000000002")
+	setSlot         = common.HexToHash("0x01")
+	clearedSlot     = common.HexToHash("0x02")
+)
+
+// A prestate tracer diff where one slot is written and another is cleared
+const prestateDiff = `{
+	"pre": {
+		"0x3000000000000000000000000000000000000001": {"balance": "0x0", "storage": {
+			"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000005",
+			"0x0000000000000000000000000000000000000000000000000000000000000002": "0x0000000000000000000000000000000000000000000000000000000000000007"
+		}},
+		"0x3000000000000000000000000000000000000002": {"balance": "0x0", "nonce": 1}
+	},
+	"post": {
+		"0x3000000000000000000000000000000000000001": {"storage": {
+			"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000009"
+		}},
+		"0x3000000000000000000000000000000000000002": {"nonce": 2}
+	}
+}`
+
+// Fake debug namespace
+type debugService struct {
+	config map[string]interface{}
+}
+
+func (s *debugService) TraceCall(args map[string]interface{}, block string, config map[string]interface{}) (json.RawMessage, error) {
+	s.config = config
+	return json.RawMessage(prestateDiff), nil
+}
+
+// Fake eth namespace
+type ethService struct {
+	block     string
+	overrides map[common.Address]map[string]map[common.Hash]common.Hash
+}
+
+func (s *ethService) Call(args map[string]interface{}, block string, overrides map[common.Address]map[string]map[common.Hash]common.Hash) (hexutil.Bytes, error) {
+	s.block = block
+	s.overrides = overrides
+	return hexutil.Bytes{0x01}, nil
+}
+
+func TestStorageOverrides(t *testing.T) {
+
+	server := rpc.NewServer()
+	debug, eth := &debugService{}, &ethService{}
+	if err := server.RegisterName("debug", debug); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	client := rocketpool.NewEIP1898Client(rpc.DialInProc(server))
+	call := ethereum.CallMsg{To: &contractAddress, Data: []byte{0xaa}}
+
+	// Written slots take their new value and cleared slots are zeroed; untouched accounts are skipped
+	changes, err := client.TraceCallStorageChanges(context.Background(), call, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || len(changes[contractAddress]) != 2 {
+		t.Fatalf("Incorrect storage changes %v", changes)
+	}
+	if changes[contractAddress][setSlot] != common.BigToHash(big.NewInt(9)) || changes[contractAddress][clearedSlot] != (common.Hash{}) {
+		t.Errorf("Incorrect slot values %v", changes[contractAddress])
+	}
+	if debug.config["tracer"] != "prestateTracer" {
+		t.Errorf("Incorrect tracer config %v", debug.config)
+	}
+
+	// Calls with storage send the changes as a state diff override
+	result, err := client.CallContractWithStorage(context.Background(), call, big.NewInt(100), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0] != 0x01 || eth.block != "0x64" {
+		t.Errorf("Incorrect call result %x at block %s", result, eth.block)
+	}
+	if stateDiff := eth.overrides[contractAddress]["stateDiff"]; len(stateDiff) != 2 || stateDiff[setSlot] != changes[contractAddress][setSlot] {
+		t.Errorf("Incorrect state overrides %v", eth.overrides)
+	}
+	if _, exists := eth.overrides[otherAddress]; exists {
+		t.Error("Overrode an account without storage changes")
+	}
+
+}
@@ -0,0 +1,213 @@
+package revert
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+const vaultAbi = `[
+	{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}],"outputs":[],"stateMutability":"nonpayable"},
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+]`
+
+var vaultAddress = common.HexToAddress("0x4000000000000000000000000000000000000001")
+
+// A JSON-RPC error with data, as returned by the rpc client
+type jsonError struct {
+	message string
+	data    interface{}
+}
+
+func (e *jsonError) Error() string          { return e.message }
+func (e *jsonError) ErrorData() interface{} { return e.data }
+
+// An execution client that fails every call with a fixed error
+type fakeClient struct {
+	rocketpool.ExecutionClient
+	err   error
+	calls []ethereum.CallMsg
+	block []*big.Int
+}
+
+func (c *fakeClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, call)
+	c.block = append(c.block, blockNumber)
+	return nil, c.err
+}
+
+func (c *fakeClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1e9)}, nil
+}
+
+// Encode a revert payload with a signature and arguments
+func encode(t *testing.T, signature string, types []string, args ...interface{}) []byte {
+	arguments := abi.Arguments{}
+	for _, typeName := range types {
+		argType, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		arguments = append(arguments, abi.Argument{Type: argType})
+	}
+	packed, err := arguments.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(crypto.Keccak256([]byte(signature))[:4], packed...)
+}
+
+func TestParseRevertError(t *testing.T) {
+
+	parsed, err := abi.JSON(strings.NewReader(vaultAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorData := encode(t, "Error(string)", []string{"string"}, "Not enough RPL")
+	panicData := encode(t, "Panic(uint256)", []string{"uint256"}, big.NewInt(0x11))
+	customData := encode(t, "InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(1), big.NewInt(2))
+
+	cases := []struct {
+		name    string
+		err     error
+		kind    rocketpool.RevertKind
+		message string
+	}{
+		{"geth error", &jsonError{"execution reverted: Not enough RPL", "0x" + hex.EncodeToString(errorData)}, rocketpool.RevertKind_Error, "execution reverted: Not enough RPL"},
+		{"geth panic", &jsonError{"execution reverted", "0x" + hex.EncodeToString(panicData)}, rocketpool.RevertKind_Panic, "execution reverted: panic 0x11 (arithmetic underflow or overflow)"},
+		{"custom error", &jsonError{"execution reverted", "0x" + hex.EncodeToString(customData)}, rocketpool.RevertKind_Custom, "execution reverted: InsufficientBalance(1, 2)"},
+		{"besu", &jsonError{"Execution reverted", "0x" + hex.EncodeToString(errorData)}, rocketpool.RevertKind_Error, "execution reverted: Not enough RPL"},
+		{"nethermind abi", errors.New("Reverted 0x" + hex.EncodeToString(errorData)), rocketpool.RevertKind_Error, "Reverted: Not enough RPL"},
+		{"nethermind text", errors.New("Reverted 0x" + hex.EncodeToString([]byte("Not enough RPL"))), rocketpool.RevertKind_Error, "Reverted: Not enough RPL"},
+		{"nethermind data", &jsonError{"VM execution error.", "Reverted 0x" + hex.EncodeToString(errorData)}, rocketpool.RevertKind_Error, "Reverted: Not enough RPL"},
+		{"message only", errors.New("execution reverted: Not enough RPL"), rocketpool.RevertKind_Unknown, "execution reverted: Not enough RPL"},
+		{"hex in message", errors.New("execution reverted: 0x" + hex.EncodeToString(errorData)), rocketpool.RevertKind_Unknown, "execution reverted"},
+		{"no data", &jsonError{"execution reverted", "0x"}, rocketpool.RevertKind_Unknown, "execution reverted"},
+		{"wrapped", fmt.Errorf("error estimating gas needed: %w", &jsonError{"execution reverted", "0x" + hex.EncodeToString(panicData)}), rocketpool.RevertKind_Panic, "execution reverted: panic 0x11 (arithmetic underflow or overflow)"},
+	}
+	for _, c := range cases {
+		revertErr, ok := rocketpool.ParseRevertError(c.err, &parsed)
+		if !ok {
+			t.Errorf("%s: error was not parsed as a revert", c.name)
+			continue
+		}
+		if revertErr.Kind != c.kind {
+			t.Errorf("%s: incorrect kind %d", c.name, revertErr.Kind)
+		}
+		if revertErr.Error() != c.message {
+			t.Errorf("%s: incorrect message %q", c.name, revertErr.Error())
+		}
+		if !errors.Is(revertErr, c.err) {
+			t.Errorf("%s: revert error does not wrap the client error", c.name)
+		}
+	}
+
+	// Custom error arguments are decoded
+	revertErr := rocketpool.DecodeRevertData(customData, &parsed)
+	if revertErr.ErrorName != "InsufficientBalance" || revertErr.ErrorArgs[1].(*big.Int).Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("Incorrect custom error %+v", revertErr)
+	}
+
+	// Unknown custom errors keep their data
+	revertErr = rocketpool.DecodeRevertData(customData)
+	if revertErr.Kind != rocketpool.RevertKind_Unknown || !strings.Contains(revertErr.Error(), hex.EncodeToString(customData)) {
+		t.Errorf("Incorrect unknown error %q", revertErr.Error())
+	}
+
+	// Other errors aren't reverts, even if they mention one
+	for _, err := range []error{
+		errors.New("connection refused"),
+		errors.New("failed to revert to snapshot 0x" + hex.EncodeToString(errorData)),
+		&jsonError{"nonce too low", "invalid nonce"},
+	} {
+		if revertErr, ok := rocketpool.ParseRevertError(err); ok {
+			t.Errorf("Parsed %q as a revert: %v", err.Error(), revertErr)
+		}
+	}
+
+}
+
+func TestSimulate(t *testing.T) {
+
+	parsed, err := abi.JSON(strings.NewReader(vaultAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	customData := encode(t, "InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(1), big.NewInt(2))
+	client := &fakeClient{err: &jsonError{"execution reverted", "0x" + hex.EncodeToString(customData)}}
+	contract := &rocketpool.Contract{
+		Contract: bind.NewBoundContract(vaultAddress, parsed, client, client, client),
+		Address:  &vaultAddress,
+		ABI:      &parsed,
+		Client:   client,
+	}
+	from := common.HexToAddress("0x5000000000000000000000000000000000000001")
+
+	// Simulate a contract method directly
+	_, err = contract.Simulate(&bind.TransactOpts{From: from, Value: big.NewInt(3)}, "withdraw", big.NewInt(2))
+	var revertErr *rocketpool.RevertError
+	if !errors.As(err, &revertErr) || revertErr.ErrorName != "InsufficientBalance" {
+		t.Fatalf("Incorrect simulation error %v", err)
+	}
+	if client.block[0].Cmp(big.NewInt(-1)) != 0 {
+		t.Errorf("Simulation ran at block %s instead of the pending block", client.block[0].String())
+	}
+	if client.calls[0].From != from || client.calls[0].Value.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("Incorrect simulation call %+v", client.calls[0])
+	}
+
+	// Simulate the transaction built by a write function
+	input, _ := parsed.Pack("withdraw", big.NewInt(5))
+	opts := &bind.TransactOpts{From: from, Nonce: big.NewInt(0), GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e9)}
+	_, err = rocketpool.SimulateTransaction(client, opts, func(opts *bind.TransactOpts) error {
+		_, err := contract.Transact(opts, "withdraw", big.NewInt(5))
+		return err
+	}, &parsed)
+	if !errors.As(err, &revertErr) || revertErr.Kind != rocketpool.RevertKind_Custom {
+		t.Fatalf("Incorrect transaction simulation error %v", err)
+	}
+	last := client.calls[len(client.calls)-1]
+	if *last.To != vaultAddress || string(last.Data) != string(input) || last.Gas != 0 {
+		t.Errorf("Incorrect transaction simulation call %+v", last)
+	}
+	if opts.Signer != nil || opts.NoSend || opts.GasLimit != 0 {
+		t.Error("Simulation modified the caller's transaction options")
+	}
+
+	// Write functions must build exactly one transaction
+	_, err = rocketpool.SimulateTransaction(client, opts, func(opts *bind.TransactOpts) error { return nil })
+	if err == nil {
+		t.Error("Simulated a write function that built no transaction")
+	}
+
+	// Reverts during gas estimation are decoded too
+	gasClient := &estimateClient{fakeClient: client}
+	contract.Client = gasClient
+	_, err = contract.Transact(&bind.TransactOpts{From: from}, "withdraw", big.NewInt(5))
+	if !errors.As(err, &revertErr) || revertErr.ErrorName != "InsufficientBalance" {
+		t.Errorf("Incorrect gas estimation error %v", err)
+	}
+
+}
+
+// A client whose gas estimates fail with the call error
+type estimateClient struct {
+	*fakeClient
+}
+
+func (c *estimateClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 0, c.err
+}
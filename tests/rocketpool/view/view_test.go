@@ -0,0 +1,171 @@
+package view
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/contracts"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+)
+
+var contractAddress = common.HexToAddress("0x3000000000000000000000000000000000000001")
+
+const nodeManagerAbi = `[{"type":"function","name":"getNodeCount","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`
+
+// Create a fake client with a canonical chain of the given length and a node manager
+func newClient(t *testing.T, length uint64) *fakeclient.Client {
+	client := fakeclient.New(t)
+	client.LatestBlock = length - 1
+	client.SetContract("rocketNodeManager", contractAddress, nodeManagerAbi)
+	return client
+}
+
+// Create a RocketStorage getAddress call for the node manager
+func getAddressCall(t *testing.T) ethereum.CallMsg {
+	storageAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := storageAbi.Pack("getAddress", crypto.Keccak256Hash([]byte("contract.address"), []byte("rocketNodeManager")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ethereum.CallMsg{To: &fakeclient.StorageAddress, Data: data}
+}
+
+func TestAtBlock(t *testing.T) {
+
+	client := newClient(t, 20)
+	cache := rocketpool.NewMemoryCache()
+	rp, err := rocketpool.NewRocketPoolWithCache(client, fakeclient.StorageAddress, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := rp.AtBlock(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockNumber, blockHash, pinned := view.GetPinnedBlock()
+	if !pinned || blockNumber != 10 || blockHash != client.GetHeader(10).Hash() {
+		t.Fatalf("Incorrect pinned block %d / %s", blockNumber, blockHash.Hex())
+	}
+	if _, _, pinned := rp.GetPinnedBlock(); pinned {
+		t.Error("Base instance reports a pinned block")
+	}
+
+	// Lookups through the view read at the pinned block
+	address, err := view.GetAddress("rocketNodeManager", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *address != contractAddress {
+		t.Errorf("Incorrect address %s", address.Hex())
+	}
+	if callBlocks := client.GetCallBlocks(); len(callBlocks) != 1 || callBlocks[0] == nil || callBlocks[0].Uint64() != 10 {
+		t.Fatalf("Lookup was not pinned to block 10: %v", callBlocks)
+	}
+
+	// Pinned lookups are cached historically, not as the current value
+	if _, exists, _ := cache.Get(rocketpool.CacheValueType_Address, "rocketNodeManager", nil); exists {
+		t.Error("Pinned lookup was cached as the current value")
+	}
+	block := uint64(10)
+	if _, exists, _ := cache.Get(rocketpool.CacheValueType_Address, "rocketNodeManager", &block); !exists {
+		t.Error("Pinned lookup was not cached at the pinned block")
+	}
+
+	// Views are read-only
+	if err := view.Client.SendTransaction(context.Background(), &types.Transaction{}); !errors.Is(err, rocketpool.ErrReadOnlyView) {
+		t.Errorf("Expected a read-only error, got %v", err)
+	}
+
+	// Reads by number check that the pinned block is still canonical
+	headerQueries := client.GetHeaderQueries()
+	if _, err := view.Client.CallContract(context.Background(), getAddressCall(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	if client.GetHeaderQueries() != headerQueries+1 {
+		t.Errorf("Pinned read made %d header requests", client.GetHeaderQueries()-headerQueries)
+	}
+
+	// Reads fail once the pinned block is reorged, without the caller checking it
+	client.Reorg(10)
+	if _, err := view.Client.CallContract(context.Background(), getAddressCall(t), nil); !errors.Is(err, rocketpool.ErrPinnedBlockReorged) {
+		t.Errorf("Expected a reorg error, got %v", err)
+	}
+	if _, err := view.GetAddress("rocketDepositPool", nil); !errors.Is(err, rocketpool.ErrPinnedBlockReorged) {
+		t.Errorf("Expected a reorg error for a lookup, got %v", err)
+	}
+	if err := view.CheckPinnedBlock(); !errors.Is(err, rocketpool.ErrPinnedBlockReorged) {
+		t.Errorf("Expected a reorg error, got %v", err)
+	}
+
+}
+
+func TestAtBlockWithHashReads(t *testing.T) {
+
+	client := &fakeclient.HashClient{Client: newClient(t, 20)}
+	rp := fakeclient.NewRocketPool(t, client)
+	view, err := rp.AtBlock(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pinned reads select the block by hash in a single request
+	headerQueries := client.GetHeaderQueries()
+	address, err := view.GetAddress("rocketNodeManager", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *address != contractAddress {
+		t.Errorf("Incorrect address %s", address.Hex())
+	}
+	if callHashes := client.GetCallHashes(); len(callHashes) != 1 || callHashes[0] != client.GetHeader(10).Hash() || len(client.GetCallBlocks()) != 0 {
+		t.Errorf("Lookup was not pinned to block 10's hash: %v", callHashes)
+	}
+	if client.GetHeaderQueries() != headerQueries {
+		t.Errorf("Pinned read made %d header requests", client.GetHeaderQueries()-headerQueries)
+	}
+
+	// Reads fail once the pinned block is reorged
+	client.Reorg(10)
+	if _, err := view.Client.CallContract(context.Background(), getAddressCall(t), nil); !errors.Is(err, rocketpool.ErrPinnedBlockReorged) {
+		t.Errorf("Expected a reorg error, got %v", err)
+	}
+
+}
+
+func TestAtBlockHash(t *testing.T) {
+
+	client := newClient(t, 20)
+	rp := fakeclient.NewRocketPool(t, client)
+
+	// Pin by hash
+	hash := client.GetHeader(5).Hash()
+	view, err := rp.AtBlockHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockNumber, _, _ := view.GetPinnedBlock(); blockNumber != 5 {
+		t.Errorf("Incorrect pinned block %d", blockNumber)
+	}
+	if latest, err := view.Client.BlockNumber(context.Background()); err != nil || latest != 5 {
+		t.Errorf("Incorrect latest block %d for view", latest)
+	}
+
+	// Hashes that are no longer canonical can't be pinned
+	client.Reorg(5)
+	if _, err := rp.AtBlockHash(hash); !errors.Is(err, rocketpool.ErrPinnedBlockReorged) {
+		t.Errorf("Expected a reorg error, got %v", err)
+	}
+
+}
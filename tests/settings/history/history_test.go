@@ -0,0 +1,114 @@
+package history
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/settings"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+)
+
+// Create a value for a registered setting
+func getValue(t *testing.T, contractName string, path string, value interface{}) settings.SettingValue {
+	setting, exists := settings.GetSettingDefinition(contractName, path)
+	if !exists {
+		t.Fatalf("Setting %s is not registered", path)
+	}
+	settingValue := settings.SettingValue{Setting: setting, Available: true}
+	switch v := value.(type) {
+	case bool:
+		settingValue.Bool = v
+	case int64:
+		settingValue.Uint = big.NewInt(v)
+	case nil:
+		settingValue.Available = false
+	}
+	return settingValue
+}
+
+func TestDiffSettings(t *testing.T) {
+
+	oldValues := []settings.SettingValue{
+		getValue(t, psettings.DepositSettingsContractName, psettings.DepositEnabledSettingPath, true),
+		getValue(t, psettings.DepositSettingsContractName, psettings.MinimumDepositSettingPath, int64(100)),
+		getValue(t, tnsettings.MinipoolSettingsContractName, tnsettings.ScrubPeriodPath, int64(43200)),
+		getValue(t, psettings.ProposalsSettingsContractName, psettings.ProposalBondSettingPath, nil),
+		getValue(t, psettings.NodeSettingsContractName, psettings.NodeDepositEnabledSettingPath, true),
+	}
+	newValues := []settings.SettingValue{
+		getValue(t, psettings.DepositSettingsContractName, psettings.DepositEnabledSettingPath, false),
+		getValue(t, psettings.DepositSettingsContractName, psettings.MinimumDepositSettingPath, int64(100)),
+		getValue(t, tnsettings.MinipoolSettingsContractName, tnsettings.ScrubPeriodPath, int64(3600)),
+		getValue(t, psettings.ProposalsSettingsContractName, psettings.ProposalBondSettingPath, int64(5)),
+	}
+	changes := settings.DiffSettings(oldValues, newValues)
+	if len(changes) != 4 {
+		t.Fatalf("Incorrect change count %d: %+v", len(changes), changes)
+	}
+	if changes[0].Setting.Path != psettings.DepositEnabledSettingPath || !changes[0].Old.Bool || changes[0].New.Bool {
+		t.Errorf("Incorrect flag change %+v", changes[0])
+	}
+	if changes[1].Setting.Path != tnsettings.ScrubPeriodPath || changes[1].Old.Uint.Int64() != 43200 || changes[1].New.Uint.Int64() != 3600 {
+		t.Errorf("Incorrect uint change %+v", changes[1])
+	}
+	if changes[2].Setting.Path != psettings.ProposalBondSettingPath || changes[2].Old.Available || !changes[2].New.Available {
+		t.Errorf("Incorrect new setting change %+v", changes[2])
+	}
+	if changes[3].Setting.Path != psettings.NodeDepositEnabledSettingPath || changes[3].New.Available {
+		t.Errorf("Incorrect removed setting change %+v", changes[3])
+	}
+
+	// Identical snapshots have no changes
+	if changes := settings.DiffSettings(oldValues, oldValues); len(changes) != 0 {
+		t.Errorf("Found changes between identical snapshots: %+v", changes)
+	}
+
+}
+
+const depositSettingsAbi = `[{"type":"function","name":"getDepositEnabled","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]}]`
+
+var depositSettingsAddress = common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+// Create a fake client for a network where only an older deposit settings contract is deployed
+func newClient(t *testing.T) *fakeclient.Client {
+	client := fakeclient.New(t)
+	client.LatestBlock = 100
+	client.SetContract(psettings.DepositSettingsContractName, depositSettingsAddress, depositSettingsAbi)
+	client.SetCallHandler(depositSettingsAddress, depositSettingsAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+		return []interface{}{true}, nil
+	})
+	return client
+}
+
+func TestUnavailableSettings(t *testing.T) {
+
+	rp := fakeclient.NewRocketPool(t, newClient(t))
+
+	// Settings of deployed getters are read
+	value, err := settings.GetSetting(rp, psettings.DepositSettingsContractName, psettings.DepositEnabledSettingPath, nil)
+	if err != nil || !value.Available || !value.Bool {
+		t.Errorf("Incorrect deployed setting %+v: %v", value, err)
+	}
+
+	// Getters missing from the deployed ABI are unavailable
+	value, err = settings.GetSetting(rp, psettings.DepositSettingsContractName, psettings.DepositFeeSettingPath, nil)
+	if err != nil || value.Available {
+		t.Errorf("Incorrect missing getter %+v: %v", value, err)
+	}
+
+	// Contracts that aren't deployed yet are unavailable
+	value, err = settings.GetSetting(rp, psettings.SecuritySettingsContractName, psettings.SecurityMembersQuorumSettingPath, nil)
+	if err != nil || value.Available || value.Setting.Path != psettings.SecurityMembersQuorumSettingPath {
+		t.Errorf("Incorrect undeployed setting %+v: %v", value, err)
+	}
+	updates, err := settings.GetSettingHistory(rp, psettings.SecuritySettingsContractName, psettings.SecurityMembersQuorumSettingPath, big.NewInt(0), big.NewInt(100), nil, nil)
+	if err != nil || len(updates) != 0 {
+		t.Errorf("Incorrect undeployed setting history %+v: %v", updates, err)
+	}
+
+}
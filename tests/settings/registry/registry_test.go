@@ -0,0 +1,216 @@
+package registry
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/settings"
+	psettings "github.com/rocket-pool/rocketpool-go/settings/protocol"
+	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestRegistry(t *testing.T) {
+
+	// Every setting is registered once, with a getter
+	seen := map[string]bool{}
+	securityCount := 0
+	for _, setting := range settings.GetSettings() {
+		key := setting.ContractName + "/" + setting.Path
+		if seen[key] {
+			t.Errorf("Setting %s is registered more than once", key)
+		}
+		seen[key] = true
+		if setting.Getter == "" || setting.Path == "" || setting.ContractName == "" {
+			t.Errorf("Setting %s is incomplete", key)
+		}
+		if setting.SecurityCouncil {
+			securityCount++
+			if setting.Dao != settings.SettingDao_Protocol || setting.Type != types.ProposalSettingType_Bool {
+				t.Errorf("Security council setting %s isn't a Protocol DAO flag", key)
+			}
+		}
+	}
+	if securityCount != 13 {
+		t.Errorf("Incorrect security council setting count %d", securityCount)
+	}
+
+	// Paths are shared between contracts, so lookups need both
+	quorum, exists := settings.GetSettingDefinition(tnsettings.MembersSettingsContractName, tnsettings.QuorumSettingPath)
+	if !exists || quorum.Dao != settings.SettingDao_OracleDao || quorum.Getter != "getQuorum" || quorum.Unit != settings.SettingUnit_Percentage {
+		t.Errorf("Incorrect oDAO quorum setting %+v", quorum)
+	}
+	securityQuorum, exists := settings.GetSettingDefinition(psettings.SecuritySettingsContractName, psettings.SecurityMembersQuorumSettingPath)
+	if !exists || securityQuorum.Dao != settings.SettingDao_Protocol {
+		t.Errorf("Incorrect security quorum setting %+v", securityQuorum)
+	}
+	if _, exists := settings.GetSettingDefinition(psettings.NodeSettingsContractName, tnsettings.QuorumSettingPath); exists {
+		t.Error("Found a setting on the wrong contract")
+	}
+
+	// The claim interval is stored as a number of inflation intervals, not a duration
+	claimPeriods, exists := settings.GetSettingDefinition(psettings.RewardsSettingsContractName, psettings.RewardsClaimIntervalPeriodsSettingPath)
+	if !exists || claimPeriods.Getter != "getRewardsClaimIntervalPeriods" || claimPeriods.Unit != settings.SettingUnit_Count {
+		t.Errorf("Incorrect claim periods setting %+v", claimPeriods)
+	}
+
+	// The security council can pause price submissions
+	if _, exists := settings.GetSecurityCouncilSettingDefinition("network", psettings.SubmitPricesEnabledSettingPath); !exists {
+		t.Error("Price submissions can't be changed by the security council")
+	}
+
+}
+
+func TestCheckSettingValue(t *testing.T) {
+
+	fee, _ := settings.GetSettingDefinition(psettings.DepositSettingsContractName, psettings.DepositFeeSettingPath)
+	enabled, _ := settings.GetSettingDefinition(psettings.DepositSettingsContractName, psettings.DepositEnabledSettingPath)
+	maxStake, _ := settings.GetSettingDefinition(psettings.NodeSettingsContractName, psettings.MaximumPerMinipoolStakeSettingPath)
+
+	cases := []struct {
+		name    string
+		setting settings.Setting
+		value   interface{}
+		valid   bool
+	}{
+		{"percentage", fee, eth.EthToWei(0.005), true},
+		{"percentage over 100%", fee, eth.EthToWei(1.01), false},
+		{"negative", fee, big.NewInt(-1), false},
+		{"nil uint", fee, (*big.Int)(nil), false},
+		{"wrong type", fee, true, false},
+		{"ratio over 100%", maxStake, eth.EthToWei(1.5), true},
+		{"bool", enabled, false, true},
+		{"address for bool", enabled, common.Address{}, false},
+	}
+	for _, c := range cases {
+		if err := settings.CheckSettingValue(c.setting, c.value); (err == nil) != c.valid {
+			t.Errorf("%s: incorrect validation result %v", c.name, err)
+		}
+	}
+
+}
+
+func TestSettingPaths(t *testing.T) {
+
+	// The on-chain key of every registered setting, by contract and getter
+	keys := map[string]string{
+		"rocketDAOProtocolSettingsAuction/getCreateLotEnabled":                    "auction.lot.create.enabled",
+		"rocketDAOProtocolSettingsAuction/getBidOnLotEnabled":                     "auction.lot.bidding.enabled",
+		"rocketDAOProtocolSettingsAuction/getLotMinimumEthValue":                  "auction.lot.value.minimum",
+		"rocketDAOProtocolSettingsAuction/getLotMaximumEthValue":                  "auction.lot.value.maximum",
+		"rocketDAOProtocolSettingsAuction/getLotDuration":                         "auction.lot.duration",
+		"rocketDAOProtocolSettingsAuction/getStartingPriceRatio":                  "auction.price.start",
+		"rocketDAOProtocolSettingsAuction/getReservePriceRatio":                   "auction.price.reserve",
+		"rocketDAOProtocolSettingsDeposit/getDepositEnabled":                      "deposit.enabled",
+		"rocketDAOProtocolSettingsDeposit/getAssignDepositsEnabled":               "deposit.assign.enabled",
+		"rocketDAOProtocolSettingsDeposit/getMinimumDeposit":                      "deposit.minimum",
+		"rocketDAOProtocolSettingsDeposit/getMaximumDepositPoolSize":              "deposit.pool.maximum",
+		"rocketDAOProtocolSettingsDeposit/getMaximumDepositAssignments":           "deposit.assign.maximum",
+		"rocketDAOProtocolSettingsDeposit/getMaximumDepositSocialisedAssignments": "deposit.assign.socialised.maximum",
+		"rocketDAOProtocolSettingsDeposit/getDepositFee":                          "deposit.fee",
+		"rocketDAOProtocolSettingsInflation/getInflationIntervalRate":             "rpl.inflation.interval.rate",
+		"rocketDAOProtocolSettingsInflation/getInflationIntervalStartTime":        "rpl.inflation.interval.start",
+		"rocketDAOProtocolSettingsMinipool/getSubmitWithdrawableEnabled":          "minipool.submit.withdrawable.enabled",
+		"rocketDAOProtocolSettingsMinipool/getLaunchTimeout":                      "minipool.launch.timeout",
+		"rocketDAOProtocolSettingsMinipool/getBondReductionEnabled":               "minipool.bond.reduction.enabled",
+		"rocketDAOProtocolSettingsMinipool/getMaximumCount":                       "minipool.maximum.count",
+		"rocketDAOProtocolSettingsMinipool/getUserDistributeWindowStart":          "minipool.user.distribute.window.start",
+		"rocketDAOProtocolSettingsMinipool/getUserDistributeWindowLength":         "minipool.user.distribute.window.length",
+		"rocketDAOProtocolSettingsNetwork/getNodeConsensusThreshold":              "network.consensus.threshold",
+		"rocketDAOProtocolSettingsNetwork/getSubmitBalancesEnabled":               "network.submit.balances.enabled",
+		"rocketDAOProtocolSettingsNetwork/getSubmitBalancesFrequency":             "network.submit.balances.frequency",
+		"rocketDAOProtocolSettingsNetwork/getSubmitPricesEnabled":                 "network.submit.prices.enabled",
+		"rocketDAOProtocolSettingsNetwork/getSubmitPricesFrequency":               "network.submit.prices.frequency",
+		"rocketDAOProtocolSettingsNetwork/getMinimumNodeFee":                      "network.node.fee.minimum",
+		"rocketDAOProtocolSettingsNetwork/getTargetNodeFee":                       "network.node.fee.target",
+		"rocketDAOProtocolSettingsNetwork/getMaximumNodeFee":                      "network.node.fee.maximum",
+		"rocketDAOProtocolSettingsNetwork/getNodeFeeDemandRange":                  "network.node.fee.demand.range",
+		"rocketDAOProtocolSettingsNetwork/getTargetRethCollateralRate":            "network.reth.collateral.target",
+		"rocketDAOProtocolSettingsNetwork/getNodePenaltyThreshold":                "network.penalty.threshold",
+		"rocketDAOProtocolSettingsNetwork/getPerPenaltyRate":                      "network.penalty.per.rate",
+		"rocketDAOProtocolSettingsNetwork/getSubmitRewardsEnabled":                "network.submit.rewards.enabled",
+		"rocketDAOProtocolSettingsNode/getRegistrationEnabled":                    "node.registration.enabled",
+		"rocketDAOProtocolSettingsNode/getSmoothingPoolRegistrationEnabled":       "node.smoothing.pool.registration.enabled",
+		"rocketDAOProtocolSettingsNode/getDepositEnabled":                         "node.deposit.enabled",
+		"rocketDAOProtocolSettingsNode/getVacantMinipoolsEnabled":                 "node.vacant.minipools.enabled",
+		"rocketDAOProtocolSettingsNode/getMinimumPerMinipoolStake":                "node.per.minipool.stake.minimum",
+		"rocketDAOProtocolSettingsNode/getMaximumPerMinipoolStake":                "node.per.minipool.stake.maximum",
+		"rocketDAOProtocolSettingsNode/getMaximumStakeForVotingPower":             "node.voting.power.stake.maximum",
+		"rocketDAOProtocolSettingsProposals/getVotePhase1Time":                    "proposal.vote.phase1.time",
+		"rocketDAOProtocolSettingsProposals/getVotePhase2Time":                    "proposal.vote.phase2.time",
+		"rocketDAOProtocolSettingsProposals/getVoteDelayTime":                     "proposal.vote.delay.time",
+		"rocketDAOProtocolSettingsProposals/getExecuteTime":                       "proposal.execute.time",
+		"rocketDAOProtocolSettingsProposals/getProposalBond":                      "proposal.bond",
+		"rocketDAOProtocolSettingsProposals/getChallengeBond":                     "proposal.challenge.bond",
+		"rocketDAOProtocolSettingsProposals/getChallengePeriod":                   "proposal.challenge.period",
+		"rocketDAOProtocolSettingsProposals/getProposalQuorum":                    "proposal.quorum",
+		"rocketDAOProtocolSettingsProposals/getProposalVetoQuorum":                "proposal.veto.quorum",
+		"rocketDAOProtocolSettingsProposals/getProposalMaxBlockAge":               "proposal.max.block.age",
+		"rocketDAOProtocolSettingsRewards/getRewardsClaimIntervalPeriods":         "rewards.claimsperiods",
+		"rocketDAOProtocolSettingsSecurity/getQuorum":                             "members.quorum",
+		"rocketDAOProtocolSettingsSecurity/getLeaveTime":                          "members.leave.time",
+		"rocketDAOProtocolSettingsSecurity/getVoteTime":                           "proposal.vote.time",
+		"rocketDAOProtocolSettingsSecurity/getExecuteTime":                        "proposal.execute.time",
+		"rocketDAOProtocolSettingsSecurity/getActionTime":                         "proposal.action.time",
+		"rocketDAONodeTrustedSettingsMembers/getQuorum":                           "members.quorum",
+		"rocketDAONodeTrustedSettingsMembers/getRPLBond":                          "members.rplbond",
+		"rocketDAONodeTrustedSettingsMembers/getMinipoolUnbondedMax":              "members.minipool.unbonded.max",
+		"rocketDAONodeTrustedSettingsMembers/getMinipoolUnbondedMinFee":           "members.minipool.unbonded.min.fee",
+		"rocketDAONodeTrustedSettingsMembers/getChallengeCooldown":                "members.challenge.cooldown",
+		"rocketDAONodeTrustedSettingsMembers/getChallengeWindow":                  "members.challenge.window",
+		"rocketDAONodeTrustedSettingsMembers/getChallengeCost":                    "members.challenge.cost",
+		"rocketDAONodeTrustedSettingsMinipool/getScrubPeriod":                     "minipool.scrub.period",
+		"rocketDAONodeTrustedSettingsMinipool/getPromotionScrubPeriod":            "minipool.promotion.scrub.period",
+		"rocketDAONodeTrustedSettingsMinipool/getScrubPenaltyEnabled":             "minipool.scrub.penalty.enabled",
+		"rocketDAONodeTrustedSettingsMinipool/getBondReductionWindowStart":        "minipool.bond.reduction.window.start",
+		"rocketDAONodeTrustedSettingsMinipool/getBondReductionWindowLength":       "minipool.bond.reduction.window.length",
+		"rocketDAONodeTrustedSettingsProposals/getCooldownTime":                   "proposal.cooldown.time",
+		"rocketDAONodeTrustedSettingsProposals/getVoteTime":                       "proposal.vote.time",
+		"rocketDAONodeTrustedSettingsProposals/getVoteDelayTime":                  "proposal.vote.delay.time",
+		"rocketDAONodeTrustedSettingsProposals/getExecuteTime":                    "proposal.execute.time",
+		"rocketDAONodeTrustedSettingsProposals/getActionTime":                     "proposal.action.time",
+	}
+	registered := settings.GetSettings()
+	if len(registered) != len(keys) {
+		t.Errorf("Registry has %d settings but %d on-chain keys are pinned", len(registered), len(keys))
+	}
+	for _, setting := range registered {
+		key, exists := keys[setting.ContractName+"/"+setting.Getter]
+		if !exists {
+			t.Errorf("Setting %s/%s has no pinned on-chain key", setting.ContractName, setting.Getter)
+		} else if setting.Path != key {
+			t.Errorf("Setting %s/%s has path %s instead of %s", setting.ContractName, setting.Getter, setting.Path, key)
+		}
+	}
+
+}
+
+func TestSettingGuardrails(t *testing.T) {
+
+	inflation, _ := settings.GetSettingDefinition(psettings.InflationSettingsContractName, psettings.InflationRateSettingPath)
+	phase1, _ := settings.GetSettingDefinition(psettings.ProposalsSettingsContractName, psettings.VotePhase1TimeSettingPath)
+	leaveTime, _ := settings.GetSettingDefinition(psettings.SecuritySettingsContractName, psettings.SecurityMembersLeaveTimeSettingPath)
+
+	cases := []struct {
+		name    string
+		setting settings.Setting
+		value   *big.Int
+		valid   bool
+	}{
+		{"inflation at 5% per year", inflation, big.NewInt(1000133680617113500), true},
+		{"inflation over 5% per year", inflation, big.NewInt(1000133680617113501), false},
+		{"vote phase of a week", phase1, big.NewInt(7 * 24 * 60 * 60), true},
+		{"vote phase under a week", phase1, big.NewInt(7*24*60*60 - 1), false},
+		{"leave time under 14 days", leaveTime, big.NewInt(13 * 24 * 60 * 60), true},
+		{"leave time of 14 days", leaveTime, big.NewInt(14 * 24 * 60 * 60), false},
+	}
+	for _, c := range cases {
+		if err := settings.CheckSettingValue(c.setting, c.value); (err == nil) != c.valid {
+			t.Errorf("%s: incorrect validation result %v", c.name, err)
+		}
+	}
+
+}
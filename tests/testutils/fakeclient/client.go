@@ -0,0 +1,381 @@
+package fakeclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/contracts"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// The address of the fake RocketStorage contract
+var StorageAddress = common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+// Answers a call to a fake contract with the method's outputs
+type CallHandler func(method *abi.Method, args []interface{}) ([]interface{}, error)
+
+// A contract with a call handler
+type fakeContract struct {
+	abi     abi.ABI
+	handler CallHandler
+}
+
+// An in-memory execution client for offline tests.
+// It serves RocketStorage contract addresses, ABIs and uints, contracts with call handlers, a list of logs, and a canonical chain of
+// headers up to LatestBlock that can be reorged. Every method fails with the context's error once it is cancelled.
+type Client struct {
+	rocketpool.ExecutionClient
+
+	LatestBlock uint64
+	Logs        []types.Log
+
+	lock          sync.Mutex
+	t             *testing.T
+	storageAbi    abi.ABI
+	addresses     map[common.Hash]common.Address
+	strings       map[common.Hash]string
+	uints         map[common.Hash]*big.Int
+	contracts     map[common.Address]fakeContract
+	headers       map[uint64]*types.Header
+	orphans       []*types.Header
+	reorgs        int
+	calls         []ethereum.CallMsg
+	callBlocks    []*big.Int
+	callHashes    []common.Hash
+	headerQueries int
+	filterQueries []ethereum.FilterQuery
+}
+
+// A fake client that also reads at canonical block hashes with EIP-1898
+type HashClient struct {
+	*Client
+}
+
+// Create a fake client with no contracts
+func New(t *testing.T) *Client {
+	storageAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{
+		t:          t,
+		storageAbi: storageAbi,
+		addresses:  map[common.Hash]common.Address{},
+		strings:    map[common.Hash]string{},
+		uints:      map[common.Hash]*big.Int{},
+		contracts:  map[common.Address]fakeContract{},
+		headers:    map[uint64]*types.Header{},
+	}
+}
+
+// Create a fake client that supports EIP-1898 reads
+func NewHashClient(t *testing.T) *HashClient {
+	return &HashClient{Client: New(t)}
+}
+
+// Create a Rocket Pool instance for a fake client
+func NewRocketPool(t *testing.T, client rocketpool.ExecutionClient) *rocketpool.RocketPool {
+	rp, err := rocketpool.NewRocketPool(client, StorageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// Set the address and ABI RocketStorage holds for a contract
+func (c *Client) SetContract(contractName string, address common.Address, abiJson string) {
+	abiEncoded, err := rocketpool.EncodeAbiStr(abiJson)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.addresses[crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName))] = address
+	c.strings[crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName))] = abiEncoded
+}
+
+// Set a uint RocketStorage holds
+func (c *Client) SetUint(key common.Hash, value *big.Int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.uints[key] = value
+}
+
+// Answer calls to an address with a handler
+func (c *Client) SetCallHandler(address common.Address, abiJson string, handler CallHandler) {
+	parsed, err := abi.JSON(strings.NewReader(abiJson))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.contracts[address] = fakeContract{abi: parsed, handler: handler}
+}
+
+// Get the canonical header at a height
+func (c *Client) GetHeader(blockNumber uint64) *types.Header {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.getHeader(blockNumber)
+}
+
+// Replace the header at a height to simulate a reorg
+func (c *Client) Reorg(blockNumber uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.orphans = append(c.orphans, c.getHeader(blockNumber))
+	c.reorgs++
+	c.headers[blockNumber] = &types.Header{Number: big.NewInt(0).SetUint64(blockNumber), Extra: []byte(fmt.Sprintf("reorg %d", c.reorgs))}
+}
+
+// Get the calls made so far
+func (c *Client) GetCalls() []ethereum.CallMsg {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]ethereum.CallMsg{}, c.calls...)
+}
+
+// Get the block numbers of the calls made so far
+func (c *Client) GetCallBlocks() []*big.Int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*big.Int{}, c.callBlocks...)
+}
+
+// Get the block hashes of the EIP-1898 calls made so far
+func (c *Client) GetCallHashes() []common.Hash {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]common.Hash{}, c.callHashes...)
+}
+
+// Get the number of header requests made so far
+func (c *Client) GetHeaderQueries() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.headerQueries
+}
+
+// Get the log queries made so far
+func (c *Client) GetFilterQueries() []ethereum.FilterQuery {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]ethereum.FilterQuery{}, c.filterQueries...)
+}
+
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.LatestBlock, nil
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.headerQueries++
+	if number == nil {
+		return c.getHeader(c.LatestBlock), nil
+	}
+	if number.Uint64() > c.LatestBlock {
+		return nil, ethereum.NotFound
+	}
+	return c.getHeader(number.Uint64()), nil
+}
+
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.headerQueries++
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header, nil
+		}
+	}
+	for _, header := range c.orphans {
+		if header.Hash() == hash {
+			return header, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *Client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, call)
+	c.callBlocks = append(c.callBlocks, blockNumber)
+	return c.call(call)
+}
+
+func (c *Client) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.filterQueries = append(c.filterQueries, query)
+	logs := []types.Log{}
+	for _, log := range c.Logs {
+		if matchesQuery(log, query) {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+// Execute a contract call at the given block, which must be canonical
+func (c *HashClient) CallContractAtCanonicalHash(ctx context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, call)
+	c.callHashes = append(c.callHashes, blockHash)
+	if !c.isCanonical(blockHash) {
+		return nil, errors.New("header for hash not found or not canonical")
+	}
+	return c.call(call)
+}
+
+func (c *HashClient) CodeAtCanonicalHash(ctx context.Context, contract common.Address, blockHash common.Hash) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *HashClient) BalanceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *HashClient) NonceAtCanonicalHash(ctx context.Context, account common.Address, blockHash common.Hash) (uint64, error) {
+	return 0, errors.New("not implemented")
+}
+
+// Get the canonical header at a height, creating it if it hasn't been requested yet
+func (c *Client) getHeader(blockNumber uint64) *types.Header {
+	header, exists := c.headers[blockNumber]
+	if !exists {
+		header = &types.Header{Number: big.NewInt(0).SetUint64(blockNumber), Extra: []byte("canonical")}
+		c.headers[blockNumber] = header
+	}
+	return header
+}
+
+// Check if a block hash is part of the canonical chain
+func (c *Client) isCanonical(blockHash common.Hash) bool {
+	for _, header := range c.headers {
+		if header.Hash() == blockHash {
+			return true
+		}
+	}
+	return false
+}
+
+// Answer a call with RocketStorage or a contract's handler
+func (c *Client) call(call ethereum.CallMsg) ([]byte, error) {
+	if call.To == nil {
+		return nil, errors.New("contract creation is not supported")
+	}
+	if *call.To == StorageAddress {
+		method, err := c.storageAbi.MethodById(call.Data)
+		if err != nil {
+			return nil, err
+		}
+		var key common.Hash
+		copy(key[:], call.Data[4:36])
+		switch method.Name {
+		case "getString":
+			return method.Outputs.Pack(c.strings[key])
+		case "getAddress":
+			return method.Outputs.Pack(c.addresses[key])
+		case "getUint":
+			value, exists := c.uints[key]
+			if !exists {
+				value = big.NewInt(0)
+			}
+			return method.Outputs.Pack(value)
+		}
+		return nil, fmt.Errorf("RocketStorage method %s is not supported", method.Name)
+	}
+	contract, exists := c.contracts[*call.To]
+	if !exists {
+		return nil, fmt.Errorf("no contract at %s", call.To.Hex())
+	}
+	method, err := contract.abi.MethodById(call.Data)
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := contract.handler(method, args)
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack(outputs...)
+}
+
+// Check if a log matches a query's block range, addresses and topics
+func matchesQuery(log types.Log, query ethereum.FilterQuery) bool {
+	if query.BlockHash != nil && log.BlockHash != *query.BlockHash {
+		return false
+	}
+	if query.FromBlock != nil && log.BlockNumber < query.FromBlock.Uint64() {
+		return false
+	}
+	if query.ToBlock != nil && log.BlockNumber > query.ToBlock.Uint64() {
+		return false
+	}
+	if len(query.Addresses) > 0 {
+		found := false
+		for _, address := range query.Addresses {
+			if log.Address == address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, topics := range query.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		found := false
+		for _, topic := range topics {
+			if log.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package beacon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/beacon"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+var (
+	_ rocketpool.BeaconClient = (*beacon.HttpClient)(nil)
+	_ rocketpool.BeaconClient = (*beacon.FakeClient)(nil)
+)
+
+// Get a test pubkey
+func getPubkey(i int) types.ValidatorPubkey {
+	var pubkey types.ValidatorPubkey
+	pubkey[0] = 0xaa
+	pubkey[46] = byte(i >> 8)
+	pubkey[47] = byte(i)
+	return pubkey
+}
+
+// Serve a Beacon API where every even-numbered test validator exists
+func newBeaconServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		switch {
+		case r.URL.Path == "/eth/v1/beacon/genesis":
+			fmt.Fprint(w, `{"data":{"genesis_time":"1606824023","genesis_validators_root":"0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95","genesis_fork_version":"0x00000000"}}`)
+
+		case r.URL.Path == "/eth/v2/beacon/blocks/100":
+			fmt.Fprint(w, `{"version":"capella","data":{"message":{"slot":"100","proposer_index":"7","body":{"execution_payload":{"block_number":"17000000","block_hash":"0x0000000000000000000000000000000000000000000000000000000000000001"}}}}}`)
+
+		case r.URL.Path == "/eth/v1/beacon/states/head/validators":
+			data := []string{}
+			for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+				pubkey, err := types.HexToValidatorPubkey(strings.TrimPrefix(id, "0x"))
+				if err != nil {
+					t.Error(err)
+				}
+				index := int(pubkey[46])<<8 | int(pubkey[47])
+				if index%2 != 0 {
+					continue
+				}
+				data = append(data, fmt.Sprintf(`{"index":"%d","balance":"32000000001","status":"active_ongoing","validator":{"pubkey":"%s","withdrawal_credentials":"0x0100000000000000000000001000000000000000000000000000000000000001","effective_balance":"32000000000","slashed":false,"activation_eligibility_epoch":"1","activation_epoch":"2","exit_epoch":"18446744073709551615","withdrawable_epoch":"18446744073709551615"}}`, index, id))
+			}
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+
+		default:
+			http.Error(w, `{"code":404,"message":"not found"}`, http.StatusNotFound)
+		}
+	}))
+}
+
+func TestHttpClient(t *testing.T) {
+
+	requests := 0
+	server := newBeaconServer(t, &requests)
+	defer server.Close()
+	client := beacon.NewHttpClient(server.URL+"/", 0)
+	ctx := context.Background()
+
+	// Genesis
+	genesis, err := client.GetGenesis(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesis.GenesisTime.Unix() != 1606824023 || len(genesis.GenesisForkVersion) != 4 {
+		t.Errorf("Incorrect genesis %+v", genesis)
+	}
+
+	// Blocks
+	block, exists, err := client.GetBeaconBlock(ctx, "100")
+	if err != nil || !exists {
+		t.Fatalf("Block was not found: %v", err)
+	}
+	if block.Slot != 100 || block.ProposerIndex != 7 || !block.HasExecutionPayload || block.ExecutionBlockNumber != 17000000 {
+		t.Errorf("Incorrect block %+v", block)
+	}
+	if _, exists, err := client.GetBeaconBlock(ctx, "101"); err != nil || exists {
+		t.Errorf("Missed slot was not reported as missing: %v", err)
+	}
+
+	// Validators are queried in batches and missing ones are omitted
+	pubkeys := make([]types.ValidatorPubkey, beacon.ValidatorStatusBatchSize+10)
+	for i := range pubkeys {
+		pubkeys[i] = getPubkey(i)
+	}
+	requests = 0
+	statuses, err := client.GetValidatorStatuses(ctx, pubkeys, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(statuses) != len(pubkeys)/2 {
+		t.Errorf("Got %d statuses in %d requests", len(statuses), requests)
+	}
+	status := statuses[getPubkey(4)]
+	if status.Index != 4 || status.Balance != 32000000001 || status.State != types.ValidatorState_ActiveOngoing || !status.State.IsActive() || status.ExitEpoch != ^uint64(0) {
+		t.Errorf("Incorrect validator status %+v", status)
+	}
+	if _, err := client.GetValidatorStatuses(ctx, pubkeys[:1], "finalized"); err == nil {
+		t.Error("Missing state did not return an error")
+	}
+
+}
+
+func TestGetValidators(t *testing.T) {
+
+	client := beacon.NewFakeClient(types.BeaconGenesis{})
+	client.SetValidator(types.ValidatorStatus{
+		Pubkey:                getPubkey(1),
+		Index:                 10,
+		Balance:               31500000000,
+		State:                 types.ValidatorState_ExitedUnslashed,
+		WithdrawalCredentials: common.HexToHash("0x01"),
+	})
+	client.SetBlock(types.BeaconBlock{Slot: 5}, "head")
+	if block, exists, _ := client.GetBeaconBlock(context.Background(), "head"); !exists || block.Slot != 5 {
+		t.Error("Incorrect head block")
+	}
+
+	// Validators are joined in pubkey order
+	pubkeys := []types.ValidatorPubkey{getPubkey(2), getPubkey(1)}
+	validators, err := beacon.GetValidators(context.Background(), client, pubkeys, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validators[0].Exists || validators[0].Balance.Sign() != 0 {
+		t.Errorf("Incorrect missing validator %+v", validators[0])
+	}
+	if !validators[1].Exists || validators[1].Status.Index != 10 || !validators[1].Status.State.IsExited() || validators[1].Balance.Cmp(eth.EthToWei(31.5)) != 0 {
+		t.Errorf("Incorrect validator %+v", validators[1])
+	}
+
+	// Balances are returned in wei
+	balances, err := beacon.GetBalances(context.Background(), client, pubkeys, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[0].Sign() != 0 || balances[1].Cmp(eth.EthToWei(31.5)) != 0 {
+		t.Errorf("Incorrect balances %v", balances)
+	}
+
+}
@@ -0,0 +1,174 @@
+package depositindex
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils"
+)
+
+var (
+	pubkeyA = types.BytesToValidatorPubkey(common.LeftPadBytes([]byte{0x0a}, types.ValidatorPubkeyLength))
+	pubkeyB = types.BytesToValidatorPubkey(common.LeftPadBytes([]byte{0x0b}, types.ValidatorPubkeyLength))
+)
+
+// Create a deposit
+func getDeposit(pubkey types.ValidatorPubkey, block uint64, txIndex uint) utils.DepositData {
+	return utils.DepositData{
+		Pubkey:      pubkey,
+		Amount:      1000000000,
+		BlockNumber: block,
+		TxIndex:     txIndex,
+	}
+}
+
+func TestDepositIndex(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "deposits.jsonl")
+	index, err := utils.OpenDepositIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := index.GetCheckpoint(); exists {
+		t.Error("New index has a checkpoint")
+	}
+
+	// Add deposits
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyA, 10, 1), getDeposit(pubkeyB, 10, 2), getDeposit(pubkeyA, 10, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyA, 25, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 30}); err != nil {
+		t.Fatal(err)
+	}
+	deposits := index.GetDeposits(map[types.ValidatorPubkey]bool{pubkeyA: true})
+	if len(deposits) != 1 || len(deposits[pubkeyA]) != 3 || deposits[pubkeyA][0].TxIndex != 0 || deposits[pubkeyA][2].BlockNumber != 25 {
+		t.Errorf("Incorrect deposits %+v", deposits)
+	}
+
+	// Deposits outside of the new range are rejected
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyA, 30, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 40}); err == nil {
+		t.Error("Appended a deposit from an indexed block")
+	}
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyA, 41, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 40}); err == nil {
+		t.Error("Appended a deposit after the checkpoint")
+	}
+
+	// Roll back a reorg
+	if err := index.Rollback(utils.DepositIndexCheckpoint{BlockNumber: 24, BlockHash: common.HexToHash("0x24")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyB, 26, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 35, BlockHash: common.HexToHash("0x35")}); err != nil {
+		t.Fatal(err)
+	}
+	if index.GetDepositCount() != 4 {
+		t.Errorf("Incorrect deposit count %d after rollback", index.GetDepositCount())
+	}
+	if err := index.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an interrupted update
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"deposit":{"pubkey":"` + pubkeyA.Hex() + `","blockNumber":36}}` + "\n" + `{"checkpo`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	// The index resumes from the last checkpoint
+	index, err = utils.OpenDepositIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	checkpoint, exists := index.GetCheckpoint()
+	if !exists || checkpoint.BlockNumber != 35 || checkpoint.BlockHash != common.HexToHash("0x35") {
+		t.Errorf("Incorrect checkpoint %+v", checkpoint)
+	}
+	deposits = index.GetDeposits(map[types.ValidatorPubkey]bool{pubkeyA: true, pubkeyB: true})
+	if len(deposits[pubkeyA]) != 2 || len(deposits[pubkeyB]) != 2 || deposits[pubkeyB][1].BlockNumber != 26 {
+		t.Errorf("Incorrect reloaded deposits %+v", deposits)
+	}
+	if err := index.Append([]utils.DepositData{getDeposit(pubkeyA, 36, 0)}, utils.DepositIndexCheckpoint{BlockNumber: 36}); err != nil {
+		t.Fatal(err)
+	}
+	if index.GetDepositCount() != 5 {
+		t.Errorf("Incorrect deposit count %d after resuming", index.GetDepositCount())
+	}
+
+}
+
+const casperDepositAbi = `[{"type":"event","name":"DepositEvent","inputs":[{"name":"pubkey","type":"bytes","indexed":false},{"name":"withdrawal_credentials","type":"bytes","indexed":false},{"name":"amount","type":"bytes","indexed":false},{"name":"signature","type":"bytes","indexed":false},{"name":"index","type":"bytes","indexed":false}],"anonymous":false}]`
+
+var casperDepositAddress = common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
+
+// A fake client with no deposits, which reorgs a block when deposits are retrieved (if reorgBlock is set)
+type reorgingClient struct {
+	*fakeclient.Client
+	reorgBlock uint64
+}
+
+func (c *reorgingClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	if c.reorgBlock != 0 {
+		c.Reorg(c.reorgBlock)
+	}
+	return c.Client.FilterLogs(ctx, query)
+}
+
+// Get the block ranges scanned for deposits
+func getScannedRanges(client *fakeclient.Client) [][2]uint64 {
+	ranges := [][2]uint64{}
+	for _, query := range client.GetFilterQueries() {
+		if len(query.Addresses) > 0 && query.Addresses[0] == casperDepositAddress {
+			ranges = append(ranges, [2]uint64{query.FromBlock.Uint64(), query.ToBlock.Uint64()})
+		}
+	}
+	return ranges
+}
+
+func TestDepositIndexUpdate(t *testing.T) {
+
+	client := &reorgingClient{Client: fakeclient.New(t)}
+	client.LatestBlock = 100
+	client.SetContract("casperDeposit", casperDepositAddress, casperDepositAbi)
+	rp := fakeclient.NewRocketPool(t, client)
+	index, err := utils.OpenDepositIndex(filepath.Join(t.TempDir(), "deposits.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	// The index stays behind the head
+	if err := index.Update(rp, big.NewInt(0), big.NewInt(1000), nil); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, exists := index.GetCheckpoint()
+	if !exists || checkpoint.BlockNumber != 100-utils.DepositIndexFollowDistance {
+		t.Fatalf("Incorrect checkpoint %+v", checkpoint)
+	}
+	if ranges := getScannedRanges(client.Client); len(ranges) != 1 || ranges[0] != [2]uint64{0, 100 - utils.DepositIndexFollowDistance} {
+		t.Errorf("Incorrect scanned ranges %v", ranges)
+	}
+
+	// A reorg of the target block during the scan doesn't move the checkpoint
+	client.LatestBlock = 200
+	client.reorgBlock = 200 - utils.DepositIndexFollowDistance
+	if err := index.Update(rp, big.NewInt(0), big.NewInt(1000), nil); err == nil {
+		t.Error("Indexed a block that was reorged during the update")
+	}
+	if reorged, _ := index.GetCheckpoint(); reorged != checkpoint {
+		t.Errorf("Checkpoint moved to %+v during a reorg", reorged)
+	}
+
+}
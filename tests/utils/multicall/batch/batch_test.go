@@ -0,0 +1,471 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+)
+
+const distributorAbi = `[
+	{"type":"function","name":"distribute","inputs":[],"outputs":[],"stateMutability":"nonpayable"},
+	{"type":"function","name":"deposit","inputs":[{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"payable"},
+	{"type":"event","name":"Distributed","inputs":[{"name":"amount","type":"uint256","indexed":false}],"anonymous":false}
+]`
+
+var (
+	multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	safeAddress       = common.HexToAddress("0x5afe000000000000000000000000000000000001")
+	distributorA      = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	distributorB      = common.HexToAddress("0x1000000000000000000000000000000000000002")
+	multiSendAddress  = common.HexToAddress("0x40A2aCCbd92BCA938b02010E17A5b8929b49130D")
+)
+
+// An execution client that answers calls with canned responses per target, and runs MultiSend payloads
+// through a Safe's simulateAndRevert
+type fakeClient struct {
+	rocketpool.ExecutionClient
+	t         *testing.T
+	responses map[common.Address][]byte
+	reverts   map[common.Address][]byte
+	calls     []ethereum.CallMsg
+}
+
+// A revert returned in the JSON-RPC error data
+type revertError struct {
+	data []byte
+}
+
+func (e revertError) Error() string          { return "execution reverted" }
+func (e revertError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+func (c *fakeClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, call)
+	if *call.To == safeAddress {
+		return nil, c.simulateAndRevert(call.Data)
+	}
+	if data, exists := c.reverts[*call.To]; exists {
+		return nil, revertError{data: data}
+	}
+	return c.responses[*call.To], nil
+}
+
+// Run a MultiSend payload, stopping at the first failed call like MultiSend does
+func (c *fakeClient) simulateAndRevert(data []byte) error {
+	safeAbi, _ := abi.JSON(strings.NewReader(multicall.SafeSimulationABI))
+	msAbi, _ := abi.JSON(strings.NewReader(multicall.MultiSendABI))
+	args, err := safeAbi.Methods["simulateAndRevert"].Inputs.Unpack(data[4:])
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if args[0].(common.Address) != multiSendAddress {
+		c.t.Fatalf("Simulated against %s", args[0].(common.Address).Hex())
+	}
+	payload := args[1].([]byte)
+	msArgs, err := msAbi.Methods["multiSend"].Inputs.Unpack(payload[4:])
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	transactions := msArgs[0].([]byte)
+	success := big.NewInt(1)
+	returnData := []byte{}
+	for offset := 0; offset < len(transactions); {
+		target := common.BytesToAddress(transactions[offset+1 : offset+21])
+		length := int(new(big.Int).SetBytes(transactions[offset+53 : offset+85]).Int64())
+		offset += 85 + length
+		if revert, exists := c.reverts[target]; exists {
+			success = big.NewInt(0)
+			returnData = revert
+			break
+		}
+	}
+	result := append(common.LeftPadBytes(success.Bytes(), 32), common.LeftPadBytes(big.NewInt(int64(len(returnData))).Bytes(), 32)...)
+	return revertError{data: append(result, returnData...)}
+}
+
+// Create a contract binding for a distributor address
+func makeDistributor(t *testing.T, client rocketpool.ExecutionClient, address common.Address) *rocketpool.Contract {
+	parsed, err := abi.JSON(strings.NewReader(distributorAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rocketpool.Contract{
+		Address: &address,
+		ABI:     &parsed,
+		Client:  client,
+	}
+}
+
+// Encode an Error(string) revert
+func makeRevert(t *testing.T, reason string) []byte {
+	stringType, _ := abi.NewType("string", "", nil)
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...)
+}
+
+// Create a batch with a successful distribution, a failing distribution and a deposit with value
+func makeBatch(t *testing.T, client rocketpool.ExecutionClient, allowFailure bool) *multicall.Batcher {
+	a := makeDistributor(t, client, distributorA)
+	b := makeDistributor(t, client, distributorB)
+	batcher := multicall.NewBatcher(client)
+	if err := batcher.AddCall(a, nil, allowFailure, "distribute"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batcher.AddCall(b, nil, allowFailure, "distribute"); err != nil {
+		t.Fatal(err)
+	}
+	if err := batcher.AddCall(a, big.NewInt(5), allowFailure, "deposit", big.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	return batcher
+}
+
+// Encode the aggregate3Value results of the batch: the second distribution fails
+func makeAggregate3Response(t *testing.T) []byte {
+	mcAbi, err := abi.JSON(strings.NewReader(multicall.Multicall3ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		Success    bool
+		ReturnData []byte
+	}
+	response, err := mcAbi.Methods["aggregate3Value"].Outputs.Pack([]result{
+		{Success: true, ReturnData: []byte{}},
+		{Success: false, ReturnData: makeRevert(t, "Nothing to distribute")},
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(5).Bytes(), 32)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// Create a Distributed log
+func makeDistributedLog(t *testing.T, address common.Address, amount int64, index uint) *types.Log {
+	parsed, err := abi.JSON(strings.NewReader(distributorAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parsed.Events["Distributed"].Inputs.Pack(big.NewInt(amount))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Log{Address: address, Topics: []common.Hash{parsed.Events["Distributed"].ID}, Data: data, Index: index}
+}
+
+// Get the traced form of a log
+func toCallLog(log *types.Log) multicall.CallLog {
+	return multicall.CallLog{Address: log.Address, Topics: log.Topics, Data: log.Data}
+}
+
+func TestMulticall3Simulation(t *testing.T) {
+
+	mcAbi, err := abi.JSON(strings.NewReader(multicall.Multicall3ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := makeAggregate3Response(t)
+	client := &fakeClient{t: t, responses: map[common.Address][]byte{multicall3Address: response}}
+
+	// Check the batch calldata and value
+	batcher := makeBatch(t, client, true)
+	if batcher.GetTotalValue().Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Incorrect total value %s", batcher.GetTotalValue().String())
+	}
+	data, err := batcher.GetAggregate3ValueData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := mcAbi.Methods["aggregate3Value"].Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []multicall.Call3Value
+	if err := mcAbi.Methods["aggregate3Value"].Inputs.Copy(&calls, args); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || calls[1].Target != distributorB || !calls[1].AllowFailure || calls[2].Value.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Incorrect packed calls %+v", calls)
+	}
+
+	// Simulate and check per-call results
+	results, err := batcher.SimulateMulticall3(multicall3Address, safeAddress, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.calls) != 1 || client.calls[0].From != safeAddress || client.calls[0].Value.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Incorrect simulation call %+v", client.calls)
+	}
+	if !results[0].Success || results[1].Success || !results[2].Success {
+		t.Fatalf("Incorrect call results %+v", results)
+	}
+	if results[1].RevertReason != "Nothing to distribute" {
+		t.Errorf("Incorrect revert reason %q", results[1].RevertReason)
+	}
+	var deposited *big.Int
+	if err := results[2].Unpack(&deposited); err != nil {
+		t.Fatal(err)
+	}
+	if deposited.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Incorrect unpacked result %s", deposited.String())
+	}
+	if err := results[1].Unpack(&deposited); err == nil {
+		t.Error("Unpacked the result of a failed call")
+	}
+
+	// Mismatched result counts are rejected
+	if _, err := multicall.NewBatcher(client).DecodeAggregate3Results(response); err == nil {
+		t.Error("Decoded results for a different batch")
+	}
+
+}
+
+func TestMultiSend(t *testing.T) {
+
+	client := &fakeClient{t: t}
+
+	// Calls that allow failure can't be sent through MultiSend
+	if _, err := makeBatch(t, client, true).GetMultiSendTransactions(); err == nil {
+		t.Error("Packed a MultiSend batch with calls that allow failure")
+	}
+
+	// Check the packed transaction layout
+	batcher := makeBatch(t, client, false)
+	packed, err := batcher.GetMultiSendTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := 0
+	for i, call := range batcher.GetCalls() {
+		if packed[offset] != 0 {
+			t.Errorf("Call %d has operation %d", i, packed[offset])
+		}
+		if common.BytesToAddress(packed[offset+1:offset+21]) != call.Target {
+			t.Errorf("Call %d has incorrect target", i)
+		}
+		if new(big.Int).SetBytes(packed[offset+21:offset+53]).Cmp(call.Value) != 0 {
+			t.Errorf("Call %d has incorrect value", i)
+		}
+		length := int(new(big.Int).SetBytes(packed[offset+53 : offset+85]).Int64())
+		if !bytes.Equal(packed[offset+85:offset+85+length], call.CallData) {
+			t.Errorf("Call %d has incorrect data", i)
+		}
+		offset += 85 + length
+	}
+	if offset != len(packed) {
+		t.Errorf("Packed transactions have %d trailing bytes", len(packed)-offset)
+	}
+
+	// The calldata wraps the packed transactions
+	data, err := batcher.GetMultiSendData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msAbi, _ := abi.JSON(strings.NewReader(multicall.MultiSendABI))
+	unpacked, err := msAbi.Methods["multiSend"].Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unpacked[0].([]byte), packed) {
+		t.Error("MultiSend calldata does not wrap the packed transactions")
+	}
+
+	// A successful simulation runs the whole payload once
+	results, err := batcher.SimulateSafe(safeAddress, multiSendAddress, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.calls) != 1 || client.calls[0].From != safeAddress {
+		t.Errorf("Incorrect simulation calls %+v", client.calls)
+	}
+	for i, result := range results {
+		if !result.Success {
+			t.Errorf("Call %d failed: %s", i, result.RevertReason)
+		}
+	}
+
+	// A failed simulation finds the failing call
+	client.calls = nil
+	client.reverts = map[common.Address][]byte{distributorB: makeRevert(t, "Nothing to distribute")}
+	results, err = batcher.SimulateSafe(safeAddress, multiSendAddress, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Success || results[1].Success || results[2].Success {
+		t.Fatalf("Incorrect call results %+v", results)
+	}
+	if results[1].RevertReason != "Nothing to distribute" {
+		t.Errorf("Incorrect revert reason %q", results[1].RevertReason)
+	}
+	if !strings.Contains(results[2].RevertReason, "not executed") {
+		t.Errorf("Call after the failure has reason %q", results[2].RevertReason)
+	}
+
+	// Non-Safe targets are rejected
+	if _, err := batcher.SimulateSafe(distributorA, multiSendAddress, nil); err == nil {
+		t.Error("Simulated through a contract that isn't a Safe")
+	}
+
+}
+
+func TestDecodeReceipt(t *testing.T) {
+
+	client := &fakeClient{t: t}
+	batcher := makeBatch(t, client, true)
+	receipt := &types.Receipt{
+		Status: types.ReceiptStatusSuccessful,
+		TxHash: common.HexToHash("0x01"),
+		Logs: []*types.Log{
+			makeDistributedLog(t, distributorA, 7, 0),
+			makeDistributedLog(t, common.HexToAddress("0x99"), 7, 1),
+		},
+	}
+
+	// Per-call results come from the return data, and events aren't attributed by address
+	decoded, err := batcher.DecodeReceipt(receipt, makeAggregate3Response(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Success || len(decoded.Calls) != 3 {
+		t.Fatalf("Incorrect decoded receipt %+v", decoded)
+	}
+	if !decoded.Calls[0].Success || decoded.Calls[1].Success || !decoded.Calls[2].Success {
+		t.Errorf("Incorrect call results %+v", decoded.Calls)
+	}
+	if decoded.Calls[1].RevertReason != "Nothing to distribute" {
+		t.Errorf("Incorrect revert reason %q", decoded.Calls[1].RevertReason)
+	}
+	for i, call := range decoded.Calls {
+		if len(call.Events) != 0 {
+			t.Errorf("Call %d was attributed %d events", i, len(call.Events))
+		}
+	}
+	if len(decoded.Events) != 1 || decoded.Events[0].Name != "Distributed" {
+		t.Errorf("Incorrect batch events %+v", decoded.Events)
+	}
+
+	// The return data is required if a call can fail on its own
+	if _, err := batcher.DecodeReceipt(receipt, nil); err == nil {
+		t.Error("Decoded a receipt without knowing which calls failed")
+	}
+	decoded, err = makeBatch(t, client, false).DecodeReceipt(receipt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, call := range decoded.Calls {
+		if !call.Success {
+			t.Errorf("Call %d of a successful batch without optional calls failed", i)
+		}
+	}
+
+	// A reverted batch marks every call as failed
+	receipt.Status = types.ReceiptStatusFailed
+	receipt.Logs = nil
+	decoded, err = batcher.DecodeReceipt(receipt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, call := range decoded.Calls {
+		if call.Success {
+			t.Errorf("Call %d of a reverted batch was marked successful", i)
+		}
+	}
+
+}
+
+func TestDecodeTrace(t *testing.T) {
+
+	client := &fakeClient{t: t}
+	batcher := makeBatch(t, client, true)
+	calls := batcher.GetCalls()
+	first := makeDistributedLog(t, distributorA, 7, 0)
+	second := makeDistributedLog(t, distributorA, 9, 1)
+	receipt := &types.Receipt{
+		Status: types.ReceiptStatusSuccessful,
+		TxHash: common.HexToHash("0x01"),
+		Logs:   []*types.Log{first, second},
+	}
+
+	// Both successful calls target the same contract, so their events can only be told apart by frame
+	trace := &multicall.CallFrame{
+		Type: "CALL",
+		To:   multicall3Address,
+		Calls: []multicall.CallFrame{
+			{Type: "CALL", To: distributorA, Input: calls[0].CallData, Logs: []multicall.CallLog{toCallLog(first)}},
+			{Type: "STATICCALL", To: distributorB},
+			{Type: "CALL", To: distributorB, Input: calls[1].CallData, Error: "execution reverted", Output: makeRevert(t, "Nothing to distribute")},
+			{Type: "CALL", To: distributorA, Input: calls[2].CallData, Output: common.LeftPadBytes(big.NewInt(5).Bytes(), 32), Calls: []multicall.CallFrame{
+				{Type: "CALL", To: distributorA, Logs: []multicall.CallLog{toCallLog(second)}},
+			}},
+		},
+	}
+	decoded, err := batcher.DecodeTrace(receipt, trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Success || !decoded.Calls[0].Success || decoded.Calls[1].Success || !decoded.Calls[2].Success {
+		t.Fatalf("Incorrect call results %+v", decoded.Calls)
+	}
+	if decoded.Calls[1].RevertReason != "Nothing to distribute" {
+		t.Errorf("Incorrect revert reason %q", decoded.Calls[1].RevertReason)
+	}
+	if len(decoded.Calls[0].Events) != 1 || decoded.Calls[0].Events[0].Log.Index != 0 {
+		t.Errorf("Incorrect events for the first call %+v", decoded.Calls[0].Events)
+	}
+	if len(decoded.Calls[2].Events) != 1 || decoded.Calls[2].Events[0].Log.Index != 1 {
+		t.Errorf("Incorrect events for the deposit %+v", decoded.Calls[2].Events)
+	}
+	var deposited *big.Int
+	if err := decoded.Calls[2].Unpack(&deposited); err != nil || deposited.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("Incorrect deposit result %v (%v)", deposited, err)
+	}
+
+	// A Safe catches a failed MultiSend, so the transaction succeeds but none of the calls do
+	receipt.Logs = nil
+	trace = &multicall.CallFrame{
+		Type: "CALL",
+		To:   safeAddress,
+		Calls: []multicall.CallFrame{
+			{Type: "DELEGATECALL", To: multiSendAddress, Error: "execution reverted", Calls: []multicall.CallFrame{
+				{Type: "CALL", To: distributorA, Input: calls[0].CallData},
+				{Type: "CALL", To: distributorB, Input: calls[1].CallData, Error: "execution reverted", Output: makeRevert(t, "Nothing to distribute")},
+			}},
+		},
+	}
+	decoded, err = batcher.DecodeTrace(receipt, trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Success {
+		t.Error("Reverted MultiSend was marked successful")
+	}
+	for i, call := range decoded.Calls {
+		if call.Success {
+			t.Errorf("Call %d of a reverted MultiSend was marked successful", i)
+		}
+	}
+	if decoded.Calls[1].RevertReason != "Nothing to distribute" || !strings.Contains(decoded.Calls[2].RevertReason, "not executed") {
+		t.Errorf("Incorrect call results %+v", decoded.Calls)
+	}
+
+	// Traces of other transactions are rejected
+	if _, err := batcher.DecodeTrace(receipt, &multicall.CallFrame{Type: "CALL", To: distributorA}); err == nil {
+		t.Error("Decoded a trace without the batch calls")
+	}
+
+}
@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+var (
+	statusTime     = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	latestDelegate = common.HexToAddress("0xd000000000000000000000000000000000000001")
+	oldDelegate    = common.HexToAddress("0xd000000000000000000000000000000000000000")
+	network        = &state.NetworkDetails{
+		ScrubPeriod:               12 * time.Hour,
+		PromotionScrubPeriod:      3 * 24 * time.Hour,
+		MinipoolLaunchTimeout:     big.NewInt(72 * 60 * 60),
+		BondReductionWindowStart:  12 * time.Hour,
+		BondReductionWindowLength: 2 * 24 * time.Hour,
+		BondReductionEnabled:      true,
+	}
+)
+
+// Get the details of a v3 minipool
+func getMinipool(status types.MinipoolStatus) *state.NativeMinipoolDetails {
+	return &state.NativeMinipoolDetails{
+		Status:             status,
+		StatusTime:         big.NewInt(statusTime.Unix()),
+		Version:            3,
+		Delegate:           latestDelegate,
+		Balance:            big.NewInt(0),
+		NodeDepositBalance: eth.EthToWei(16),
+		ReduceBondTime:     big.NewInt(0),
+	}
+}
+
+// Check the allowed actions
+func checkAllowed(t *testing.T, name string, actions []state.MinipoolActionStatus, expected ...state.MinipoolAction) {
+	if expected == nil {
+		expected = []state.MinipoolAction{}
+	}
+	if allowed := state.GetAllowedMinipoolActions(actions); !reflect.DeepEqual(allowed, expected) {
+		t.Errorf("%s: incorrect allowed actions %v", name, allowed)
+	}
+}
+
+// Get an action's status
+func getAction(actions []state.MinipoolActionStatus, action state.MinipoolAction) state.MinipoolActionStatus {
+	for _, status := range actions {
+		if status.Action == action {
+			return status
+		}
+	}
+	return state.MinipoolActionStatus{}
+}
+
+func TestGetMinipoolActions(t *testing.T) {
+
+	// Prelaunch minipools stake after the scrub period and dissolve after the launch timeout
+	prelaunch := getMinipool(types.Prelaunch)
+	actions := state.GetMinipoolActions(network, prelaunch, latestDelegate, statusTime.Add(time.Hour))
+	checkAllowed(t, "scrub period", actions)
+	stake := getAction(actions, state.MinipoolAction_Stake)
+	if reasons := stake.GetBlockedReasons(); len(reasons) != 1 || reasons[0] != "scrub period has passed" || !stake.WindowStart.Equal(statusTime.Add(12*time.Hour)) {
+		t.Errorf("Incorrect stake status %+v", stake)
+	}
+	checkAllowed(t, "after scrub", state.GetMinipoolActions(network, prelaunch, latestDelegate, statusTime.Add(12*time.Hour)), state.MinipoolAction_Stake)
+	checkAllowed(t, "timed out", state.GetMinipoolActions(network, prelaunch, latestDelegate, statusTime.Add(72*time.Hour)), state.MinipoolAction_Stake, state.MinipoolAction_Dissolve)
+
+	// Vacant minipools are promoted instead of staked
+	prelaunch.IsVacant = true
+	actions = state.GetMinipoolActions(network, prelaunch, latestDelegate, statusTime.Add(24*time.Hour))
+	checkAllowed(t, "vacant scrub", actions)
+	if reasons := getAction(actions, state.MinipoolAction_Stake).GetBlockedReasons(); len(reasons) != 1 || reasons[0] != "minipool is not vacant" {
+		t.Errorf("Incorrect vacant stake reasons %v", reasons)
+	}
+	checkAllowed(t, "vacant", state.GetMinipoolActions(network, prelaunch, latestDelegate, statusTime.Add(3*24*time.Hour)), state.MinipoolAction_Promote, state.MinipoolAction_Dissolve)
+
+	// Dissolved minipools can only be closed
+	checkAllowed(t, "dissolved", state.GetMinipoolActions(network, getMinipool(types.Dissolved), latestDelegate, statusTime), state.MinipoolAction_Close)
+
+	// Staking minipools distribute and reduce their bonds
+	staking := getMinipool(types.Staking)
+	staking.Balance = eth.EthToWei(0.1)
+	staking.Delegate = oldDelegate
+	checkAllowed(t, "staking", state.GetMinipoolActions(network, staking, latestDelegate, statusTime),
+		state.MinipoolAction_DistributeBalance, state.MinipoolAction_BeginReduceBondAmount, state.MinipoolAction_DelegateUpgrade)
+
+	// Bonds can't be reduced while bond reduction is disabled or once they're at the minimum
+	disabled := *network
+	disabled.BondReductionEnabled = false
+	begin := getAction(state.GetMinipoolActions(&disabled, staking, latestDelegate, statusTime), state.MinipoolAction_BeginReduceBondAmount)
+	if reasons := begin.GetBlockedReasons(); len(reasons) != 1 || reasons[0] != "bond reduction is enabled" {
+		t.Errorf("Incorrect disabled bond reduction reasons %v", reasons)
+	}
+	leb8 := *staking
+	leb8.NodeDepositBalance = eth.EthToWei(8)
+	begin = getAction(state.GetMinipoolActions(network, &leb8, latestDelegate, statusTime), state.MinipoolAction_BeginReduceBondAmount)
+	if reasons := begin.GetBlockedReasons(); len(reasons) != 1 || reasons[0] != "bond is above the minimum bond" {
+		t.Errorf("Incorrect LEB8 bond reduction reasons %v", reasons)
+	}
+
+	reduceBondTime := statusTime.Add(24 * time.Hour)
+	staking.ReduceBondTime = big.NewInt(reduceBondTime.Unix())
+	staking.UseLatestDelegate = true
+	for _, c := range []struct {
+		offset  time.Duration
+		allowed bool
+	}{{time.Hour, false}, {12 * time.Hour, true}, {60*time.Hour - time.Second, true}, {60 * time.Hour, false}} {
+		reduce := getAction(state.GetMinipoolActions(network, staking, latestDelegate, reduceBondTime.Add(c.offset)), state.MinipoolAction_ReduceBondAmount)
+		if reduce.Allowed != c.allowed || !reduce.WindowEnd.Equal(reduceBondTime.Add(60*time.Hour)) {
+			t.Errorf("Incorrect bond reduction status %v at %s: %v", reduce.Allowed, c.offset, reduce.GetBlockedReasons())
+		}
+	}
+	staking.ReduceBondCancelled = true
+	checkAllowed(t, "cancelled", state.GetMinipoolActions(network, staking, latestDelegate, reduceBondTime.Add(24*time.Hour)), state.MinipoolAction_DistributeBalance)
+
+	// Finalisation depends on the delegate version
+	staking = getMinipool(types.Staking)
+	staking.UserDistributed = true
+	staking.Finalised = false
+	checkAllowed(t, "user distributed", state.GetMinipoolActions(network, staking, latestDelegate, statusTime), state.MinipoolAction_Finalise, state.MinipoolAction_BeginReduceBondAmount)
+	legacy := getMinipool(types.Withdrawable)
+	legacy.Version = 2
+	checkAllowed(t, "legacy", state.GetMinipoolActions(network, legacy, latestDelegate, statusTime), state.MinipoolAction_Finalise)
+
+}
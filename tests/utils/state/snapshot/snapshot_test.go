@@ -0,0 +1,137 @@
+package snapshot
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+var (
+	node1     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	node2     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	minipool1 = common.HexToAddress("0xa000000000000000000000000000000000000001")
+	minipool2 = common.HexToAddress("0xa000000000000000000000000000000000000002")
+	minipool3 = common.HexToAddress("0xa000000000000000000000000000000000000003")
+)
+
+// Get node details with the derived fields initialised
+func getNodeDetails(address common.Address, distributorBalance *big.Int, collateralisationRatio *big.Int) state.NativeNodeDetails {
+	return state.NativeNodeDetails{
+		NodeAddress:               address,
+		Exists:                    true,
+		DistributorBalance:        distributorBalance,
+		CollateralisationRatio:    collateralisationRatio,
+		AverageNodeFee:            big.NewInt(0),
+		DistributorBalanceNodeETH: big.NewInt(0),
+		DistributorBalanceUserETH: big.NewInt(0),
+	}
+}
+
+func TestNetworkState(t *testing.T) {
+
+	networkState := &state.NetworkState{
+		ElBlockNumber: 100,
+		NetworkDetails: &state.NetworkDetails{
+			RewardIndex:       3,
+			IntervalStart:     time.Unix(1700000000, 0).UTC(),
+			PendingRPLRewards: big.NewInt(500),
+		},
+		NodeDetails: []state.NativeNodeDetails{
+			getNodeDetails(node1, eth.EthToWei(1), eth.EthToWei(2)),
+			getNodeDetails(node2, eth.EthToWei(1), eth.EthToWei(4)),
+		},
+		MinipoolDetails: []state.NativeMinipoolDetails{
+			{MinipoolAddress: minipool1, NodeAddress: node1, Status: types.Staking, NodeFee: eth.EthToWei(0.1)},
+			{MinipoolAddress: minipool2, NodeAddress: node1, Status: types.Staking, NodeFee: eth.EthToWei(0.2)},
+			{MinipoolAddress: minipool3, NodeAddress: node1, Status: types.Dissolved, NodeFee: eth.EthToWei(0.05)},
+		},
+		OracleDaoMemberDetails: []state.OracleDaoMemberDetails{
+			{Address: node2, Exists: true, ID: "member"},
+		},
+	}
+
+	// Lookups
+	networkState.BuildLookups()
+	if details, exists := networkState.GetNodeDetails(node1); !exists || details != &networkState.NodeDetails[0] {
+		t.Error("Incorrect node lookup")
+	}
+	if details, exists := networkState.GetMinipoolDetails(minipool2); !exists || details.NodeFee.Cmp(eth.EthToWei(0.2)) != 0 {
+		t.Error("Incorrect minipool lookup")
+	}
+	if _, exists := networkState.GetMinipoolDetails(node1); exists {
+		t.Error("Found a minipool for a node address")
+	}
+	if minipools := networkState.GetNodeMinipoolDetails(node1); len(minipools) != 3 {
+		t.Errorf("Incorrect minipool count %d for node 1", len(minipools))
+	}
+	if minipools := networkState.GetNodeMinipoolDetails(node2); minipools == nil || len(minipools) != 0 {
+		t.Error("Node 2 should have an empty minipool list")
+	}
+	if details, exists := networkState.GetOracleDaoMemberDetails(node2); !exists || details.ID != "member" {
+		t.Error("Incorrect Oracle DAO member lookup")
+	}
+
+	// Derived fields are written into the state's node details
+	for i := range networkState.NodeDetails {
+		node := &networkState.NodeDetails[i]
+		if err := state.CalculateAverageFeeAndDistributorShares(nil, nil, node, networkState.GetNodeMinipoolDetails(node.NodeAddress)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	details, _ := networkState.GetNodeDetails(node1)
+	if details.AverageNodeFee.Cmp(eth.EthToWei(0.15)) != 0 || details.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.575)) != 0 || details.DistributorBalanceUserETH.Cmp(eth.EthToWei(0.425)) != 0 {
+		t.Errorf("Incorrect derived details for node 1: %s %s %s", details.AverageNodeFee, details.DistributorBalanceNodeETH, details.DistributorBalanceUserETH)
+	}
+	details, _ = networkState.GetNodeDetails(node2)
+	if details.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.25)) != 0 || details.DistributorBalanceUserETH.Cmp(eth.EthToWei(0.75)) != 0 {
+		t.Errorf("Incorrect derived details for node 2: %s %s", details.DistributorBalanceNodeETH, details.DistributorBalanceUserETH)
+	}
+
+	// Copies of the details that share the old values aren't modified
+	original, _ := networkState.GetNodeDetails(node1)
+	copied := *original
+	copied.DistributorBalance = eth.EthToWei(2)
+	if err := state.CalculateAverageFeeAndDistributorShares(nil, nil, &copied, networkState.GetNodeMinipoolDetails(node1)); err != nil {
+		t.Fatal(err)
+	}
+	if original.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.575)) != 0 || copied.DistributorBalanceNodeETH.Cmp(eth.EthToWei(1.15)) != 0 {
+		t.Errorf("Incorrect shares %s for the original and %s for the copy", original.DistributorBalanceNodeETH, copied.DistributorBalanceNodeETH)
+	}
+
+	// Details without derived values are filled in
+	empty := state.NativeNodeDetails{NodeAddress: node2}
+	if err := state.CalculateAverageFeeAndDistributorShares(nil, nil, &empty, nil); err != nil || empty.AverageNodeFee.Sign() != 0 || empty.DistributorBalanceNodeETH.Sign() != 0 {
+		t.Errorf("Incorrect derived details for an empty node: %+v (%v)", empty, err)
+	}
+
+	// Serialization
+	for _, name := range []string{"state.json", "state.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := networkState.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := state.LoadNetworkState(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.ElBlockNumber != 100 || loaded.NetworkDetails.RewardIndex != 3 || !loaded.NetworkDetails.IntervalStart.Equal(networkState.NetworkDetails.IntervalStart) {
+			t.Errorf("Incorrect network details loaded from %s", name)
+		}
+		minipool, exists := loaded.GetMinipoolDetails(minipool3)
+		if !exists || minipool.Status != types.Dissolved || len(loaded.GetNodeMinipoolDetails(node1)) != 3 {
+			t.Errorf("Incorrect minipool details loaded from %s", name)
+		}
+		node, exists := loaded.GetNodeDetails(node1)
+		if !exists || node.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.575)) != 0 {
+			t.Errorf("Incorrect node details loaded from %s", name)
+		}
+	}
+
+}
@@ -0,0 +1,125 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+var (
+	nodeManager     = common.HexToAddress("0x0100000000000000000000000000000000000001")
+	minipoolManager = common.HexToAddress("0x0100000000000000000000000000000000000002")
+	prices          = common.HexToAddress("0x0100000000000000000000000000000000000003")
+	upgrade         = common.HexToAddress("0x0100000000000000000000000000000000000004")
+	odaoActions     = common.HexToAddress("0x0100000000000000000000000000000000000005")
+	pdaoProposal    = common.HexToAddress("0x0100000000000000000000000000000000000006")
+
+	node1     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	node2     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	minipool1 = common.HexToAddress("0xa000000000000000000000000000000000000001")
+	minipool2 = common.HexToAddress("0xa000000000000000000000000000000000000002")
+	minipool3 = common.HexToAddress("0xa000000000000000000000000000000000000003")
+
+	minipoolCreatedID   = crypto.Keccak256Hash([]byte("MinipoolCreated(address,address,uint256)"))
+	minipoolDestroyedID = crypto.Keccak256Hash([]byte("MinipoolDestroyed(address,address,uint256)"))
+	statusUpdatedID     = crypto.Keccak256Hash([]byte("StatusUpdated(uint8,uint256)"))
+)
+
+// Get a change detector for the test contracts
+func getDetector() *state.ChangeDetector {
+	return &state.ChangeDetector{
+		NodeContracts:        []common.Address{nodeManager},
+		MinipoolContracts:    []common.Address{minipoolManager},
+		OracleDaoContracts:   []common.Address{odaoActions},
+		ProtocolDaoContracts: []common.Address{pdaoProposal},
+		PricesContract:       prices,
+		UpgradeContract:      upgrade,
+		MinipoolCreatedID:    minipoolCreatedID,
+		MinipoolDestroyedID:  minipoolDestroyedID,
+	}
+}
+
+// Get a state with two nodes, where node 1 has two minipools
+func getState() *state.NetworkState {
+	networkState := &state.NetworkState{
+		NodeDetails: []state.NativeNodeDetails{
+			{NodeAddress: node1, Exists: true},
+			{NodeAddress: node2, Exists: true},
+		},
+		MinipoolDetails: []state.NativeMinipoolDetails{
+			{MinipoolAddress: minipool1, NodeAddress: node1},
+			{MinipoolAddress: minipool2, NodeAddress: node1},
+		},
+	}
+	networkState.BuildLookups()
+	return networkState
+}
+
+// Get a log with address topics
+func getLog(address common.Address, id common.Hash, topics ...common.Address) types.Log {
+	log := types.Log{
+		Address: address,
+		Topics:  []common.Hash{id},
+	}
+	for _, topic := range topics {
+		log.Topics = append(log.Topics, common.BytesToHash(topic.Bytes()))
+	}
+	return log
+}
+
+func TestGetChanges(t *testing.T) {
+
+	detector := getDetector()
+	networkState := getState()
+
+	// Node events only refresh that node
+	changes := detector.GetChanges(networkState, []types.Log{getLog(nodeManager, crypto.Keccak256Hash([]byte("NodeSmoothingPoolStateChanged(address,bool)")), node2)})
+	if len(changes.Nodes) != 1 || !changes.Nodes[node2] || len(changes.Minipools) != 0 || changes.AllNodes || changes.Reconcile {
+		t.Errorf("Incorrect changes for a node event %+v", changes)
+	}
+
+	// A new minipool is recognised along with its own events in the same range
+	changes = detector.GetChanges(networkState, []types.Log{
+		getLog(minipool3, statusUpdatedID),
+		getLog(minipoolManager, minipoolCreatedID, minipool3, node2),
+		getLog(common.HexToAddress("0xb000000000000000000000000000000000000001"), statusUpdatedID),
+	})
+	if len(changes.Minipools) != 1 || !changes.Minipools[minipool3] || len(changes.Nodes) != 1 || !changes.Nodes[node2] {
+		t.Errorf("Incorrect changes for a new minipool %+v", changes)
+	}
+
+	// Existing minipools refresh their node, and destroyed minipools are removed
+	changes = detector.GetChanges(networkState, []types.Log{
+		getLog(minipool1, statusUpdatedID),
+		getLog(minipool2, statusUpdatedID),
+		getLog(minipoolManager, minipoolDestroyedID, minipool2, node1),
+	})
+	if len(changes.Minipools) != 1 || !changes.Minipools[minipool1] || !changes.RemovedMinipools[minipool2] || len(changes.Nodes) != 1 || !changes.Nodes[node1] {
+		t.Errorf("Incorrect changes for minipool events %+v", changes)
+	}
+
+	// Network-wide events
+	changes = detector.GetChanges(networkState, []types.Log{
+		getLog(prices, crypto.Keccak256Hash([]byte("PricesUpdated(uint256,uint256,uint256)"))),
+		getLog(odaoActions, crypto.Keccak256Hash([]byte("ActionJoined(address,uint256,uint256)")), node2),
+		getLog(pdaoProposal, crypto.Keccak256Hash([]byte("ProposalAdded(address,uint256,bytes,uint256)")), node1),
+	})
+	if !changes.AllNodes || !changes.OracleDao || !changes.ProtocolDao || changes.Reconcile || len(changes.Nodes) != 0 {
+		t.Errorf("Incorrect changes for network events %+v", changes)
+	}
+
+	// Upgrades and reorganizations require a full snapshot
+	if changes := detector.GetChanges(networkState, []types.Log{getLog(upgrade, crypto.Keccak256Hash([]byte("ContractUpgraded(bytes32,address,address,uint256)")))}); !changes.Reconcile {
+		t.Error("An upgrade did not require reconciliation")
+	}
+	removed := getLog(minipool1, statusUpdatedID)
+	removed.Removed = true
+	if changes := detector.GetChanges(networkState, []types.Log{removed}); !changes.Reconcile {
+		t.Error("A removed log did not require reconciliation")
+	}
+
+}
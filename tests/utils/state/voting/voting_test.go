@@ -0,0 +1,152 @@
+package voting
+
+import (
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+var (
+	nodeAddress    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	networkDetails = &state.NetworkDetails{
+		RplPrice:                   big.NewInt(1e16),  // 0.01 ETH per RPL
+		MaximumStakeForVotingPower: big.NewInt(15e17), // 150% of bonded ETH
+	}
+)
+
+func TestCalculateVotingPower(t *testing.T) {
+
+	// Stakes below the cap count in full
+	power := state.CalculateVotingPower(eth.EthToWei(1000), eth.EthToWei(8), networkDetails.RplPrice, networkDetails.MaximumStakeForVotingPower)
+	expected := big.NewInt(0).Sqrt(big.NewInt(0).Mul(eth.EthToWei(1000), eth.EthToWei(1)))
+	if power.Cmp(expected) != 0 {
+		t.Errorf("Incorrect voting power %s, expected %s", power.String(), expected.String())
+	}
+
+	// Stakes above the cap are limited to 150% of the bonded ETH's value in RPL
+	stake := state.GetVotingPowerStake(eth.EthToWei(2000), eth.EthToWei(8), networkDetails.RplPrice, networkDetails.MaximumStakeForVotingPower)
+	if stake.Cmp(eth.EthToWei(1200)) != 0 {
+		t.Errorf("Incorrect capped stake %s", stake.String())
+	}
+	power = state.CalculateVotingPower(eth.EthToWei(2000), eth.EthToWei(8), networkDetails.RplPrice, networkDetails.MaximumStakeForVotingPower)
+	expected = big.NewInt(0).Sqrt(big.NewInt(0).Mul(eth.EthToWei(1200), eth.EthToWei(1)))
+	if power.Cmp(expected) != 0 {
+		t.Errorf("Incorrect capped voting power %s, expected %s", power.String(), expected.String())
+	}
+
+	// Nodes without bonded ETH or a price have no voting power
+	if power := state.CalculateVotingPower(eth.EthToWei(1000), big.NewInt(0), networkDetails.RplPrice, networkDetails.MaximumStakeForVotingPower); power.Sign() != 0 {
+		t.Errorf("Node without bonded ETH has voting power %s", power.String())
+	}
+	if power := state.CalculateVotingPower(eth.EthToWei(1000), eth.EthToWei(8), big.NewInt(0), networkDetails.MaximumStakeForVotingPower); power.Sign() != 0 {
+		t.Errorf("Zero RPL price gave voting power %s", power.String())
+	}
+
+}
+
+func TestProjectNodeVotingPower(t *testing.T) {
+	networkState := &state.NetworkState{
+		NetworkDetails: networkDetails,
+		NodeDetails: []state.NativeNodeDetails{{
+			NodeAddress:       nodeAddress,
+			RplStake:          eth.EthToWei(1000),
+			EthProvided:       eth.EthToWei(8),
+			VotingInitialised: true,
+		}},
+	}
+
+	current, err := networkState.GetNodeVotingPower(nodeAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Staking more increases voting power up to the cap
+	more, err := networkState.ProjectNodeVotingPower(nodeAddress, eth.EthToWei(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if more.Cmp(current) <= 0 {
+		t.Errorf("Staking RPL didn't increase voting power from %s to %s", current.String(), more.String())
+	}
+	capped, err := networkState.ProjectNodeVotingPower(nodeAddress, eth.EthToWei(5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	atCap, err := networkState.ProjectNodeVotingPower(nodeAddress, eth.EthToWei(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capped.Cmp(atCap) != 0 {
+		t.Errorf("Staking beyond the cap changed voting power from %s to %s", atCap.String(), capped.String())
+	}
+
+	// Withdrawing decreases it, but not below zero stake
+	less, err := networkState.ProjectNodeVotingPower(nodeAddress, eth.EthToWei(-500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if less.Cmp(current) >= 0 {
+		t.Errorf("Withdrawing RPL didn't decrease voting power from %s to %s", current.String(), less.String())
+	}
+	if _, err := networkState.ProjectNodeVotingPower(nodeAddress, eth.EthToWei(-1001)); err == nil {
+		t.Error("Projected a withdrawal of more than the node's stake")
+	}
+
+	// Nodes that haven't initialised voting have none
+	networkState.NodeDetails[0].VotingInitialised = false
+	if power, _ := networkState.GetNodeVotingPower(nodeAddress); power.Sign() != 0 {
+		t.Errorf("Uninitialised node has voting power %s", power.String())
+	}
+
+}
+
+// Check the calculation against getVotingPower for every node on a Houston network.
+// Set VOTING_POWER_RPC_URL, VOTING_POWER_STORAGE_ADDRESS, VOTING_POWER_MULTICALLER_ADDRESS and VOTING_POWER_BALANCE_BATCHER_ADDRESS to run it,
+// and optionally VOTING_POWER_BLOCK to check a specific block instead of the latest one.
+func TestVotingPowerMatchesChain(t *testing.T) {
+	rpcUrl := os.Getenv("VOTING_POWER_RPC_URL")
+	if rpcUrl == "" {
+		t.Skip("VOTING_POWER_RPC_URL is not set")
+	}
+	client, err := ethclient.Dial(rpcUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, err := rocketpool.NewRocketPool(client, common.HexToAddress(os.Getenv("VOTING_POWER_STORAGE_ADDRESS")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	multicallerAddress := common.HexToAddress(os.Getenv("VOTING_POWER_MULTICALLER_ADDRESS"))
+	balanceBatcherAddress := common.HexToAddress(os.Getenv("VOTING_POWER_BALANCE_BATCHER_ADDRESS"))
+
+	var opts *bind.CallOpts
+	if block := os.Getenv("VOTING_POWER_BLOCK"); block != "" {
+		blockNumber, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(blockNumber)}
+	}
+
+	networkState, err := state.CreateNetworkState(rp, multicallerAddress, balanceBatcherAddress, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatches, err := networkState.CheckVotingPowers(rp, multicallerAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mismatch := range mismatches {
+		t.Errorf("Node %s has calculated voting power %s but on-chain voting power %s", mismatch.NodeAddress.Hex(), mismatch.Calculated.String(), mismatch.OnChain.String())
+	}
+
+}
@@ -0,0 +1,400 @@
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/txmgr"
+)
+
+// A contract whose every call emits Ping()
+const pingerAbi = `[{"type":"function","name":"ping","inputs":[],"outputs":[],"stateMutability":"nonpayable"},{"type":"event","name":"Ping","inputs":[],"anonymous":false}]`
+
+// Init code that deploys `PUSH32 keccak("Ping()") PUSH1 0 PUSH1 0 LOG1 STOP`
+var pingerBytecode = "0x6027600c60003960276000f3" + "7f" + crypto.Keccak256Hash([]byte("Ping()")).Hex()[2:] + "60006000a100"
+
+var chainID = big.NewInt(1337)
+
+// Adds the client functions the simulated backend doesn't provide
+type simulatedClient struct {
+	*backends.SimulatedBackend
+}
+
+func (c *simulatedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+func (c *simulatedClient) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// Create a simulated chain with a funded account
+func newSimulatedClient(t *testing.T) (*simulatedClient, *ecdsa.PrivateKey) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: eth.EthToWei(100)},
+	}
+	backend := backends.NewSimulatedBackend(alloc, 30000000)
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+	return &simulatedClient{SimulatedBackend: backend}, key
+}
+
+// Get transaction options for a key
+func getTransactor(t *testing.T, key *ecdsa.PrivateKey) *bind.TransactOpts {
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+// Wait for a transaction in the background, committing blocks until it is done
+func waitWithCommits(t *testing.T, client *simulatedClient, manager *txmgr.Manager, tx *txmgr.Transaction) (*txmgr.Receipt, error) {
+	type result struct {
+		receipt *txmgr.Receipt
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := manager.Wait(context.Background(), tx)
+		done <- result{receipt, err}
+	}()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case r := <-done:
+			return r.receipt, r.err
+		case <-time.After(10 * time.Millisecond):
+			client.Commit()
+		case <-timeout:
+			t.Fatal("Timed out waiting for transaction")
+		}
+	}
+}
+
+func TestNonceQueue(t *testing.T) {
+
+	client, key := newSimulatedClient(t)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond})
+	opts := getTransactor(t, key)
+	toAddress := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Submit concurrently from one account
+	count := 5
+	txs := make([]*txmgr.Transaction, count)
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	for i := 0; i < count; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sendOpts := *opts
+			sendOpts.Value = big.NewInt(int64(i + 1))
+			tx, err := manager.Send(context.Background(), toAddress, nil, &sendOpts)
+			if err != nil {
+				errs <- err
+				return
+			}
+			txs[i] = tx
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// Every submission got its own nonce
+	seen := map[uint64]bool{}
+	for _, tx := range txs {
+		if seen[tx.Nonce] {
+			t.Errorf("Nonce %d was allocated twice", tx.Nonce)
+		}
+		seen[tx.Nonce] = true
+	}
+	for i := 0; i < count; i++ {
+		if !seen[uint64(i)] {
+			t.Errorf("Nonce %d was skipped", i)
+		}
+	}
+
+	// And they were all mined
+	client.Commit()
+	for _, tx := range txs {
+		receipt, err := manager.Wait(context.Background(), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Confirmations < 1 {
+			t.Errorf("Incorrect confirmations %d", receipt.Confirmations)
+		}
+	}
+	if balance, err := client.BalanceAt(context.Background(), toAddress, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Int64() != 15 {
+		t.Errorf("Incorrect balance %s", balance.String())
+	}
+
+}
+
+func TestReplacement(t *testing.T) {
+
+	client, key := newSimulatedClient(t)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond})
+	opts := getTransactor(t, key)
+	toAddress := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Send a transaction, then drop it from the pending block as if it was stuck
+	sendOpts := *opts
+	sendOpts.Value = big.NewInt(1)
+	tx, err := manager.Send(context.Background(), toAddress, nil, &sendOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Rollback()
+
+	// Replacements must pay the minimum bump
+	original := tx.Latest()
+	if _, err := manager.SpeedUp(context.Background(), tx, original.GasTipCap(), original.GasFeeCap()); err == nil {
+		t.Error("Replacement without a fee bump was accepted")
+	}
+
+	// Speed it up
+	replacement, err := manager.SpeedUp(context.Background(), tx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement.Nonce() != original.Nonce() {
+		t.Errorf("Replacement nonce %d doesn't match %d", replacement.Nonce(), original.Nonce())
+	}
+	if replacement.GasTipCap().Cmp(original.GasTipCap()) <= 0 || replacement.GasFeeCap().Cmp(original.GasFeeCap()) <= 0 {
+		t.Error("Replacement fees were not raised")
+	}
+	receipt, err := waitWithCommits(t, client, manager, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != replacement.Hash() || receipt.Transaction.Hash() != replacement.Hash() {
+		t.Error("Receipt is not for the replacement")
+	}
+
+	// Cancel a transaction
+	tx, err = manager.Send(context.Background(), toAddress, nil, &sendOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Rollback()
+	cancellation, err := manager.Cancel(context.Background(), tx, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cancellation.To() != opts.From || cancellation.Value().Sign() != 0 {
+		t.Error("Cancellation is not an empty transfer to the sender")
+	}
+	if _, err := waitWithCommits(t, client, manager, tx); err != nil {
+		t.Fatal(err)
+	}
+	if !tx.IsCancelled {
+		t.Error("Transaction was not marked as cancelled")
+	}
+	if balance, err := client.BalanceAt(context.Background(), toAddress, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Int64() != 1 {
+		t.Errorf("Incorrect balance %s", balance.String())
+	}
+
+}
+
+func TestFailedAutomaticReplacement(t *testing.T) {
+
+	// Automatic replacements can't raise the max fee at all
+	client, key := newSimulatedClient(t)
+	feeCap := big.NewInt(100e9)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond, ReplaceAfter: time.Millisecond, MaxFeeCap: feeCap})
+	opts := getTransactor(t, key)
+	opts.GasFeeCap = feeCap
+	opts.GasTipCap = big.NewInt(1e9)
+	opts.Value = big.NewInt(1)
+	tx, err := manager.Send(context.Background(), common.HexToAddress("0x1000000000000000000000000000000000000001"), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := tx.Latest()
+
+	// Waiting continues until the original is mined
+	receipt, err := waitWithCommits(t, client, manager, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != original.Hash() {
+		t.Error("Receipt is not for the original transaction")
+	}
+	if len(tx.GetAttempts()) != 1 {
+		t.Errorf("Expected only the original attempt but got %d", len(tx.GetAttempts()))
+	}
+	if len(tx.GetReplacementErrors()) == 0 {
+		t.Error("Failed replacements were not recorded")
+	}
+
+}
+
+func TestReceiptEvents(t *testing.T) {
+
+	client, key := newSimulatedClient(t)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond, Confirmations: 3})
+	opts := getTransactor(t, key)
+
+	// Deploy the pinger
+	parsed, err := abi.JSON(strings.NewReader(pingerAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, bound, err := bind.DeployContract(opts, parsed, hexutil.MustDecode(pingerBytecode), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+	contract := &rocketpool.Contract{
+		Contract: bound,
+		Address:  &address,
+		ABI:      &parsed,
+		Client:   client,
+	}
+
+	// Nonces are picked up after transactions sent outside the manager
+	tx, err := manager.Transact(context.Background(), contract, opts, "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce != 1 {
+		t.Errorf("Incorrect nonce %d", tx.Nonce)
+	}
+
+	receipt, err := waitWithCommits(t, client, manager, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Confirmations < 3 {
+		t.Errorf("Receipt returned with %d confirmations", receipt.Confirmations)
+	}
+	pings := receipt.GetEvents("Ping")
+	if len(pings) != 1 || len(receipt.Events) != 1 {
+		t.Fatalf("Incorrect events %v", receipt.Events)
+	}
+	if pings[0].Log.Address != address || pings[0].Contract != contract {
+		t.Error("Event was decoded with the wrong contract")
+	}
+
+}
+
+func TestReorg(t *testing.T) {
+
+	client, key := newSimulatedClient(t)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond, Confirmations: 2})
+	opts := getTransactor(t, key)
+	toAddress := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Mine the transaction
+	sendOpts := *opts
+	sendOpts.Value = big.NewInt(1)
+	parent := client.Blockchain().CurrentBlock().Hash()
+	tx, err := manager.Send(context.Background(), toAddress, nil, &sendOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+
+	// Wait in the background until the mined receipt has been seen
+	type result struct {
+		receipt *txmgr.Receipt
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := manager.Wait(context.Background(), tx)
+		done <- result{receipt, err}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// Reorg it out with a longer empty chain
+	if err := client.Fork(context.Background(), parent); err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+	client.Commit()
+	time.Sleep(100 * time.Millisecond)
+
+	// Re-include it and confirm it
+	if err := client.SendTransaction(context.Background(), tx.Latest()); err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+	client.Commit()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for transaction")
+	}
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if r.receipt.Reorgs < 1 {
+		t.Error("Reorg was not detected")
+	}
+	if r.receipt.BlockNumber.Uint64() != 3 {
+		t.Errorf("Receipt is from block %s", r.receipt.BlockNumber.String())
+	}
+
+}
+
+func TestNonceConsumed(t *testing.T) {
+
+	client, key := newSimulatedClient(t)
+	manager := txmgr.NewManager(client, chainID, nil, txmgr.Config{PollInterval: time.Millisecond})
+	opts := getTransactor(t, key)
+	toAddress := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Drop the managed transaction and use its nonce elsewhere
+	tx, err := manager.Send(context.Background(), toAddress, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Rollback()
+	externalOpts := *opts
+	externalOpts.GasTipCap = tx.Latest().GasTipCap()
+	externalOpts.GasFeeCap = tx.Latest().GasFeeCap()
+	if _, err := eth.SendTransaction(client, toAddress, chainID, nil, false, &externalOpts); err != nil {
+		t.Fatal(err)
+	}
+	client.Commit()
+
+	if _, err := manager.Wait(context.Background(), tx); !errors.Is(err, txmgr.ErrNonceConsumed) {
+		t.Errorf("Expected a consumed nonce error, got %v", err)
+	}
+
+}
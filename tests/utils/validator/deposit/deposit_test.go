@@ -0,0 +1,121 @@
+package deposit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+func TestSignatures(t *testing.T) {
+
+	// The public key of 1 is the G1 generator
+	pubkey, err := validator.GetPubkey(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pubkey.Hex() != "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" {
+		t.Errorf("Incorrect pubkey %s", pubkey.Hex())
+	}
+
+	// Consensus spec signing vector
+	secretKey, _ := big.NewInt(0).SetString("263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3", 16)
+	message := common.HexToHash("0x5656565656565656565656565656565656565656565656565656565656565656")
+	signature, err := validator.Sign(secretKey, message[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature.Hex() != "882730e5d03f6b42c3abc26d3372625034e1d871b65a8a6b900a56dae22da98abbe1b68f85e49fe7652a55ec3d0591c20767677e33e5cbb1207315c41a9ac03be39c2e7668edc043d6cb1d9fd93033caa8a1c5b0e84bedaeb6c64972503a43eb" {
+		t.Errorf("Incorrect signature %s", signature.Hex())
+	}
+	pubkey, err = validator.GetPubkey(secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := validator.Verify(pubkey, message[:], signature); err != nil || !valid {
+		t.Errorf("Signature did not verify: %v", err)
+	}
+	if valid, err := validator.Verify(pubkey, []byte("other"), signature); err != nil || valid {
+		t.Errorf("Signature verified for the wrong message: %v", err)
+	}
+
+	// Malformed encodings are rejected
+	if _, err := validator.Verify(types.ValidatorPubkey{}, message[:], signature); err == nil {
+		t.Error("Verified with an uncompressed pubkey")
+	}
+	badSignature := signature
+	badSignature[95] ^= 0x01
+	if valid, err := validator.Verify(pubkey, message[:], badSignature); err == nil && valid {
+		t.Error("Verified a tampered signature")
+	}
+	infinity := make([]byte, 96)
+	infinity[0] = 0xc0
+	if _, err := validator.Verify(types.BytesToValidatorPubkey(infinity[:48]), message[:], signature); err == nil {
+		t.Error("Verified with the infinity pubkey")
+	}
+	if valid, err := validator.Verify(pubkey, message[:], types.BytesToValidatorSignature(infinity)); err != nil || valid {
+		t.Errorf("Verified the infinity signature: %v", err)
+	}
+	if _, err := validator.Sign(big.NewInt(0), message[:]); err == nil {
+		t.Error("Signed with a zero secret key")
+	}
+
+}
+
+func TestDepositData(t *testing.T) {
+
+	// Deposit domains
+	domain, err := validator.GetDepositDomain(validator.MainnetGenesisForkVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.Hex() != "0x03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9" {
+		t.Errorf("Incorrect mainnet deposit domain %s", domain.Hex())
+	}
+
+	// Withdrawal credentials
+	minipoolAddress := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	withdrawalCredentials := validator.GetAddressWithdrawalCredentials(minipoolAddress)
+	if withdrawalCredentials.Hex() != "0x0100000000000000000000001234567890123456789012345678901234567890" {
+		t.Errorf("Incorrect withdrawal credentials %s", withdrawalCredentials.Hex())
+	}
+
+	// Create and verify deposit data
+	secretKey := big.NewInt(0).SetBytes(hexutil.MustDecode("0x1d5d76e3bc2ef0a4cbd0df6328a04e5d1c38d05a42e1a6a0ac4d58e1e7d4f5b2"))
+	data, err := validator.CreateDepositData(secretKey, withdrawalCredentials, validator.PrelaunchDepositAmount, validator.HoleskyGenesisForkVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.VerifyDepositData(data, validator.HoleskyGenesisForkVersion); err != nil {
+		t.Error(err)
+	}
+	root, err := validator.GetDepositDataRoot(data.Pubkey, data.WithdrawalCredentials, data.Amount, data.Signature)
+	if err != nil || root != data.DepositDataRoot {
+		t.Errorf("Incorrect deposit data root %s: %v", root.Hex(), err)
+	}
+
+	// Deposits for other forks, credentials, amounts or roots are rejected
+	if err := validator.VerifyDepositData(data, validator.MainnetGenesisForkVersion); err == nil {
+		t.Error("Verified a deposit against the wrong fork")
+	}
+	modified := data
+	modified.WithdrawalCredentials = validator.GetAddressWithdrawalCredentials(common.HexToAddress("0x01"))
+	if err := validator.VerifyDepositData(modified, validator.HoleskyGenesisForkVersion); err == nil {
+		t.Error("Verified a deposit with the wrong withdrawal credentials")
+	}
+	modified = data
+	modified.Amount = validator.StakeDepositAmount
+	if err := validator.VerifyDepositData(modified, validator.HoleskyGenesisForkVersion); err == nil {
+		t.Error("Verified a deposit with the wrong amount")
+	}
+	modified = data
+	modified.DepositDataRoot = common.Hash{}
+	if err := validator.VerifyDepositData(modified, validator.HoleskyGenesisForkVersion); err == nil {
+		t.Error("Verified a deposit with the wrong deposit data root")
+	}
+
+}
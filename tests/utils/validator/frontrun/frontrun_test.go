@@ -0,0 +1,192 @@
+package frontrun
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/tests/testutils/fakeclient"
+	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+var (
+	forkVersion     = validator.MainnetGenesisForkVersion
+	secretKey       = big.NewInt(0xdeadbeef)
+	minipoolAddress = common.HexToAddress("0xa000000000000000000000000000000000000001")
+	attackerAddress = common.HexToAddress("0xb000000000000000000000000000000000000002")
+)
+
+// Create a deposit log entry
+func getDeposit(t *testing.T, withdrawalAddress common.Address, block uint64, validSignature bool) utils.DepositData {
+	data, err := validator.CreateDepositData(secretKey, validator.GetAddressWithdrawalCredentials(withdrawalAddress), validator.PrelaunchDepositAmount, forkVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validSignature {
+		data.Amount++
+	}
+	return utils.DepositData{
+		Pubkey:                data.Pubkey,
+		WithdrawalCredentials: data.WithdrawalCredentials,
+		Amount:                data.Amount,
+		Signature:             data.Signature,
+		BlockNumber:           block,
+	}
+}
+
+func TestCheckPrestakeDeposits(t *testing.T) {
+
+	minipoolDeposit := getDeposit(t, minipoolAddress, 100, true)
+	prestake := minipool.PrestakeData{
+		Pubkey:                minipoolDeposit.Pubkey,
+		WithdrawalCredentials: minipoolDeposit.WithdrawalCredentials,
+		Signature:             minipoolDeposit.Signature,
+	}
+	frontrun := getDeposit(t, attackerAddress, 90, true)
+	invalidFrontrun := getDeposit(t, attackerAddress, 80, false)
+	invalidMinipoolDeposit := getDeposit(t, minipoolAddress, 100, false)
+
+	cases := []struct {
+		name     string
+		deposits []utils.DepositData
+		reason   validator.FrontrunReason
+		evidence utils.DepositData
+	}{
+		{"only the minipool deposit", []utils.DepositData{minipoolDeposit}, "", utils.DepositData{}},
+		{"later deposit", []utils.DepositData{minipoolDeposit, frontrun}, "", utils.DepositData{}},
+		{"invalid frontrun", []utils.DepositData{invalidFrontrun, minipoolDeposit}, "", utils.DepositData{}},
+		{"no deposits", []utils.DepositData{}, "", utils.DepositData{}},
+		{"frontrun", []utils.DepositData{frontrun, minipoolDeposit}, validator.FrontrunReason_WrongWithdrawalCredentials, frontrun},
+		{"frontrun after invalid deposit", []utils.DepositData{invalidFrontrun, frontrun, minipoolDeposit}, validator.FrontrunReason_WrongWithdrawalCredentials, frontrun},
+		{"invalid minipool deposit", []utils.DepositData{invalidMinipoolDeposit, frontrun}, validator.FrontrunReason_InvalidSignature, invalidMinipoolDeposit},
+	}
+	for _, c := range cases {
+		report, found := validator.CheckPrestakeDeposits(minipoolAddress, prestake, c.deposits, forkVersion)
+		if found != (c.reason != "") {
+			t.Errorf("%s: incorrect scrub result %t", c.name, found)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if report.Reason != c.reason || report.OffendingDeposit != c.evidence || report.MinipoolAddress != minipoolAddress || len(report.Deposits) != len(c.deposits) {
+			t.Errorf("%s: incorrect report %+v", c.name, report)
+		}
+	}
+
+	// Deposits signed for another network are treated as invalid
+	if _, found := validator.CheckPrestakeDeposits(minipoolAddress, prestake, []utils.DepositData{minipoolDeposit}, validator.HoleskyGenesisForkVersion); !found {
+		t.Error("Accepted a deposit signed for another network")
+	}
+
+}
+
+const (
+	minipoolManagerAbi = `[
+		{"type":"function","name":"getMinipoolCount","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"getPrelaunchMinipools","stateMutability":"view","inputs":[{"name":"offset","type":"uint256"},{"name":"limit","type":"uint256"}],"outputs":[{"name":"","type":"address[]"}]}
+	]`
+	minipoolAbi = `[
+		{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+		{"type":"function","name":"getVacant","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"event","name":"MinipoolPrestaked","inputs":[{"name":"validatorPubkey","type":"bytes","indexed":false},{"name":"validatorSignature","type":"bytes","indexed":false},{"name":"depositDataRoot","type":"bytes32","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"withdrawalCredentials","type":"bytes","indexed":false},{"name":"time","type":"uint256","indexed":false}],"anonymous":false}
+	]`
+	casperDepositAbi = `[{"type":"event","name":"DepositEvent","inputs":[{"name":"pubkey","type":"bytes","indexed":false},{"name":"withdrawal_credentials","type":"bytes","indexed":false},{"name":"amount","type":"bytes","indexed":false},{"name":"signature","type":"bytes","indexed":false},{"name":"index","type":"bytes","indexed":false}],"anonymous":false}]`
+)
+
+var (
+	minipoolManagerAddress = common.HexToAddress("0x2000000000000000000000000000000000000001")
+	casperDepositAddress   = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	vacantMinipoolAddress  = common.HexToAddress("0xa000000000000000000000000000000000000002")
+)
+
+// Create a log for an event with non-indexed arguments
+func getLog(t *testing.T, abiJson string, eventName string, address common.Address, block uint64, args ...interface{}) types.Log {
+	parsed, err := abi.JSON(strings.NewReader(abiJson))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events[eventName]
+	data, err := event.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Log{
+		Address:     address,
+		Topics:      []common.Hash{event.ID},
+		Data:        data,
+		BlockNumber: block,
+	}
+}
+
+// Create a deposit contract log for a deposit
+func getDepositLog(t *testing.T, deposit utils.DepositData, index uint64) types.Log {
+	amount := make([]byte, 8)
+	binary.LittleEndian.PutUint64(amount, deposit.Amount)
+	indexBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(indexBytes, index)
+	return getLog(t, casperDepositAbi, "DepositEvent", casperDepositAddress, deposit.BlockNumber, deposit.Pubkey.Bytes(), deposit.WithdrawalCredentials.Bytes(), amount, deposit.Signature.Bytes(), indexBytes)
+}
+
+func TestGetFrontrunMinipools(t *testing.T) {
+
+	// A frontrun prelaunch minipool and a vacant one migrated from a solo validator
+	client := fakeclient.New(t)
+	client.LatestBlock = 20000
+	client.SetUint(crypto.Keccak256Hash([]byte("deploy.block")), big.NewInt(1))
+	client.SetContract("rocketMinipoolManager", minipoolManagerAddress, minipoolManagerAbi)
+	client.SetContract("casperDeposit", casperDepositAddress, casperDepositAbi)
+	client.SetCallHandler(minipoolManagerAddress, minipoolManagerAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+		if method.Name == "getMinipoolCount" {
+			return []interface{}{big.NewInt(2)}, nil
+		}
+		return []interface{}{[]common.Address{vacantMinipoolAddress, minipoolAddress}}, nil
+	})
+	for _, address := range []common.Address{minipoolAddress, vacantMinipoolAddress} {
+		vacant := address == vacantMinipoolAddress
+		client.SetCallHandler(address, minipoolAbi, func(method *abi.Method, args []interface{}) ([]interface{}, error) {
+			if method.Name == "version" {
+				return []interface{}{uint8(3)}, nil
+			}
+			return []interface{}{vacant}, nil
+		})
+	}
+
+	minipoolDeposit := getDeposit(t, minipoolAddress, 15000, true)
+	frontrun := getDeposit(t, attackerAddress, 14990, true)
+	client.Logs = []types.Log{
+		getDepositLog(t, frontrun, 0),
+		getDepositLog(t, minipoolDeposit, 1),
+		getLog(t, minipoolAbi, "MinipoolPrestaked", minipoolAddress, 15000, minipoolDeposit.Pubkey.Bytes(), minipoolDeposit.Signature.Bytes(), [32]byte{}, big.NewInt(1e18), minipoolDeposit.WithdrawalCredentials.Bytes(), big.NewInt(0)),
+	}
+	rp := fakeclient.NewRocketPool(t, client)
+
+	reports, vacantMinipools, err := validator.GetFrontrunMinipools(rp, forkVersion, big.NewInt(0), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].MinipoolAddress != minipoolAddress || reports[0].Reason != validator.FrontrunReason_WrongWithdrawalCredentials || reports[0].OffendingDeposit.BlockNumber != frontrun.BlockNumber {
+		t.Errorf("Incorrect reports %+v", reports)
+	}
+
+	// Vacant minipools are reported separately without looking for a prestake event
+	if len(vacantMinipools) != 1 || vacantMinipools[0] != vacantMinipoolAddress {
+		t.Errorf("Incorrect vacant minipools %v", vacantMinipools)
+	}
+	for _, query := range client.GetFilterQueries() {
+		for _, address := range query.Addresses {
+			if address == vacantMinipoolAddress {
+				t.Errorf("Searched for a prestake event of the vacant minipool: %+v", query)
+			}
+		}
+	}
+
+}
@@ -2,9 +2,11 @@ package types
 
 import (
 	"fmt"
+	"time"
 
 	"encoding/hex"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/rocket-pool/rocketpool-go/utils/json"
 )
 
@@ -103,3 +105,59 @@ func (v *ValidatorSignature) UnmarshalJSON(data []byte) error {
 	}
 	return err
 }
+
+// Validator states, as reported by the Beacon API
+type ValidatorState string
+
+const (
+	ValidatorState_PendingInitialized ValidatorState = "pending_initialized"
+	ValidatorState_PendingQueued      ValidatorState = "pending_queued"
+	ValidatorState_ActiveOngoing      ValidatorState = "active_ongoing"
+	ValidatorState_ActiveExiting      ValidatorState = "active_exiting"
+	ValidatorState_ActiveSlashed      ValidatorState = "active_slashed"
+	ValidatorState_ExitedUnslashed    ValidatorState = "exited_unslashed"
+	ValidatorState_ExitedSlashed      ValidatorState = "exited_slashed"
+	ValidatorState_WithdrawalPossible ValidatorState = "withdrawal_possible"
+	ValidatorState_WithdrawalDone     ValidatorState = "withdrawal_done"
+)
+
+// Check if a validator in this state is active
+func (s ValidatorState) IsActive() bool {
+	return s == ValidatorState_ActiveOngoing || s == ValidatorState_ActiveExiting || s == ValidatorState_ActiveSlashed
+}
+
+// Check if a validator in this state has exited
+func (s ValidatorState) IsExited() bool {
+	return s == ValidatorState_ExitedUnslashed || s == ValidatorState_ExitedSlashed || s == ValidatorState_WithdrawalPossible || s == ValidatorState_WithdrawalDone
+}
+
+// A validator's status on the Beacon chain; balances are in gwei
+type ValidatorStatus struct {
+	Pubkey                     ValidatorPubkey `json:"pubkey"`
+	Index                      uint64          `json:"index"`
+	WithdrawalCredentials      common.Hash     `json:"withdrawalCredentials"`
+	Balance                    uint64          `json:"balance"`
+	EffectiveBalance           uint64          `json:"effectiveBalance"`
+	State                      ValidatorState  `json:"state"`
+	Slashed                    bool            `json:"slashed"`
+	ActivationEligibilityEpoch uint64          `json:"activationEligibilityEpoch"`
+	ActivationEpoch            uint64          `json:"activationEpoch"`
+	ExitEpoch                  uint64          `json:"exitEpoch"`
+	WithdrawableEpoch          uint64          `json:"withdrawableEpoch"`
+}
+
+// A Beacon chain block
+type BeaconBlock struct {
+	Slot                 uint64      `json:"slot"`
+	ProposerIndex        uint64      `json:"proposerIndex"`
+	HasExecutionPayload  bool        `json:"hasExecutionPayload"`
+	ExecutionBlockNumber uint64      `json:"executionBlockNumber"`
+	ExecutionBlockHash   common.Hash `json:"executionBlockHash"`
+}
+
+// The Beacon chain's genesis details
+type BeaconGenesis struct {
+	GenesisTime           time.Time   `json:"genesisTime"`
+	GenesisValidatorsRoot common.Hash `json:"genesisValidatorsRoot"`
+	GenesisForkVersion    []byte      `json:"genesisForkVersion"`
+}
@@ -0,0 +1,70 @@
+package beacon
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// An in-memory Beacon chain client for tests and offline replays.
+// Validator statuses are the same at every state.
+type FakeClient struct {
+	Genesis    types.BeaconGenesis
+	Blocks     map[string]types.BeaconBlock
+	Validators map[types.ValidatorPubkey]types.ValidatorStatus
+	lock       sync.Mutex
+}
+
+// Create a new fake client
+func NewFakeClient(genesis types.BeaconGenesis) *FakeClient {
+	return &FakeClient{
+		Genesis:    genesis,
+		Blocks:     map[string]types.BeaconBlock{},
+		Validators: map[types.ValidatorPubkey]types.ValidatorStatus{},
+	}
+}
+
+// Add a block, which can be looked up by its slot or by any of the extra IDs (e.g. "head")
+func (c *FakeClient) SetBlock(block types.BeaconBlock, ids ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Blocks[strconv.FormatUint(block.Slot, 10)] = block
+	for _, id := range ids {
+		c.Blocks[id] = block
+	}
+}
+
+// Add or replace a validator
+func (c *FakeClient) SetValidator(status types.ValidatorStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.Validators[status.Pubkey] = status
+}
+
+// Get the chain's genesis details
+func (c *FakeClient) GetGenesis(ctx context.Context) (types.BeaconGenesis, error) {
+	return c.Genesis, nil
+}
+
+// Get a block, or false if there is no block for the ID
+func (c *FakeClient) GetBeaconBlock(ctx context.Context, blockID string) (types.BeaconBlock, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	block, exists := c.Blocks[blockID]
+	return block, exists, nil
+}
+
+// Get the statuses of validators
+func (c *FakeClient) GetValidatorStatuses(ctx context.Context, pubkeys []types.ValidatorPubkey, stateID string) (map[types.ValidatorPubkey]types.ValidatorStatus, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	statuses := make(map[types.ValidatorPubkey]types.ValidatorStatus, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		if status, exists := c.Validators[pubkey]; exists {
+			statuses[pubkey] = status
+		}
+	}
+	return statuses, nil
+}
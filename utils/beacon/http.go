@@ -0,0 +1,222 @@
+package beacon
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// Settings
+const (
+	DefaultHttpTimeout             = 30 * time.Second
+	ValidatorStatusBatchSize   int = 64
+	validatorStatusThreadLimit     = 4
+)
+
+// A Beacon chain client that uses the standard Beacon API over HTTP
+type HttpClient struct {
+	Url    string
+	Client *http.Client
+}
+
+// Create a new Beacon API client for a node URL
+func NewHttpClient(url string, timeout time.Duration) *HttpClient {
+	if timeout == 0 {
+		timeout = DefaultHttpTimeout
+	}
+	return &HttpClient{
+		Url: strings.TrimSuffix(url, "/"),
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// A uint64 that the Beacon API encodes as a decimal string
+type quotedUint64 uint64
+
+func (q *quotedUint64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("error parsing Beacon API integer %s: %w", string(data), err)
+	}
+	*q = quotedUint64(value)
+	return nil
+}
+
+// Response types
+type genesisResponse struct {
+	Data struct {
+		GenesisTime           quotedUint64 `json:"genesis_time"`
+		GenesisValidatorsRoot common.Hash  `json:"genesis_validators_root"`
+		GenesisForkVersion    string       `json:"genesis_fork_version"`
+	} `json:"data"`
+}
+type blockResponse struct {
+	Data struct {
+		Message struct {
+			Slot          quotedUint64 `json:"slot"`
+			ProposerIndex quotedUint64 `json:"proposer_index"`
+			Body          struct {
+				ExecutionPayload *struct {
+					BlockNumber quotedUint64 `json:"block_number"`
+					BlockHash   common.Hash  `json:"block_hash"`
+				} `json:"execution_payload"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+type validatorsResponse struct {
+	Data []struct {
+		Index     quotedUint64 `json:"index"`
+		Balance   quotedUint64 `json:"balance"`
+		Status    string       `json:"status"`
+		Validator struct {
+			Pubkey                     string       `json:"pubkey"`
+			WithdrawalCredentials      common.Hash  `json:"withdrawal_credentials"`
+			EffectiveBalance           quotedUint64 `json:"effective_balance"`
+			Slashed                    bool         `json:"slashed"`
+			ActivationEligibilityEpoch quotedUint64 `json:"activation_eligibility_epoch"`
+			ActivationEpoch            quotedUint64 `json:"activation_epoch"`
+			ExitEpoch                  quotedUint64 `json:"exit_epoch"`
+			WithdrawableEpoch          quotedUint64 `json:"withdrawable_epoch"`
+		} `json:"validator"`
+	} `json:"data"`
+}
+
+// Get the chain's genesis details
+func (c *HttpClient) GetGenesis(ctx context.Context) (types.BeaconGenesis, error) {
+	var response genesisResponse
+	if _, err := c.get(ctx, "/eth/v1/beacon/genesis", &response); err != nil {
+		return types.BeaconGenesis{}, fmt.Errorf("error getting genesis: %w", err)
+	}
+	forkVersion, err := hex.DecodeString(strings.TrimPrefix(response.Data.GenesisForkVersion, "0x"))
+	if err != nil {
+		return types.BeaconGenesis{}, fmt.Errorf("error decoding genesis fork version: %w", err)
+	}
+	return types.BeaconGenesis{
+		GenesisTime:           time.Unix(int64(response.Data.GenesisTime), 0).UTC(),
+		GenesisValidatorsRoot: response.Data.GenesisValidatorsRoot,
+		GenesisForkVersion:    forkVersion,
+	}, nil
+}
+
+// Get a block, or false if there is no block for the ID
+func (c *HttpClient) GetBeaconBlock(ctx context.Context, blockID string) (types.BeaconBlock, bool, error) {
+	var response blockResponse
+	found, err := c.get(ctx, fmt.Sprintf("/eth/v2/beacon/blocks/%s", blockID), &response)
+	if err != nil {
+		return types.BeaconBlock{}, false, fmt.Errorf("error getting beacon block %s: %w", blockID, err)
+	}
+	if !found {
+		return types.BeaconBlock{}, false, nil
+	}
+	message := response.Data.Message
+	block := types.BeaconBlock{
+		Slot:          uint64(message.Slot),
+		ProposerIndex: uint64(message.ProposerIndex),
+	}
+	if payload := message.Body.ExecutionPayload; payload != nil {
+		block.HasExecutionPayload = true
+		block.ExecutionBlockNumber = uint64(payload.BlockNumber)
+		block.ExecutionBlockHash = payload.BlockHash
+	}
+	return block, true, nil
+}
+
+// Get the statuses of validators at a state, querying them in batches
+func (c *HttpClient) GetValidatorStatuses(ctx context.Context, pubkeys []types.ValidatorPubkey, stateID string) (map[types.ValidatorPubkey]types.ValidatorStatus, error) {
+	statuses := make(map[types.ValidatorPubkey]types.ValidatorStatus, len(pubkeys))
+	var lock sync.Mutex
+	var wg errgroup.Group
+	wg.SetLimit(validatorStatusThreadLimit)
+	for i := 0; i < len(pubkeys); i += ValidatorStatusBatchSize {
+		i := i
+		max := i + ValidatorStatusBatchSize
+		if max > len(pubkeys) {
+			max = len(pubkeys)
+		}
+
+		wg.Go(func() error {
+			ids := make([]string, 0, max-i)
+			for _, pubkey := range pubkeys[i:max] {
+				ids = append(ids, "0x"+pubkey.Hex())
+			}
+			var response validatorsResponse
+			found, err := c.get(ctx, fmt.Sprintf("/eth/v1/beacon/states/%s/validators?id=%s", stateID, strings.Join(ids, ",")), &response)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("state %s was not found", stateID)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			for _, data := range response.Data {
+				pubkey, err := types.HexToValidatorPubkey(strings.TrimPrefix(data.Validator.Pubkey, "0x"))
+				if err != nil {
+					return err
+				}
+				statuses[pubkey] = types.ValidatorStatus{
+					Pubkey:                     pubkey,
+					Index:                      uint64(data.Index),
+					WithdrawalCredentials:      data.Validator.WithdrawalCredentials,
+					Balance:                    uint64(data.Balance),
+					EffectiveBalance:           uint64(data.Validator.EffectiveBalance),
+					State:                      types.ValidatorState(data.Status),
+					Slashed:                    data.Validator.Slashed,
+					ActivationEligibilityEpoch: uint64(data.Validator.ActivationEligibilityEpoch),
+					ActivationEpoch:            uint64(data.Validator.ActivationEpoch),
+					ExitEpoch:                  uint64(data.Validator.ExitEpoch),
+					WithdrawableEpoch:          uint64(data.Validator.WithdrawableEpoch),
+				}
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting validator statuses at state %s: %w", stateID, err)
+	}
+	return statuses, nil
+}
+
+// Make a GET request and decode the response, returning false if the resource wasn't found
+func (c *HttpClient) get(ctx context.Context, path string, response interface{}) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Url+path, nil)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Accept", "application/json")
+	httpResponse, err := c.Client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer httpResponse.Body.Close()
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return false, fmt.Errorf("error reading response body: %w", err)
+	}
+	if httpResponse.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("request failed with status %d: %s", httpResponse.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return false, fmt.Errorf("error decoding response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package beacon
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// A minipool validator joined with its Beacon chain status
+type Validator struct {
+	Pubkey types.ValidatorPubkey `json:"pubkey"`
+
+	// False if the validator isn't on the Beacon chain yet
+	Exists bool `json:"exists"`
+
+	// The validator's status; empty if it doesn't exist
+	Status types.ValidatorStatus `json:"status"`
+
+	// The validator's balance in wei
+	Balance *big.Int `json:"balance"`
+}
+
+// Get the Beacon chain status of each of a node's validating minipools
+func GetNodeValidators(ctx context.Context, rp *rocketpool.RocketPool, bc rocketpool.BeaconClient, nodeAddress common.Address, stateID string, opts *bind.CallOpts) ([]Validator, error) {
+	pubkeys, err := minipool.GetNodeValidatingMinipoolPubkeys(rp, nodeAddress, opts)
+	if err != nil {
+		return nil, err
+	}
+	return GetValidators(ctx, bc, pubkeys, stateID)
+}
+
+// Get the Beacon chain status of a list of validators, in the same order as the pubkeys
+func GetValidators(ctx context.Context, bc rocketpool.BeaconClient, pubkeys []types.ValidatorPubkey, stateID string) ([]Validator, error) {
+	statuses, err := bc.GetValidatorStatuses(ctx, pubkeys, stateID)
+	if err != nil {
+		return nil, err
+	}
+	validators := make([]Validator, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		status, exists := statuses[pubkey]
+		validators[i] = Validator{
+			Pubkey:  pubkey,
+			Exists:  exists,
+			Status:  status,
+			Balance: GweiToWei(status.Balance),
+		}
+	}
+	return validators, nil
+}
+
+// Get the Beacon chain balances of a list of validators in wei, in the same order as the pubkeys.
+// Validators that aren't on the Beacon chain yet have a balance of zero.
+func GetBalances(ctx context.Context, bc rocketpool.BeaconClient, pubkeys []types.ValidatorPubkey, stateID string) ([]*big.Int, error) {
+	validators, err := GetValidators(ctx, bc, pubkeys, stateID)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(validators))
+	for i, validator := range validators {
+		balances[i] = validator.Balance
+	}
+	return balances, nil
+}
+
+// Convert a Beacon chain amount in gwei to wei
+func GweiToWei(gwei uint64) *big.Int {
+	wei := big.NewInt(0).SetUint64(gwei)
+	return wei.Mul(wei, big.NewInt(1e9))
+}
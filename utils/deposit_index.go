@@ -0,0 +1,330 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	rptypes "github.com/rocket-pool/rocketpool-go/types"
+)
+
+// Settings
+const (
+	DepositIndexReorgDepth     uint64 = 64
+	DepositIndexFollowDistance uint64 = 8
+)
+
+// The last block a deposit index has been synced to
+type DepositIndexCheckpoint struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+}
+
+// An entry in the index file; deposits are only valid once they're followed by a checkpoint
+type depositIndexRecord struct {
+	Deposit    *DepositData            `json:"deposit,omitempty"`
+	Checkpoint *DepositIndexCheckpoint `json:"checkpoint,omitempty"`
+	Rollback   bool                    `json:"rollback,omitempty"`
+}
+
+// An append-only, file-backed index of the deposit contract's deposit events
+type DepositIndex struct {
+	file       *os.File
+	checkpoint *DepositIndexCheckpoint
+	deposits   []DepositData
+	byPubkey   map[rptypes.ValidatorPubkey][]int
+	lock       sync.RWMutex
+}
+
+// Open a deposit index file, creating it if it doesn't exist.
+// Any deposits written after the last checkpoint (e.g. by an interrupted update) are discarded.
+func OpenDepositIndex(path string) (*DepositIndex, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening deposit index %s: %w", path, err)
+	}
+	index := &DepositIndex{
+		file:     file,
+		deposits: []DepositData{},
+		byPubkey: map[rptypes.ValidatorPubkey][]int{},
+	}
+	if err := index.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error loading deposit index %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// Close the index file
+func (i *DepositIndex) Close() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.file.Close()
+}
+
+// Get the last block the index has been synced to
+func (i *DepositIndex) GetCheckpoint() (DepositIndexCheckpoint, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	if i.checkpoint == nil {
+		return DepositIndexCheckpoint{}, false
+	}
+	return *i.checkpoint, true
+}
+
+// Get the number of indexed deposits
+func (i *DepositIndex) GetDepositCount() int {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return len(i.deposits)
+}
+
+// Gets all of the indexed deposit events for the provided pubkeys, in the same form as GetDeposits
+func (i *DepositIndex) GetDeposits(pubkeys map[rptypes.ValidatorPubkey]bool) map[rptypes.ValidatorPubkey][]DepositData {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	depositMap := make(map[rptypes.ValidatorPubkey][]DepositData, len(pubkeys))
+	for pubkey := range pubkeys {
+		positions, exists := i.byPubkey[pubkey]
+		if !exists {
+			continue
+		}
+		deposits := make([]DepositData, len(positions))
+		for j, position := range positions {
+			deposits[j] = i.deposits[position]
+		}
+		sortDepositData(deposits)
+		depositMap[pubkey] = deposits
+	}
+	return depositMap
+}
+
+// Sync the index to the block in opts (or DepositIndexFollowDistance blocks behind the latest block if opts is nil).
+// An empty index starts from startBlock. If the last checkpoint is no longer canonical, the last DepositIndexReorgDepth blocks are rolled back and rescanned.
+// The target block's hash is read before the scan and checked again after it, so nothing is indexed if the target block is reorged during the update.
+func (i *DepositIndex) Update(rp *rocketpool.RocketPool, startBlock *big.Int, intervalSize *big.Int, opts *bind.CallOpts) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	// Get the target block
+	var targetBlock uint64
+	if opts != nil && opts.BlockNumber != nil {
+		targetBlock = opts.BlockNumber.Uint64()
+	} else {
+		latestBlock, err := rp.Client.BlockNumber(context.Background())
+		if err != nil {
+			return fmt.Errorf("error getting latest block: %w", err)
+		}
+		if latestBlock < DepositIndexFollowDistance {
+			return nil
+		}
+		targetBlock = latestBlock - DepositIndexFollowDistance
+	}
+
+	// Roll back if the last checkpoint was reorged out
+	if i.checkpoint != nil {
+		hash, err := getBlockHash(rp, i.checkpoint.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if hash != i.checkpoint.BlockHash {
+			var rollbackBlock uint64
+			if i.checkpoint.BlockNumber > DepositIndexReorgDepth {
+				rollbackBlock = i.checkpoint.BlockNumber - DepositIndexReorgDepth
+			}
+			rollbackHash, err := getBlockHash(rp, rollbackBlock)
+			if err != nil {
+				return err
+			}
+			if err := i.rollback(DepositIndexCheckpoint{BlockNumber: rollbackBlock, BlockHash: rollbackHash}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Get the block range to scan
+	fromBlock := startBlock.Uint64()
+	if i.checkpoint != nil {
+		fromBlock = i.checkpoint.BlockNumber + 1
+	}
+	if fromBlock > targetBlock {
+		return nil
+	}
+
+	// Get the new deposits, making sure the target block stayed canonical while they were retrieved
+	targetHash, err := getBlockHash(rp, targetBlock)
+	if err != nil {
+		return err
+	}
+	casperDeposit, err := getCasperDeposit(rp, opts)
+	if err != nil {
+		return err
+	}
+	targetBlockBig := big.NewInt(0).SetUint64(targetBlock)
+	deposits, err := getDepositEvents(rp, casperDeposit, nil, big.NewInt(0).SetUint64(fromBlock), targetBlockBig, intervalSize)
+	if err != nil {
+		return fmt.Errorf("error getting deposits from block %d to %d: %w", fromBlock, targetBlock, err)
+	}
+	finalHash, err := getBlockHash(rp, targetBlock)
+	if err != nil {
+		return err
+	}
+	if finalHash != targetHash {
+		return fmt.Errorf("block %d was reorged while retrieving deposits; the update will need to be retried", targetBlock)
+	}
+	return i.append(deposits, DepositIndexCheckpoint{BlockNumber: targetBlock, BlockHash: targetHash})
+}
+
+// Add deposits retrieved up to and including the checkpoint block.
+// The deposits must all be after the current checkpoint.
+func (i *DepositIndex) Append(deposits []DepositData, checkpoint DepositIndexCheckpoint) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.append(deposits, checkpoint)
+}
+
+// Remove all deposits after the checkpoint block
+func (i *DepositIndex) Rollback(checkpoint DepositIndexCheckpoint) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.rollback(checkpoint)
+}
+
+// Write deposits and a checkpoint to the file, then apply them
+func (i *DepositIndex) append(deposits []DepositData, checkpoint DepositIndexCheckpoint) error {
+	if i.checkpoint != nil && checkpoint.BlockNumber < i.checkpoint.BlockNumber {
+		return fmt.Errorf("checkpoint block %d is before the current checkpoint block %d", checkpoint.BlockNumber, i.checkpoint.BlockNumber)
+	}
+	for _, deposit := range deposits {
+		if deposit.BlockNumber > checkpoint.BlockNumber || (i.checkpoint != nil && deposit.BlockNumber <= i.checkpoint.BlockNumber) {
+			return fmt.Errorf("deposit in block %d is outside of the indexed range", deposit.BlockNumber)
+		}
+	}
+
+	records := make([]depositIndexRecord, 0, len(deposits)+1)
+	for j := range deposits {
+		records = append(records, depositIndexRecord{Deposit: &deposits[j]})
+	}
+	records = append(records, depositIndexRecord{Checkpoint: &checkpoint})
+	if err := i.write(records); err != nil {
+		return err
+	}
+
+	for _, deposit := range deposits {
+		i.addDeposit(deposit)
+	}
+	i.checkpoint = &checkpoint
+	return nil
+}
+
+// Write a rollback record to the file, then apply it
+func (i *DepositIndex) rollback(checkpoint DepositIndexCheckpoint) error {
+	if err := i.write([]depositIndexRecord{{Checkpoint: &checkpoint, Rollback: true}}); err != nil {
+		return err
+	}
+	i.applyRollback(checkpoint)
+	return nil
+}
+
+// Append records to the file and flush them to disk
+func (i *DepositIndex) write(records []depositIndexRecord) error {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error encoding deposit index record: %w", err)
+		}
+	}
+	if _, err := i.file.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("error seeking deposit index: %w", err)
+	}
+	if _, err := i.file.Write(buffer.Bytes()); err != nil {
+		return fmt.Errorf("error writing deposit index: %w", err)
+	}
+	if err := i.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing deposit index: %w", err)
+	}
+	return nil
+}
+
+// Replay the file, truncating anything after the last checkpoint
+func (i *DepositIndex) load() error {
+	reader := bufio.NewReader(i.file)
+	pending := []DepositData{}
+	var offset, validOffset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Incomplete lines are from an interrupted write
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+
+		var record depositIndexRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("error decoding deposit index record at offset %d: %w", offset-int64(len(line)), err)
+		}
+		switch {
+		case record.Deposit != nil:
+			pending = append(pending, *record.Deposit)
+		case record.Checkpoint != nil && record.Rollback:
+			pending = []DepositData{}
+			i.applyRollback(*record.Checkpoint)
+			validOffset = offset
+		case record.Checkpoint != nil:
+			for _, deposit := range pending {
+				i.addDeposit(deposit)
+			}
+			pending = []DepositData{}
+			checkpoint := *record.Checkpoint
+			i.checkpoint = &checkpoint
+			validOffset = offset
+		}
+	}
+
+	if err := i.file.Truncate(validOffset); err != nil {
+		return fmt.Errorf("error truncating deposit index: %w", err)
+	}
+	return nil
+}
+
+// Add a deposit to the lookups
+func (i *DepositIndex) addDeposit(deposit DepositData) {
+	i.byPubkey[deposit.Pubkey] = append(i.byPubkey[deposit.Pubkey], len(i.deposits))
+	i.deposits = append(i.deposits, deposit)
+}
+
+// Remove the deposits after a checkpoint and rebuild the lookups
+func (i *DepositIndex) applyRollback(checkpoint DepositIndexCheckpoint) {
+	deposits := i.deposits
+	i.deposits = make([]DepositData, 0, len(deposits))
+	i.byPubkey = map[rptypes.ValidatorPubkey][]int{}
+	for _, deposit := range deposits {
+		if deposit.BlockNumber <= checkpoint.BlockNumber {
+			i.addDeposit(deposit)
+		}
+	}
+	i.checkpoint = &checkpoint
+}
+
+// Get the hash of a canonical block
+func getBlockHash(rp *rocketpool.RocketPool, blockNumber uint64) (common.Hash, error) {
+	header, err := rp.Client.HeaderByNumber(context.Background(), big.NewInt(0).SetUint64(blockNumber))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting header for block %d: %w", blockNumber, err)
+	}
+	return header.Hash(), nil
+}
@@ -38,6 +38,9 @@ type DepositData struct {
 
 // Gets all of the deposit contract's deposit events for the provided pubkeys
 func GetDeposits(rp *rocketpool.RocketPool, pubkeys map[rptypes.ValidatorPubkey]bool, startBlock *big.Int, intervalSize *big.Int, opts *bind.CallOpts) (map[rptypes.ValidatorPubkey][]DepositData, error) {
+	if len(pubkeys) == 0 {
+		return map[rptypes.ValidatorPubkey][]DepositData{}, nil
+	}
 
 	// Get the deposit contract wrapper
 	casperDeposit, err := getCasperDeposit(rp, opts)
@@ -45,18 +48,41 @@ func GetDeposits(rp *rocketpool.RocketPool, pubkeys map[rptypes.ValidatorPubkey]
 		return nil, err
 	}
 
-	// Create the initial map and pubkey lookup
+	// Get the deposit events
+	deposits, err := getDepositEvents(rp, casperDeposit, pubkeys, startBlock, nil, intervalSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group them by pubkey
 	depositMap := make(map[rptypes.ValidatorPubkey][]DepositData, len(pubkeys))
+	for _, depositData := range deposits {
+		depositMap[depositData.Pubkey] = append(depositMap[depositData.Pubkey], depositData)
+	}
+
+	// Sort deposits by time
+	for _, deposits := range depositMap {
+		if len(deposits) > 1 {
+			sortDepositData(deposits)
+		}
+	}
+
+	return depositMap, nil
+}
+
+// Gets the deposit contract's deposit events for the provided pubkeys (or all pubkeys if nil) in the order they were emitted
+func getDepositEvents(rp *rocketpool.RocketPool, casperDeposit *rocketpool.Contract, pubkeys map[rptypes.ValidatorPubkey]bool, fromBlock *big.Int, toBlock *big.Int, intervalSize *big.Int) ([]DepositData, error) {
 
 	// Get the deposit events
 	addressFilter := []common.Address{*casperDeposit.Address}
 	topicFilter := [][]common.Hash{{casperDeposit.ABI.Events["DepositEvent"].ID}}
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, startBlock, nil, nil)
+	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, fromBlock, toBlock, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Process each event
+	deposits := []DepositData{}
 	for _, log := range logs {
 		depositEvent := new(BeaconDepositEvent)
 		err = casperDeposit.Contract.UnpackLog(depositEvent, "DepositEvent", log)
@@ -66,38 +92,31 @@ func GetDeposits(rp *rocketpool.RocketPool, pubkeys map[rptypes.ValidatorPubkey]
 
 		// Check if this is a deposit for one of the pubkeys we're looking for
 		pubkey := rptypes.BytesToValidatorPubkey(depositEvent.Pubkey)
-		_, exists := pubkeys[pubkey]
-		if exists {
-			// Convert the deposit amount from little-endian binary to a uint64
-			var amount uint64
-			buf := bytes.NewReader(depositEvent.Amount)
-			err = binary.Read(buf, binary.LittleEndian, &amount)
-			if err != nil {
-				return nil, err
-			}
-
-			// Create the deposit data wrapper and add it to this pubkey's collection
-			depositData := DepositData{
-				Pubkey:                pubkey,
-				WithdrawalCredentials: common.BytesToHash(depositEvent.WithdrawalCredentials),
-				Amount:                amount,
-				Signature:             rptypes.BytesToValidatorSignature(depositEvent.Signature),
-				TxHash:                log.TxHash,
-				BlockNumber:           log.BlockNumber,
-				TxIndex:               log.TxIndex,
-			}
-			depositMap[pubkey] = append(depositMap[pubkey], depositData)
+		if pubkeys != nil && !pubkeys[pubkey] {
+			continue
 		}
-	}
 
-	// Sort deposits by time
-	for _, deposits := range depositMap {
-		if len(deposits) > 1 {
-			sortDepositData(deposits)
+		// Convert the deposit amount from little-endian binary to a uint64
+		var amount uint64
+		buf := bytes.NewReader(depositEvent.Amount)
+		err = binary.Read(buf, binary.LittleEndian, &amount)
+		if err != nil {
+			return nil, err
 		}
+
+		// Create the deposit data wrapper
+		deposits = append(deposits, DepositData{
+			Pubkey:                pubkey,
+			WithdrawalCredentials: common.BytesToHash(depositEvent.WithdrawalCredentials),
+			Amount:                amount,
+			Signature:             rptypes.BytesToValidatorSignature(depositEvent.Signature),
+			TxHash:                log.TxHash,
+			BlockNumber:           log.BlockNumber,
+			TxIndex:               log.TxIndex,
+		})
 	}
+	return deposits, nil
 
-	return depositMap, nil
 }
 
 // Sorts a slice of deposit data entries - lower blocks come first, and if multiple transactions occur
@@ -0,0 +1,424 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/storage"
+)
+
+// Buffer size for the raw log channel of an event subscription
+const eventSubscriptionBufferSize int = 256
+
+// A single deployment of a Rocket Pool contract, valid from FromBlock until (but not including) ToBlock
+type ContractDeployment struct {
+	Address   common.Address       `json:"address"`
+	FromBlock *big.Int             `json:"fromBlock"`
+	ToBlock   *big.Int             `json:"toBlock"`
+	Contract  *rocketpool.Contract `json:"-"`
+}
+
+// Get every address a contract has been deployed at, in deployment order, following its ContractUpgraded events.
+// Each deployment is bound with the ABI that RocketStorage held for it while it was active. The current deployment
+// has a nil ToBlock.
+func GetContractDeployments(rp *rocketpool.RocketPool, contractName string, intervalSize *big.Int, opts *bind.CallOpts) ([]ContractDeployment, error) {
+	return GetContractDeploymentsContext(rocketpool.GetCallContext(opts), rp, contractName, intervalSize, opts)
+}
+
+// Get every address a contract has been deployed at with a context
+func GetContractDeploymentsContext(ctx context.Context, rp *rocketpool.RocketPool, contractName string, intervalSize *big.Int, opts *bind.CallOpts) ([]ContractDeployment, error) {
+	rocketDaoNodeTrustedUpgrade, err := rp.GetContractContext(ctx, "rocketDAONodeTrustedUpgrade", opts)
+	if err != nil {
+		return nil, err
+	}
+	deployBlock, err := storage.GetDeployBlockContext(ctx, rp)
+	if err != nil {
+		return nil, err
+	}
+	var toBlock *big.Int
+	if opts != nil {
+		toBlock = opts.BlockNumber
+	}
+
+	// Get the upgrade history
+	addressFilter := []common.Address{*rocketDaoNodeTrustedUpgrade.Address}
+	topicFilter := [][]common.Hash{{rocketDaoNodeTrustedUpgrade.ABI.Events["ContractUpgraded"].ID}, {crypto.Keccak256Hash([]byte(contractName))}}
+	logs, err := GetLogsContext(ctx, rp, addressFilter, topicFilter, intervalSize, deployBlock, toBlock, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each upgrade retires the old address at the upgrade block
+	deployments := make([]ContractDeployment, 0, len(logs)+1)
+	fromBlock := deployBlock
+	for _, log := range logs {
+		if len(log.Topics) < 3 {
+			return nil, fmt.Errorf("ContractUpgraded event had %d topics but at least 3 are required", len(log.Topics))
+		}
+		upgradeBlock := big.NewInt(0).SetUint64(log.BlockNumber)
+		deployments = append(deployments, ContractDeployment{
+			Address:   common.BytesToAddress(log.Topics[2].Bytes()),
+			FromBlock: fromBlock,
+			ToBlock:   upgradeBlock,
+		})
+		fromBlock = upgradeBlock
+	}
+
+	// Append the current address
+	currentAddress, err := rp.GetAddressContext(ctx, contractName, opts)
+	if err != nil {
+		return nil, err
+	}
+	deployments = append(deployments, ContractDeployment{
+		Address:   *currentAddress,
+		FromBlock: fromBlock,
+	})
+
+	// Bind each deployment with the ABI it had while active
+	for i := range deployments {
+		abiOpts := opts
+		if deployments[i].ToBlock != nil {
+			abiOpts = &bind.CallOpts{BlockNumber: big.NewInt(0).Sub(deployments[i].ToBlock, big.NewInt(1))}
+		}
+		contract, err := rp.MakeContractContext(ctx, contractName, deployments[i].Address, abiOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error binding %s deployment at %s: %w", contractName, deployments[i].Address.Hex(), err)
+		}
+		deployments[i].Contract = contract
+	}
+
+	return deployments, nil
+}
+
+// Get and decode events emitted by any deployment of a contract.
+// If eventNames is empty, every event is returned; q.Topics[0] is replaced with the IDs of the named events.
+func FilterContractEvents(rp *rocketpool.RocketPool, contractName string, eventNames []string, q FilterQuery, intervalSize *big.Int, opts *bind.CallOpts) ([]rocketpool.ContractEvent, error) {
+	return FilterContractEventsContext(rocketpool.GetCallContext(opts), rp, contractName, eventNames, q, intervalSize, opts)
+}
+
+// Get and decode events emitted by any deployment of a contract with a context
+func FilterContractEventsContext(ctx context.Context, rp *rocketpool.RocketPool, contractName string, eventNames []string, q FilterQuery, intervalSize *big.Int, opts *bind.CallOpts) ([]rocketpool.ContractEvent, error) {
+	deployments, err := GetContractDeploymentsContext(ctx, rp, contractName, intervalSize, opts)
+	if err != nil {
+		return nil, err
+	}
+	topics, err := getEventTopics(deployments, eventNames, q.Topics)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := GetLogsContext(ctx, rp, getDeploymentAddresses(deployments), topics, intervalSize, q.FromBlock, q.ToBlock, q.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDeploymentLogs(deployments, logs)
+}
+
+// Iterates over the historical events of a contract, one block interval at a time
+type ContractEventIterator struct {
+	Event rocketpool.ContractEvent
+
+	rp           *rocketpool.RocketPool
+	deployments  []ContractDeployment
+	topics       [][]common.Hash
+	intervalSize *big.Int
+	nextBlock    *big.Int
+	toBlock      *big.Int
+	pending      []rocketpool.ContractEvent
+	err          error
+}
+
+// Create an iterator over the events of every deployment of a contract between fromBlock and toBlock (inclusive).
+// If fromBlock is nil, the Rocket Pool deployment block is used; if toBlock is nil, the latest block is used.
+func NewContractEventIterator(rp *rocketpool.RocketPool, contractName string, eventNames []string, topics [][]common.Hash, fromBlock *big.Int, toBlock *big.Int, intervalSize *big.Int, opts *bind.CallOpts) (*ContractEventIterator, error) {
+	deployments, err := GetContractDeployments(rp, contractName, intervalSize, opts)
+	if err != nil {
+		return nil, err
+	}
+	topics, err = getEventTopics(deployments, eventNames, topics)
+	if err != nil {
+		return nil, err
+	}
+	if fromBlock == nil {
+		fromBlock = deployments[0].FromBlock
+	}
+	if toBlock == nil {
+		latestBlock, err := rp.Client.BlockNumber(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		toBlock = big.NewInt(0).SetUint64(latestBlock)
+	}
+	if intervalSize == nil {
+		intervalSize = big.NewInt(0).Add(big.NewInt(0).Sub(toBlock, fromBlock), big.NewInt(1))
+	}
+	if intervalSize.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid interval size %s", intervalSize.String())
+	}
+
+	return &ContractEventIterator{
+		rp:           rp,
+		deployments:  deployments,
+		topics:       topics,
+		intervalSize: intervalSize,
+		nextBlock:    big.NewInt(0).Set(fromBlock),
+		toBlock:      toBlock,
+	}, nil
+}
+
+// Advance to the next event, returning false when there are no more events or an error occurred
+func (it *ContractEventIterator) Next() bool {
+	return it.NextContext(context.Background())
+}
+
+// Advance to the next event with a context
+func (it *ContractEventIterator) NextContext(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for len(it.pending) == 0 {
+		if it.nextBlock.Cmp(it.toBlock) > 0 {
+			return false
+		}
+
+		// Get the next interval
+		end := big.NewInt(0).Add(it.nextBlock, it.intervalSize)
+		end.Sub(end, big.NewInt(1))
+		if end.Cmp(it.toBlock) > 0 {
+			end.Set(it.toBlock)
+		}
+		logs, err := it.rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
+			Addresses: getDeploymentAddresses(it.deployments),
+			Topics:    it.topics,
+			FromBlock: it.nextBlock,
+			ToBlock:   end,
+		})
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.pending, err = decodeDeploymentLogs(it.deployments, logs)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.nextBlock = end.Add(end, big.NewInt(1))
+	}
+
+	it.Event = it.pending[0]
+	it.pending = it.pending[1:]
+	return true
+}
+
+// Get the error that stopped iteration, if any
+func (it *ContractEventIterator) Error() error {
+	return it.err
+}
+
+// Stream newly emitted events of a contract, following it across upgrades.
+// Events removed by a chain reorganization are delivered again with Removed set.
+func SubscribeContractEvents(ctx context.Context, rp *rocketpool.RocketPool, contractName string, eventNames []string, topics [][]common.Hash, sink chan<- rocketpool.ContractEvent) (ethereum.Subscription, error) {
+	rocketDaoNodeTrustedUpgrade, err := rp.GetContractContext(ctx, "rocketDAONodeTrustedUpgrade", nil)
+	if err != nil {
+		return nil, err
+	}
+	current, err := getCurrentDeployment(ctx, rp, contractName)
+	if err != nil {
+		return nil, err
+	}
+	deployments := []ContractDeployment{current}
+	topics, err = getEventTopics(deployments, eventNames, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscribe to the contract's events and to its upgrades in one filter.
+	// Only the event IDs can be shared between the two; other topic positions are matched when logs arrive.
+	upgradeIDs := []common.Hash{}
+	for _, name := range []string{"ContractUpgraded", "ABIUpgraded"} {
+		if upgradeEvent, exists := rocketDaoNodeTrustedUpgrade.ABI.Events[name]; exists {
+			upgradeIDs = append(upgradeIDs, upgradeEvent.ID)
+		}
+	}
+	nameHash := crypto.Keccak256Hash([]byte(contractName))
+	makeQuery := func() ethereum.FilterQuery {
+		// An unrestricted event ID has to stay unrestricted, or the filter would only match upgrades
+		var filterTopics [][]common.Hash
+		if len(topics) > 0 && len(topics[0]) > 0 {
+			filterTopics = make([][]common.Hash, 1)
+			filterTopics[0] = append(append([]common.Hash{}, upgradeIDs...), topics[0]...)
+		}
+		return ethereum.FilterQuery{
+			Addresses: []common.Address{current.Address, *rocketDaoNodeTrustedUpgrade.Address},
+			Topics:    filterTopics,
+		}
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		logs := make(chan types.Log, eventSubscriptionBufferSize)
+		sub, err := rp.Client.SubscribeFilterLogs(ctx, makeQuery(), logs)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			sub.Unsubscribe()
+		}()
+
+		for {
+			select {
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case err := <-sub.Err():
+				return err
+			case log := <-logs:
+				// Follow upgrades by resubscribing with the new address and ABI
+				if log.Address == *rocketDaoNodeTrustedUpgrade.Address {
+					if err := rp.ProcessUpgradeLog(log); err != nil {
+						return err
+					}
+					if log.Removed || !matchesTopics(log, [][]common.Hash{upgradeIDs, {nameHash}}) {
+						continue
+					}
+					current, err = getCurrentDeployment(ctx, rp, contractName)
+					if err != nil {
+						return err
+					}
+					current.FromBlock = big.NewInt(0).SetUint64(log.BlockNumber)
+					deployments = append(deployments, current)
+					sub.Unsubscribe()
+					sub, err = rp.Client.SubscribeFilterLogs(ctx, makeQuery(), logs)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
+				// Decode and deliver the event
+				if !matchesTopics(log, topics) {
+					continue
+				}
+				decoded, err := decodeDeploymentLogs(deployments, []types.Log{log})
+				if err != nil {
+					return err
+				}
+				select {
+				case sink <- decoded[0]:
+				case <-quit:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}), nil
+}
+
+// Get the current deployment of a contract, bypassing the address cache
+func getCurrentDeployment(ctx context.Context, rp *rocketpool.RocketPool, contractName string) (ContractDeployment, error) {
+	latestBlock, err := rp.Client.BlockNumber(ctx)
+	if err != nil {
+		return ContractDeployment{}, err
+	}
+	opts := &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(latestBlock)}
+	contract, err := rp.GetContractContext(ctx, contractName, opts)
+	if err != nil {
+		return ContractDeployment{}, err
+	}
+	return ContractDeployment{
+		Address:  *contract.Address,
+		Contract: contract,
+	}, nil
+}
+
+// Get the topic filter for a set of event names, using the event IDs from every deployment's ABI
+func getEventTopics(deployments []ContractDeployment, eventNames []string, topics [][]common.Hash) ([][]common.Hash, error) {
+	if len(eventNames) == 0 {
+		return topics, nil
+	}
+	ids := []common.Hash{}
+	seen := map[common.Hash]bool{}
+	for _, name := range eventNames {
+		found := false
+		for _, deployment := range deployments {
+			event, exists := deployment.Contract.ABI.Events[name]
+			if !exists {
+				continue
+			}
+			found = true
+			if !seen[event.ID] {
+				seen[event.ID] = true
+				ids = append(ids, event.ID)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("event '%s' does not exist on any deployment of the contract", name)
+		}
+	}
+	newTopics := [][]common.Hash{ids}
+	if len(topics) > 1 {
+		newTopics = append(newTopics, topics[1:]...)
+	}
+	return newTopics, nil
+}
+
+// Get the addresses of a set of deployments
+func getDeploymentAddresses(deployments []ContractDeployment) []common.Address {
+	addresses := make([]common.Address, len(deployments))
+	for i, deployment := range deployments {
+		addresses[i] = deployment.Address
+	}
+	return addresses
+}
+
+// Decode logs with the deployment that emitted them
+func decodeDeploymentLogs(deployments []ContractDeployment, logs []types.Log) ([]rocketpool.ContractEvent, error) {
+	contracts := make(map[common.Address]*rocketpool.Contract, len(deployments))
+	for _, deployment := range deployments {
+		contracts[deployment.Address] = deployment.Contract
+	}
+	events := make([]rocketpool.ContractEvent, 0, len(logs))
+	for _, log := range logs {
+		contract, exists := contracts[log.Address]
+		if !exists {
+			return nil, fmt.Errorf("log from %s does not belong to any known deployment", log.Address.Hex())
+		}
+		event, err := contract.DecodeLog(log)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding log %d of tx %s: %w", log.Index, log.TxHash.Hex(), err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Check if a log matches a topic filter, where an empty position matches anything
+func matchesTopics(log types.Log, topics [][]common.Hash) bool {
+	for i, options := range topics {
+		if len(options) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		matched := false
+		for _, topic := range options {
+			if log.Topics[i] == topic {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -21,7 +21,11 @@ type FilterQuery struct {
 }
 
 func FilterContractLogs(rp *rocketpool.RocketPool, contractName string, q FilterQuery, intervalSize *big.Int, opts *bind.CallOpts) ([]types.Log, error) {
-	rocketDaoNodeTrustedUpgrade, err := rp.GetContract("rocketDAONodeTrustedUpgrade", opts)
+	return FilterContractLogsContext(rocketpool.GetCallContext(opts), rp, contractName, q, intervalSize, opts)
+}
+
+func FilterContractLogsContext(ctx context.Context, rp *rocketpool.RocketPool, contractName string, q FilterQuery, intervalSize *big.Int, opts *bind.CallOpts) ([]types.Log, error) {
+	rocketDaoNodeTrustedUpgrade, err := rp.GetContractContext(ctx, "rocketDAONodeTrustedUpgrade", opts)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +34,7 @@ func FilterContractLogs(rp *rocketpool.RocketPool, contractName string, q Filter
 	// Construct a filter to query ContractUpgraded event
 	addressFilter := []common.Address{*rocketDaoNodeTrustedUpgrade.Address}
 	topicFilter := [][]common.Hash{{rocketDaoNodeTrustedUpgrade.ABI.Events["ContractUpgraded"].ID}, {crypto.Keccak256Hash([]byte(contractName))}}
-	logs, err := GetLogs(rp, addressFilter, topicFilter, intervalSize, nil, nil, nil)
+	logs, err := GetLogsContext(ctx, rp, addressFilter, topicFilter, intervalSize, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -39,23 +43,28 @@ func FilterContractLogs(rp *rocketpool.RocketPool, contractName string, q Filter
 		addresses = append(addresses, common.HexToAddress(log.Topics[2].Hex()))
 	}
 	// Append current address
-	currentAddress, err := rp.GetAddress(contractName, opts)
+	currentAddress, err := rp.GetAddressContext(ctx, contractName, opts)
 	if err != nil {
 		return nil, err
 	}
 	addresses = append(addresses, *currentAddress)
 	// Perform the desired getLogs call and return results
-	return GetLogs(rp, addresses, q.Topics, intervalSize, q.FromBlock, q.ToBlock, q.BlockHash)
+	return GetLogsContext(ctx, rp, addresses, q.Topics, intervalSize, q.FromBlock, q.ToBlock, q.BlockHash)
 }
 
 // Gets the logs for a particular log request, breaking the calls into batches if necessary
 func GetLogs(rp *rocketpool.RocketPool, addressFilter []common.Address, topicFilter [][]common.Hash, intervalSize, fromBlock, toBlock *big.Int, blockHash *common.Hash) ([]types.Log, error) {
+	return GetLogsContext(context.Background(), rp, addressFilter, topicFilter, intervalSize, fromBlock, toBlock, blockHash)
+}
+
+// Gets the logs for a particular log request with a context, breaking the calls into batches if necessary
+func GetLogsContext(ctx context.Context, rp *rocketpool.RocketPool, addressFilter []common.Address, topicFilter [][]common.Hash, intervalSize, fromBlock, toBlock *big.Int, blockHash *common.Hash) ([]types.Log, error) {
 	var logs []types.Log
 
 	// Get the block that Rocket Pool was deployed on as the lower bound if one wasn't specified
 	if fromBlock == nil {
 		var err error
-		fromBlock, err = storage.GetDeployBlock(rp)
+		fromBlock, err = storage.GetDeployBlockContext(ctx, rp)
 		if err != nil {
 			return nil, err
 		}
@@ -63,7 +72,7 @@ func GetLogs(rp *rocketpool.RocketPool, addressFilter []common.Address, topicFil
 
 	if intervalSize == nil {
 		// Handle unlimited intervals with a single call
-		logs, err := rp.Client.FilterLogs(context.Background(), ethereum.FilterQuery{
+		logs, err := rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
 			Addresses: addressFilter,
 			Topics:    topicFilter,
 			FromBlock: fromBlock,
@@ -77,7 +86,7 @@ func GetLogs(rp *rocketpool.RocketPool, addressFilter []common.Address, topicFil
 	} else {
 		// Get the latest block
 		if toBlock == nil {
-			latestBlock, err := rp.Client.BlockNumber(context.Background())
+			latestBlock, err := rp.Client.BlockNumber(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -94,7 +103,7 @@ func GetLogs(rp *rocketpool.RocketPool, addressFilter []common.Address, topicFil
 		}
 		for {
 			// Get the logs using the current interval
-			newLogs, err := rp.Client.FilterLogs(context.Background(), ethereum.FilterQuery{
+			newLogs, err := rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
 				Addresses: addressFilter,
 				Topics:    topicFilter,
 				FromBlock: start,
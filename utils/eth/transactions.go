@@ -13,6 +13,11 @@ import (
 
 // Estimate the gas of SendTransaction
 func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress common.Address, data []byte, useSafeGasLimit bool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	return EstimateSendTransactionGasContext(context.Background(), client, toAddress, data, useSafeGasLimit, opts)
+}
+
+// Estimate the gas of SendTransaction with a context
+func EstimateSendTransactionGasContext(ctx context.Context, client rocketpool.ExecutionClient, toAddress common.Address, data []byte, useSafeGasLimit bool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
 
 	// User-defined settings
 	response := rocketpool.GasInfo{}
@@ -29,7 +34,7 @@ func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress com
 	}
 
 	// Estimate gas limit
-	gasLimit, err := client.EstimateGas(context.Background(), ethereum.CallMsg{
+	gasLimit, err := client.EstimateGas(ctx, ethereum.CallMsg{
 		From:     opts.From,
 		To:       &toAddress,
 		GasPrice: big.NewInt(0), // set to 0 for simulation
@@ -53,12 +58,17 @@ func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress com
 // Send a transaction to an address
 // useSafeGasLimit will amplify the estimated gas limit to by 50% for safety (no effect if the gas limit in opts is already set).
 func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address, chainID *big.Int, data []byte, useSafeGasLimit bool, opts *bind.TransactOpts) (common.Hash, error) {
+	return SendTransactionContext(context.Background(), client, toAddress, chainID, data, useSafeGasLimit, opts)
+}
+
+// Send a transaction to an address with a context
+func SendTransactionContext(ctx context.Context, client rocketpool.ExecutionClient, toAddress common.Address, chainID *big.Int, data []byte, useSafeGasLimit bool, opts *bind.TransactOpts) (common.Hash, error) {
 	var err error
 
 	// Get from address nonce
 	var nonce uint64
 	if opts.Nonce == nil {
-		nonce, err = client.PendingNonceAt(context.Background(), opts.From)
+		nonce, err = client.PendingNonceAt(ctx, opts.From)
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -80,7 +90,7 @@ func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address
 	// Estimate gas limit
 	gasLimit := opts.GasLimit
 	if gasLimit == 0 {
-		gasLimit, err = client.EstimateGas(context.Background(), ethereum.CallMsg{
+		gasLimit, err = client.EstimateGas(ctx, ethereum.CallMsg{
 			From:     opts.From,
 			To:       &toAddress,
 			GasPrice: big.NewInt(0), // use 0 gwei for simulation
@@ -116,7 +126,10 @@ func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address
 	}
 
 	// Send transaction
-	if err = client.SendTransaction(context.Background(), signedTx); err != nil {
+	if opts.NoSend {
+		return signedTx.Hash(), nil
+	}
+	if err = client.SendTransaction(ctx, signedTx); err != nil {
 		return common.Hash{}, err
 	}
 
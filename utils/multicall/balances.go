@@ -39,10 +39,14 @@ func NewBalanceBatcher(client rocketpool.ExecutionClient, address common.Address
 }
 
 func (b *BalanceBatcher) GetEthBalances(addresses []common.Address, opts *bind.CallOpts) ([]*big.Int, error) {
+	return b.GetEthBalancesContext(rocketpool.GetCallContext(opts), addresses, opts)
+}
+
+func (b *BalanceBatcher) GetEthBalancesContext(ctx context.Context, addresses []common.Address, opts *bind.CallOpts) ([]*big.Int, error) {
 
 	// Sync
 	count := len(addresses)
-	var wg errgroup.Group
+	wg, ctx := errgroup.WithContext(ctx)
 	wg.SetLimit(threadLimit)
 	balances := make([]*big.Int, count)
 
@@ -64,7 +68,7 @@ func (b *BalanceBatcher) GetEthBalances(addresses []common.Address, opts *bind.C
 				return fmt.Errorf("error creating calldata for balances: %w", err)
 			}
 
-			response, err := b.Client.CallContract(context.Background(), ethereum.CallMsg{To: &b.ContractAddress, Data: callData}, opts.BlockNumber)
+			response, err := b.Client.CallContract(ctx, ethereum.CallMsg{To: &b.ContractAddress, Data: callData}, opts.BlockNumber)
 			if err != nil {
 				return fmt.Errorf("error calling balances: %w", err)
 			}
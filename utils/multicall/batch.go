@@ -0,0 +1,587 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// MultiSend operation for a regular call
+const multiSendOperationCall uint8 = 0
+
+// A write call collected into a batch
+type BatchCall struct {
+	Method       string               `json:"method"`
+	Target       common.Address       `json:"target"`
+	Value        *big.Int             `json:"value"`
+	CallData     []byte               `json:"callData"`
+	AllowFailure bool                 `json:"allowFailure"`
+	Contract     *rocketpool.Contract `json:"-"`
+}
+
+// The outcome of a single call in a batch
+type BatchCallResult struct {
+	Call         BatchCall                  `json:"call"`
+	Success      bool                       `json:"success"`
+	ReturnData   []byte                     `json:"returnData"`
+	RevertReason string                     `json:"revertReason,omitempty"`
+	Events       []rocketpool.ContractEvent `json:"events,omitempty"`
+}
+
+// Unpack the call's return data into an output value
+func (r BatchCallResult) Unpack(output interface{}) error {
+	if !r.Success {
+		return fmt.Errorf("call to %s failed: %s", r.Call.Method, r.RevertReason)
+	}
+	return r.Call.Contract.ABI.UnpackIntoInterface(output, r.Call.Method, r.ReturnData)
+}
+
+// The decoded receipt of a batch transaction
+type BatchReceipt struct {
+	Receipt *types.Receipt    `json:"receipt"`
+	Success bool              `json:"success"`
+	Calls   []BatchCallResult `json:"calls"`
+
+	// Events emitted by the batch's targets, whether or not they could be attributed to a call
+	Events []rocketpool.ContractEvent `json:"events"`
+}
+
+// Collects contract write calls and sends them as a single transaction, through either a Multicall3 contract or a Safe.
+// Calls made through Multicall3 have it as their msg.sender, so only permissionless methods (e.g. distributor
+// distribution) can be batched that way; owner-restricted methods must be batched through a Safe that owns the node.
+type Batcher struct {
+	Client rocketpool.ExecutionClient
+	calls  []BatchCall
+}
+
+// Create a new batcher
+func NewBatcher(client rocketpool.ExecutionClient) *Batcher {
+	return &Batcher{
+		Client: client,
+		calls:  []BatchCall{},
+	}
+}
+
+// Add a call to a contract method; value may be nil. If allowFailure is set, the batch continues if this call reverts.
+func (b *Batcher) AddCall(contract *rocketpool.Contract, value *big.Int, allowFailure bool, method string, args ...interface{}) error {
+	callData, err := contract.ABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("error adding call [%s]: %w", method, err)
+	}
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	b.calls = append(b.calls, BatchCall{
+		Method:       method,
+		Target:       *contract.Address,
+		Value:        value,
+		CallData:     callData,
+		AllowFailure: allowFailure,
+		Contract:     contract,
+	})
+	return nil
+}
+
+// Get the calls in the batch
+func (b *Batcher) GetCalls() []BatchCall {
+	return b.calls
+}
+
+// Get the total value sent with the batch
+func (b *Batcher) GetTotalValue() *big.Int {
+	total := big.NewInt(0)
+	for _, call := range b.calls {
+		total.Add(total, call.Value)
+	}
+	return total
+}
+
+// ==================
+// === Multicall3 ===
+// ==================
+
+// Get a Multicall3 contract binding
+func (b *Batcher) getMulticall3(multicall3Address common.Address) (*rocketpool.Contract, error) {
+	mcAbi, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &rocketpool.Contract{
+		Contract: bind.NewBoundContract(multicall3Address, mcAbi, b.Client, b.Client, b.Client),
+		Address:  &multicall3Address,
+		ABI:      &mcAbi,
+		Client:   b.Client,
+	}, nil
+}
+
+// Get the Multicall3 aggregate3Value arguments for the batch
+func (b *Batcher) getCall3Values() ([]Call3Value, error) {
+	if len(b.calls) == 0 {
+		return nil, errors.New("batch has no calls")
+	}
+	calls := make([]Call3Value, len(b.calls))
+	for i, call := range b.calls {
+		calls[i] = Call3Value{
+			Target:       call.Target,
+			AllowFailure: call.AllowFailure,
+			Value:        call.Value,
+			CallData:     call.CallData,
+		}
+	}
+	return calls, nil
+}
+
+// Get the calldata of a Multicall3 aggregate3Value transaction for the batch
+func (b *Batcher) GetAggregate3ValueData() ([]byte, error) {
+	calls, err := b.getCall3Values()
+	if err != nil {
+		return nil, err
+	}
+	mcAbi, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, err
+	}
+	return mcAbi.Pack("aggregate3Value", calls)
+}
+
+// Simulate the batch through Multicall3 and get the result of each call
+func (b *Batcher) SimulateMulticall3(multicall3Address common.Address, from common.Address, opts *bind.CallOpts) ([]BatchCallResult, error) {
+	var blockNumber *big.Int
+	ctx := context.Background()
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+		if opts.Context != nil {
+			ctx = opts.Context
+		}
+	}
+	callData, err := b.GetAggregate3ValueData()
+	if err != nil {
+		return nil, err
+	}
+	response, err := b.Client.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    &multicall3Address,
+		Value: b.GetTotalValue(),
+		Data:  callData,
+	}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error simulating batch: %w", err)
+	}
+	return b.DecodeAggregate3Results(response)
+}
+
+// Decode the return data of a Multicall3 aggregate3 or aggregate3Value call into per-call results
+func (b *Batcher) DecodeAggregate3Results(returnData []byte) ([]BatchCallResult, error) {
+	mcAbi, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, err
+	}
+	unpacked, err := mcAbi.Unpack("aggregate3Value", returnData)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding batch results: %w", err)
+	}
+	responses := *abi.ConvertType(unpacked[0], new([]struct {
+		Success    bool
+		ReturnData []byte
+	})).(*[]struct {
+		Success    bool
+		ReturnData []byte
+	})
+	if len(responses) != len(b.calls) {
+		return nil, fmt.Errorf("batch has %d calls but %d results were returned", len(b.calls), len(responses))
+	}
+	results := make([]BatchCallResult, len(b.calls))
+	for i, response := range responses {
+		results[i] = makeBatchCallResult(b.calls[i], response.Success, response.ReturnData)
+	}
+	return results, nil
+}
+
+// Estimate the gas of TransactMulticall3
+func (b *Batcher) EstimateMulticall3Gas(multicall3Address common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	calls, err := b.getCall3Values()
+	if err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	multicall3, err := b.getMulticall3(multicall3Address)
+	if err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return multicall3.GetTransactionGasInfo(b.withTotalValue(opts), "aggregate3Value", calls)
+}
+
+// Send the batch as a single Multicall3 aggregate3Value transaction
+func (b *Batcher) TransactMulticall3(multicall3Address common.Address, opts *bind.TransactOpts) (*types.Transaction, error) {
+	calls, err := b.getCall3Values()
+	if err != nil {
+		return nil, err
+	}
+	multicall3, err := b.getMulticall3(multicall3Address)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := multicall3.Transact(b.withTotalValue(opts), "aggregate3Value", calls)
+	if err != nil {
+		return nil, fmt.Errorf("error sending batch of %d calls: %w", len(calls), err)
+	}
+	return tx, nil
+}
+
+// Get a copy of the transaction options that sends the batch's total value
+func (b *Batcher) withTotalValue(opts *bind.TransactOpts) *bind.TransactOpts {
+	optsCopy := *opts
+	optsCopy.Value = b.GetTotalValue()
+	return &optsCopy
+}
+
+// ============
+// === Safe ===
+// ============
+
+// Get the packed transactions argument of a Safe MultiSend call for the batch.
+// Each call is encoded as operation (uint8), to (address), value (uint256), data length (uint256) and data.
+func (b *Batcher) GetMultiSendTransactions() ([]byte, error) {
+	if len(b.calls) == 0 {
+		return nil, errors.New("batch has no calls")
+	}
+	for _, call := range b.calls {
+		if call.AllowFailure {
+			return nil, fmt.Errorf("call to %s allows failure, but MultiSend reverts if any call fails", call.Method)
+		}
+	}
+	return packMultiSendTransactions(b.calls), nil
+}
+
+// Get the calldata of a Safe MultiSend call for the batch.
+// The Safe must execute it as a DELEGATECALL (operation 1) to a MultiSend or MultiSendCallOnly contract.
+func (b *Batcher) GetMultiSendData() ([]byte, error) {
+	transactions, err := b.GetMultiSendTransactions()
+	if err != nil {
+		return nil, err
+	}
+	return packMultiSendData(transactions)
+}
+
+// Simulate the batch's MultiSend payload in the context of a Safe and get the result of each call.
+// The payload is run through the Safe's simulateAndRevert, so it executes exactly as execTransaction would delegatecall it.
+// MultiSend discards the return data of successful calls and reverts at the first failed call; if the batch fails,
+// the failing call is found by simulating shorter prefixes of the batch. Calls before it are marked successful
+// (although the batch reverts their effects) and calls after it are marked as not executed.
+func (b *Batcher) SimulateSafe(safeAddress common.Address, multiSendAddress common.Address, opts *bind.CallOpts) ([]BatchCallResult, error) {
+	var blockNumber *big.Int
+	ctx := context.Background()
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+		if opts.Context != nil {
+			ctx = opts.Context
+		}
+	}
+	if _, err := b.GetMultiSendTransactions(); err != nil {
+		return nil, err
+	}
+
+	// Simulate the full batch
+	results := make([]BatchCallResult, len(b.calls))
+	success, revertData, err := b.simulateMultiSend(ctx, safeAddress, multiSendAddress, len(b.calls), blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if success {
+		for i, call := range b.calls {
+			results[i] = makeBatchCallResult(call, true, nil)
+		}
+		return results, nil
+	}
+
+	// Find the shortest failing prefix; later calls can only fail if an earlier one does
+	low, high := 1, len(b.calls)
+	for low < high {
+		mid := (low + high) / 2
+		success, data, err := b.simulateMultiSend(ctx, safeAddress, multiSendAddress, mid, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if success {
+			low = mid + 1
+		} else {
+			high = mid
+			revertData = data
+		}
+	}
+	for i, call := range b.calls {
+		switch {
+		case i < high-1:
+			results[i] = makeBatchCallResult(call, true, nil)
+		case i == high-1:
+			results[i] = makeBatchCallResult(call, false, revertData)
+		default:
+			results[i] = BatchCallResult{
+				Call:         call,
+				RevertReason: "not executed: an earlier call in the batch failed",
+			}
+		}
+	}
+	return results, nil
+}
+
+// Run the first count calls of the batch through a Safe's simulateAndRevert and get the MultiSend outcome.
+// simulateAndRevert always reverts with the delegatecall's success flag, return data length and return data.
+func (b *Batcher) simulateMultiSend(ctx context.Context, safeAddress common.Address, multiSendAddress common.Address, count int, blockNumber *big.Int) (bool, []byte, error) {
+	multiSendData, err := packMultiSendData(packMultiSendTransactions(b.calls[:count]))
+	if err != nil {
+		return false, nil, err
+	}
+	safeAbi, err := abi.JSON(strings.NewReader(SafeSimulationABI))
+	if err != nil {
+		return false, nil, err
+	}
+	callData, err := safeAbi.Pack("simulateAndRevert", multiSendAddress, multiSendData)
+	if err != nil {
+		return false, nil, err
+	}
+	_, err = b.Client.CallContract(ctx, ethereum.CallMsg{
+		From: safeAddress,
+		To:   &safeAddress,
+		Data: callData,
+	}, blockNumber)
+	if err == nil {
+		return false, nil, fmt.Errorf("simulateAndRevert on %s didn't revert; the address may not be a Safe", safeAddress.Hex())
+	}
+	revertErr, ok := rocketpool.ParseRevertError(err)
+	if !ok || len(revertErr.Data) < 64 {
+		return false, nil, fmt.Errorf("error simulating batch: %w", err)
+	}
+	data := revertErr.Data
+	length := new(big.Int).SetBytes(data[32:64])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data)-64) {
+		return false, nil, fmt.Errorf("error simulating batch: invalid return data length %s", length.String())
+	}
+	return new(big.Int).SetBytes(data[:32]).Sign() != 0, data[64 : 64+length.Uint64()], nil
+}
+
+// Pack calls as MultiSend transactions
+func packMultiSendTransactions(calls []BatchCall) []byte {
+	packed := []byte{}
+	for _, call := range calls {
+		length := make([]byte, 32)
+		binary.BigEndian.PutUint64(length[24:], uint64(len(call.CallData)))
+		packed = append(packed, multiSendOperationCall)
+		packed = append(packed, call.Target.Bytes()...)
+		packed = append(packed, common.LeftPadBytes(call.Value.Bytes(), 32)...)
+		packed = append(packed, length...)
+		packed = append(packed, call.CallData...)
+	}
+	return packed
+}
+
+// Pack packed MultiSend transactions into multiSend calldata
+func packMultiSendData(transactions []byte) ([]byte, error) {
+	msAbi, err := abi.JSON(strings.NewReader(MultiSendABI))
+	if err != nil {
+		return nil, err
+	}
+	return msAbi.Pack("multiSend", transactions)
+}
+
+// ================
+// === Receipts ===
+// ================
+
+// Decode the receipt of a Multicall3 batch transaction, using the transaction's aggregate3Value return data
+// (e.g. from a trace) for the per-call results. The return data may only be omitted if no call allows failure,
+// since aggregate3Value reverts if any other call fails.
+// A receipt doesn't record which call emitted a log, so events are decoded into the receipt's Events without being
+// attributed to calls; use DecodeTrace to attribute them. Safe batches must also be decoded with DecodeTrace,
+// since execTransaction succeeds even if the MultiSend it runs reverts.
+func (b *Batcher) DecodeReceipt(receipt *types.Receipt, returnData []byte) (*BatchReceipt, error) {
+	result := &BatchReceipt{
+		Receipt: receipt,
+		Success: receipt.Status == types.ReceiptStatusSuccessful,
+	}
+
+	// Get the call results
+	switch {
+	case !result.Success:
+		result.Calls = make([]BatchCallResult, len(b.calls))
+		for i, call := range b.calls {
+			result.Calls[i] = BatchCallResult{
+				Call:         call,
+				RevertReason: "the batch transaction reverted",
+			}
+		}
+	case returnData != nil:
+		calls, err := b.DecodeAggregate3Results(returnData)
+		if err != nil {
+			return nil, err
+		}
+		result.Calls = calls
+	default:
+		result.Calls = make([]BatchCallResult, len(b.calls))
+		for i, call := range b.calls {
+			if call.AllowFailure {
+				return nil, fmt.Errorf("call to %s allows failure, so the aggregate3Value return data is required to decode the batch", call.Method)
+			}
+			result.Calls[i] = makeBatchCallResult(call, true, nil)
+		}
+	}
+
+	// Decode the events
+	events, err := b.decodeLogs(receipt, receipt.Logs, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Events = events
+	return result, nil
+}
+
+// Decode the receipt of a Multicall3 or Safe batch transaction using its call trace.
+// Each call's result comes from its own call frame, and it's only attributed the events emitted within that frame.
+func (b *Batcher) DecodeTrace(receipt *types.Receipt, trace *CallFrame) (*BatchReceipt, error) {
+	frame := b.findBatchFrame(trace)
+	if frame == nil {
+		return nil, fmt.Errorf("trace of %s doesn't contain the batch calls", receipt.TxHash.Hex())
+	}
+	result := &BatchReceipt{
+		Receipt: receipt,
+		Success: receipt.Status == types.ReceiptStatusSuccessful && frame.Error == "",
+		Calls:   make([]BatchCallResult, len(b.calls)),
+	}
+	events, err := b.decodeLogs(receipt, receipt.Logs, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Events = events
+
+	// Get the result of each call from its frame
+	children := getCallFrames(frame)
+	used := map[*types.Log]bool{}
+	for i, call := range b.calls {
+		if i >= len(children) {
+			result.Calls[i] = BatchCallResult{
+				Call:         call,
+				RevertReason: "not executed: an earlier call in the batch failed",
+			}
+			continue
+		}
+		child := children[i]
+		if child.Error != "" {
+			result.Calls[i] = makeBatchCallResult(call, false, child.Output)
+			continue
+		}
+		if !result.Success {
+			result.Calls[i] = BatchCallResult{
+				Call:         call,
+				ReturnData:   child.Output,
+				RevertReason: "the batch reverted",
+			}
+			continue
+		}
+		result.Calls[i] = makeBatchCallResult(call, true, child.Output)
+
+		// Match the frame's logs to the receipt's
+		logs := []*types.Log{}
+		for _, traceLog := range collectFrameLogs(child) {
+			log := findReceiptLog(receipt, traceLog, used)
+			if log == nil {
+				return nil, fmt.Errorf("log emitted by %s in call %d isn't in the receipt of %s", traceLog.Address.Hex(), i, receipt.TxHash.Hex())
+			}
+			logs = append(logs, log)
+		}
+		callEvents, err := b.decodeLogs(receipt, logs, &call)
+		if err != nil {
+			return nil, err
+		}
+		result.Calls[i].Events = callEvents
+	}
+	return result, nil
+}
+
+// Find the frame that made the batch calls: the first frame whose calls are the batch, in order.
+// A MultiSend that reverted stops at the failed call, so a frame that made a prefix of the batch ending in a failure also matches.
+func (b *Batcher) findBatchFrame(frame *CallFrame) *CallFrame {
+	if frame == nil {
+		return nil
+	}
+	children := getCallFrames(frame)
+	if len(children) > 0 && len(children) <= len(b.calls) {
+		matches := true
+		for i, child := range children {
+			if child.To != b.calls[i].Target || !bytes.Equal(child.Input, b.calls[i].CallData) {
+				matches = false
+				break
+			}
+		}
+		if matches && (len(children) == len(b.calls) || children[len(children)-1].Error != "") {
+			return frame
+		}
+	}
+	for i := range frame.Calls {
+		if found := b.findBatchFrame(&frame.Calls[i]); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Decode the logs emitted by the batch's targets with known events; if call is set, only its target's logs are decoded
+func (b *Batcher) decodeLogs(receipt *types.Receipt, logs []*types.Log, call *BatchCall) ([]rocketpool.ContractEvent, error) {
+	events := []rocketpool.ContractEvent{}
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		var contract *rocketpool.Contract
+		if call != nil {
+			if call.Target == log.Address {
+				contract = call.Contract
+			}
+		} else {
+			for _, batchCall := range b.calls {
+				if batchCall.Target == log.Address {
+					contract = batchCall.Contract
+					break
+				}
+			}
+		}
+		if contract == nil {
+			continue
+		}
+		if _, err := contract.ABI.EventByID(log.Topics[0]); err != nil {
+			continue
+		}
+		event, err := contract.DecodeLog(*log)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding log %d of %s: %w", log.Index, receipt.TxHash.Hex(), err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Create the result of a call, decoding its revert reason if it failed
+func makeBatchCallResult(call BatchCall, success bool, returnData []byte) BatchCallResult {
+	result := BatchCallResult{
+		Call:       call,
+		Success:    success,
+		ReturnData: returnData,
+	}
+	if !success {
+		reason, err := abi.UnpackRevert(returnData)
+		if err != nil {
+			reason = fmt.Sprintf("reverted without a reason (0x%x)", returnData)
+		}
+		result.RevertReason = reason
+	}
+	return result
+}
@@ -77,6 +77,10 @@ func (caller *MultiCaller) AddCall(contract *rocketpool.Contract, output interfa
 }
 
 func (caller *MultiCaller) Execute(requireSuccess bool, opts *bind.CallOpts) ([]CallResponse, error) {
+	return caller.ExecuteContext(rocketpool.GetCallContext(opts), requireSuccess, opts)
+}
+
+func (caller *MultiCaller) ExecuteContext(ctx context.Context, requireSuccess bool, opts *bind.CallOpts) ([]CallResponse, error) {
 	var multiCalls = make([]MultiCall, 0, len(caller.calls))
 	for _, call := range caller.calls {
 		multiCalls = append(multiCalls, call.GetMultiCall())
@@ -86,7 +90,7 @@ func (caller *MultiCaller) Execute(requireSuccess bool, opts *bind.CallOpts) ([]
 		return nil, err
 	}
 
-	resp, err := caller.Client.CallContract(context.Background(), ethereum.CallMsg{To: &caller.ContractAddress, Data: callData}, opts.BlockNumber)
+	resp, err := caller.Client.CallContract(ctx, ethereum.CallMsg{To: &caller.ContractAddress, Data: callData}, opts.BlockNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -110,8 +114,12 @@ func (caller *MultiCaller) Execute(requireSuccess bool, opts *bind.CallOpts) ([]
 }
 
 func (caller *MultiCaller) FlexibleCall(requireSuccess bool, opts *bind.CallOpts) ([]Result, error) {
+	return caller.FlexibleCallContext(rocketpool.GetCallContext(opts), requireSuccess, opts)
+}
+
+func (caller *MultiCaller) FlexibleCallContext(ctx context.Context, requireSuccess bool, opts *bind.CallOpts) ([]Result, error) {
 	res := make([]Result, len(caller.calls))
-	results, err := caller.Execute(requireSuccess, opts)
+	results, err := caller.ExecuteContext(ctx, requireSuccess, opts)
 	if err != nil {
 		caller.calls = []Call{}
 		return nil, err
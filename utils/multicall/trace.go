@@ -0,0 +1,93 @@
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// A call frame reported by the callTracer
+type CallFrame struct {
+	Type   string         `json:"type"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value,omitempty"`
+	Input  hexutil.Bytes  `json:"input"`
+	Output hexutil.Bytes  `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Calls  []CallFrame    `json:"calls,omitempty"`
+	Logs   []CallLog      `json:"logs,omitempty"`
+}
+
+// A log emitted within a call frame
+type CallLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// A client that can make raw JSON-RPC requests, such as *rpc.Client
+type TraceClient interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Trace a transaction with the callTracer, including the logs of each frame.
+// The client must serve the debug namespace.
+func TraceTransaction(ctx context.Context, client TraceClient, txHash common.Hash) (*CallFrame, error) {
+	var trace CallFrame
+	config := map[string]interface{}{
+		"tracer": "callTracer",
+		"tracerConfig": map[string]interface{}{
+			"withLog": true,
+		},
+	}
+	if err := client.CallContext(ctx, &trace, "debug_traceTransaction", txHash, config); err != nil {
+		return nil, fmt.Errorf("error tracing transaction %s: %w", txHash.Hex(), err)
+	}
+	return &trace, nil
+}
+
+// Get the regular calls a frame made
+func getCallFrames(frame *CallFrame) []*CallFrame {
+	calls := []*CallFrame{}
+	for i := range frame.Calls {
+		if frame.Calls[i].Type == "CALL" {
+			calls = append(calls, &frame.Calls[i])
+		}
+	}
+	return calls
+}
+
+// Get the logs emitted within a frame and its subcalls; the tracer drops the logs of reverted frames
+func collectFrameLogs(frame *CallFrame) []CallLog {
+	logs := append([]CallLog{}, frame.Logs...)
+	for i := range frame.Calls {
+		logs = append(logs, collectFrameLogs(&frame.Calls[i])...)
+	}
+	return logs
+}
+
+// Find the first receipt log that hasn't been matched yet and has the same contents as a traced log
+func findReceiptLog(receipt *types.Receipt, traceLog CallLog, used map[*types.Log]bool) *types.Log {
+	for _, log := range receipt.Logs {
+		if used[log] || log.Address != traceLog.Address || len(log.Topics) != len(traceLog.Topics) || !bytes.Equal(log.Data, traceLog.Data) {
+			continue
+		}
+		matches := true
+		for i, topic := range log.Topics {
+			if topic != traceLog.Topics[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			used[log] = true
+			return log
+		}
+	}
+	return nil
+}
@@ -52,6 +52,7 @@ type NetworkContracts struct {
 	// Houston
 	RocketDAOProtocolProposal *rocketpool.Contract
 	RocketDAOProtocolVerifier *rocketpool.Contract
+	RocketNetworkVoting       *rocketpool.Contract
 }
 
 type contractArtifacts struct {
@@ -171,6 +172,9 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 	}, contractArtifacts{
 		name:     "rocketDAOProtocolVerifier",
 		contract: &contracts.RocketDAOProtocolVerifier,
+	}, contractArtifacts{
+		name:     "rocketNetworkVoting",
+		contract: &contracts.RocketNetworkVoting,
 	})
 
 	// Add the address and ABI getters to multicall
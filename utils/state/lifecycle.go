@@ -0,0 +1,215 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// An action that can be taken on a minipool
+type MinipoolAction string
+
+const (
+	MinipoolAction_Stake                 MinipoolAction = "stake"
+	MinipoolAction_Promote               MinipoolAction = "promote"
+	MinipoolAction_Dissolve              MinipoolAction = "dissolve"
+	MinipoolAction_Close                 MinipoolAction = "close"
+	MinipoolAction_DistributeBalance     MinipoolAction = "distributeBalance"
+	MinipoolAction_Finalise              MinipoolAction = "finalise"
+	MinipoolAction_BeginReduceBondAmount MinipoolAction = "beginReduceBondAmount"
+	MinipoolAction_ReduceBondAmount      MinipoolAction = "reduceBondAmount"
+	MinipoolAction_DelegateUpgrade       MinipoolAction = "delegateUpgrade"
+)
+
+// The smallest bond a minipool's bond can be reduced to
+var MinimumReducedBond = eth.EthToWei(8)
+
+// Who can call a minipool action
+type MinipoolActionCaller string
+
+const (
+	MinipoolActionCaller_Owner  MinipoolActionCaller = "owner"
+	MinipoolActionCaller_Anyone MinipoolActionCaller = "anyone"
+)
+
+// A condition that must hold for a minipool action to succeed
+type MinipoolActionPrecondition struct {
+	Description string `json:"description"`
+	Met         bool   `json:"met"`
+}
+
+// Whether a minipool action is allowed, and why
+type MinipoolActionStatus struct {
+	Action        MinipoolAction               `json:"action"`
+	Caller        MinipoolActionCaller         `json:"caller"`
+	Allowed       bool                         `json:"allowed"`
+	Preconditions []MinipoolActionPrecondition `json:"preconditions"`
+
+	// The window the action's timing precondition allows it in, if it has one; a zero end means it doesn't close
+	WindowStart time.Time `json:"windowStart,omitempty"`
+	WindowEnd   time.Time `json:"windowEnd,omitempty"`
+}
+
+// Get the descriptions of the preconditions blocking the action
+func (s MinipoolActionStatus) GetBlockedReasons() []string {
+	reasons := []string{}
+	for _, precondition := range s.Preconditions {
+		if !precondition.Met {
+			reasons = append(reasons, precondition.Description)
+		}
+	}
+	return reasons
+}
+
+// Get the status of every action for a minipool at a block, using the block's network contracts
+func GetMinipoolActionsAtBlock(rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolAddress common.Address) ([]MinipoolActionStatus, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+
+	networkDetails, err := NewNetworkDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+	minipoolDetails, err := GetNativeMinipoolDetails(rp, contracts, minipoolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting details for minipool %s: %w", minipoolAddress.Hex(), err)
+	}
+	latestDelegate, err := rp.GetAddress("rocketMinipoolDelegate", opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest minipool delegate: %w", err)
+	}
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block header: %w", err)
+	}
+
+	return GetMinipoolActions(networkDetails, &minipoolDetails, *latestDelegate, time.Unix(int64(header.Time), 0)), nil
+}
+
+// Get the status of every action for a minipool in a state snapshot
+func (s *NetworkState) GetMinipoolActions(minipoolAddress common.Address, latestDelegate common.Address, blockTime time.Time) ([]MinipoolActionStatus, error) {
+	details, exists := s.GetMinipoolDetails(minipoolAddress)
+	if !exists {
+		return nil, fmt.Errorf("minipool %s is not in the network state", minipoolAddress.Hex())
+	}
+	return GetMinipoolActions(s.NetworkDetails, details, latestDelegate, blockTime), nil
+}
+
+// Get the status of every action for a minipool, based on its details and the network settings at a block
+func GetMinipoolActions(network *NetworkDetails, mpd *NativeMinipoolDetails, latestDelegate common.Address, blockTime time.Time) []MinipoolActionStatus {
+	statusTime := time.Unix(mpd.StatusTime.Int64(), 0)
+	isPrelaunch := mpd.Status == types.Prelaunch
+	isStaking := mpd.Status == types.Staking
+	isAtlas := mpd.Version >= 3
+
+	// Timing windows
+	scrubEnd := statusTime.Add(network.ScrubPeriod)
+	promotionScrubEnd := statusTime.Add(network.PromotionScrubPeriod)
+	launchTimeoutEnd := statusTime.Add(time.Duration(network.MinipoolLaunchTimeout.Int64()) * time.Second)
+	var reductionStart, reductionEnd time.Time
+	reductionBegun := mpd.ReduceBondTime != nil && mpd.ReduceBondTime.Sign() > 0
+	if reductionBegun {
+		reduceBondTime := time.Unix(mpd.ReduceBondTime.Int64(), 0)
+		reductionStart = reduceBondTime.Add(network.BondReductionWindowStart)
+		reductionEnd = reductionStart.Add(network.BondReductionWindowLength)
+	}
+
+	actions := []MinipoolActionStatus{
+		newMinipoolActionStatus(MinipoolAction_Stake, MinipoolActionCaller_Owner, scrubEnd, time.Time{},
+			precondition("minipool is in prelaunch", isPrelaunch),
+			precondition("minipool is not vacant", !mpd.IsVacant),
+			precondition("scrub period has passed", !blockTime.Before(scrubEnd)),
+		),
+		newMinipoolActionStatus(MinipoolAction_Promote, MinipoolActionCaller_Owner, promotionScrubEnd, time.Time{},
+			precondition("minipool is in prelaunch", isPrelaunch),
+			precondition("minipool is vacant", mpd.IsVacant),
+			precondition("promotion scrub period has passed", !blockTime.Before(promotionScrubEnd)),
+		),
+		newMinipoolActionStatus(MinipoolAction_Dissolve, MinipoolActionCaller_Anyone, launchTimeoutEnd, time.Time{},
+			precondition("minipool is in prelaunch", isPrelaunch),
+			precondition("launch timeout has passed", !blockTime.Before(launchTimeoutEnd)),
+		),
+		newMinipoolActionStatus(MinipoolAction_Close, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("minipool is dissolved", mpd.Status == types.Dissolved),
+		),
+		newMinipoolActionStatus(MinipoolAction_DistributeBalance, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("minipool is staking", isStaking),
+			precondition("minipool has a balance", mpd.Balance != nil && mpd.Balance.Sign() > 0),
+		),
+	}
+
+	// Finalising depends on the delegate version
+	if isAtlas {
+		actions = append(actions, newMinipoolActionStatus(MinipoolAction_Finalise, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("user balance has been distributed", mpd.UserDistributed),
+			precondition("minipool is not finalised", !mpd.Finalised),
+		))
+	} else {
+		actions = append(actions, newMinipoolActionStatus(MinipoolAction_Finalise, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("minipool is withdrawable", mpd.Status == types.Withdrawable),
+			precondition("minipool is not finalised", !mpd.Finalised),
+		))
+	}
+
+	actions = append(actions,
+		newMinipoolActionStatus(MinipoolAction_BeginReduceBondAmount, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("minipool uses the Atlas delegate", isAtlas),
+			precondition("minipool is staking", isStaking),
+			precondition("minipool is not finalised", !mpd.Finalised),
+			precondition("bond reduction is enabled", network.BondReductionEnabled),
+			precondition("bond is above the minimum bond", mpd.NodeDepositBalance != nil && mpd.NodeDepositBalance.Cmp(MinimumReducedBond) > 0),
+			precondition("bond reduction has not been cancelled", !mpd.ReduceBondCancelled),
+		),
+		newMinipoolActionStatus(MinipoolAction_ReduceBondAmount, MinipoolActionCaller_Owner, reductionStart, reductionEnd,
+			precondition("minipool uses the Atlas delegate", isAtlas),
+			precondition("minipool is staking", isStaking),
+			precondition("bond reduction has begun", reductionBegun),
+			precondition("bond reduction has not been cancelled", !mpd.ReduceBondCancelled),
+			precondition("bond reduction window has opened", reductionBegun && !blockTime.Before(reductionStart)),
+			precondition("bond reduction window has not closed", reductionBegun && blockTime.Before(reductionEnd)),
+		),
+		newMinipoolActionStatus(MinipoolAction_DelegateUpgrade, MinipoolActionCaller_Owner, time.Time{}, time.Time{},
+			precondition("minipool does not use the latest delegate automatically", !mpd.UseLatestDelegate),
+			precondition("minipool delegate is not the latest delegate", mpd.Delegate != latestDelegate),
+		),
+	)
+	return actions
+}
+
+// Get the actions that are currently allowed for a minipool
+func GetAllowedMinipoolActions(actions []MinipoolActionStatus) []MinipoolAction {
+	allowed := []MinipoolAction{}
+	for _, action := range actions {
+		if action.Allowed {
+			allowed = append(allowed, action.Action)
+		}
+	}
+	return allowed
+}
+
+// Create an action status from its preconditions
+func newMinipoolActionStatus(action MinipoolAction, caller MinipoolActionCaller, windowStart time.Time, windowEnd time.Time, preconditions ...MinipoolActionPrecondition) MinipoolActionStatus {
+	allowed := true
+	for _, precondition := range preconditions {
+		allowed = allowed && precondition.Met
+	}
+	return MinipoolActionStatus{
+		Action:        action,
+		Caller:        caller,
+		Allowed:       allowed,
+		Preconditions: preconditions,
+		WindowStart:   windowStart,
+		WindowEnd:     windowEnd,
+	}
+}
+
+func precondition(description string, met bool) MinipoolActionPrecondition {
+	return MinipoolActionPrecondition{Description: description, Met: met}
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/rocket-pool/rocketpool-go/minipool"
 	"github.com/rocket-pool/rocketpool-go/rocketpool"
 	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/beacon"
 	"github.com/rocket-pool/rocketpool-go/utils/multicall"
 	"golang.org/x/sync/errgroup"
 )
@@ -214,6 +216,19 @@ func CalculateCompleteMinipoolShares(rp *rocketpool.RocketPool, contracts *Netwo
 	return nil
 }
 
+// Calculate the node and user shares of the total minipool balance, getting the Beacon chain balances from a client
+func CalculateCompleteMinipoolSharesFromBeacon(rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolDetails []*NativeMinipoolDetails, bc rocketpool.BeaconClient, stateID string) error {
+	pubkeys := make([]types.ValidatorPubkey, len(minipoolDetails))
+	for i, details := range minipoolDetails {
+		pubkeys[i] = details.Pubkey
+	}
+	beaconBalances, err := beacon.GetBalances(context.Background(), bc, pubkeys, stateID)
+	if err != nil {
+		return fmt.Errorf("error getting Beacon chain balances: %w", err)
+	}
+	return CalculateCompleteMinipoolShares(rp, contracts, minipoolDetails, beaconBalances)
+}
+
 // Get all minipool addresses using the multicaller
 func getNodeMinipoolAddressesFast(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address, opts *bind.CallOpts) ([]common.Address, error) {
 	// Get minipool count
@@ -59,11 +59,13 @@ type NetworkDetails struct {
 	PromotionScrubPeriod      time.Duration
 	BondReductionWindowStart  time.Duration
 	BondReductionWindowLength time.Duration
+	BondReductionEnabled      bool
 	DepositPoolUserBalance    *big.Int
 
 	// Houston
 	PricesSubmissionFrequency   uint64
 	BalancesSubmissionFrequency uint64
+	MaximumStakeForVotingPower  *big.Int
 }
 
 // Create a snapshot of all of the network's details
@@ -130,11 +132,13 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) (
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &promotionScrubPeriodSeconds, "getPromotionScrubPeriod")
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowStartRaw, "getBondReductionWindowStart")
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowLengthRaw, "getBondReductionWindowLength")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &details.BondReductionEnabled, "getBondReductionEnabled")
 	contracts.Multicaller.AddCall(contracts.RocketDepositPool, &details.DepositPoolUserBalance, "getUserBalance")
 
 	// Houston
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &pricesSubmissionFrequency, "getSubmitPricesFrequency")
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &balancesSubmissionFrequency, "getSubmitBalancesFrequency")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNode, &details.MaximumStakeForVotingPower, "getMaximumStakeForVotingPower")
 
 	_, err := contracts.Multicaller.FlexibleCall(true, opts)
 	if err != nil {
@@ -49,6 +49,8 @@ type NativeNodeDetails struct {
 	AverageNodeFee                   *big.Int // Must call CalculateAverageFeeAndDistributorShares to get this
 	CollateralisationRatio           *big.Int
 	DistributorBalance               *big.Int
+	EthProvided                      *big.Int
+	VotingInitialised                bool
 }
 
 // Gets the details for a node using the efficient multicall contract
@@ -181,26 +183,40 @@ func GetAllNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContra
 }
 
 // Calculate the average node fee and user/node shares of the distributor's balance
-func CalculateAverageFeeAndDistributorShares(rp *rocketpool.RocketPool, contracts *NetworkContracts, node NativeNodeDetails, minipoolDetails []*NativeMinipoolDetails) error {
+// The results replace the node's AverageNodeFee and DistributorBalance*ETH values with new values, so copies of the
+// node details that share the old ones aren't modified
+func CalculateAverageFeeAndDistributorShares(rp *rocketpool.RocketPool, contracts *NetworkContracts, node *NativeNodeDetails, minipoolDetails []*NativeMinipoolDetails) error {
 
 	// Calculate the total of all fees for staking minipools that aren't finalized
 	totalFee := big.NewInt(0)
 	eligibleMinipools := int64(0)
 	for _, mpd := range minipoolDetails {
 		if mpd.Status == types.Staking && !mpd.Finalised {
+			if mpd.NodeFee == nil {
+				return fmt.Errorf("minipool %s has no node fee", mpd.MinipoolAddress.Hex())
+			}
 			totalFee.Add(totalFee, mpd.NodeFee)
 			eligibleMinipools++
 		}
 	}
 
 	// Get the average fee (0 if there aren't any minipools)
+	averageNodeFee := big.NewInt(0)
 	if eligibleMinipools > 0 {
-		node.AverageNodeFee.Div(totalFee, big.NewInt(eligibleMinipools))
+		averageNodeFee.Div(totalFee, big.NewInt(eligibleMinipools))
 	}
 
 	// Get the user and node portions of the distributor balance
-	distributorBalance := big.NewInt(0).Set(node.DistributorBalance)
+	distributorBalance := big.NewInt(0)
+	if node.DistributorBalance != nil {
+		distributorBalance.Set(node.DistributorBalance)
+	}
+	distributorBalanceNodeEth := big.NewInt(0)
+	distributorBalanceUserEth := big.NewInt(0)
 	if distributorBalance.Cmp(big.NewInt(0)) > 0 {
+		if node.CollateralisationRatio == nil || node.CollateralisationRatio.Sign() <= 0 {
+			return fmt.Errorf("node %s has no collateralisation ratio", node.NodeAddress.Hex())
+		}
 		nodeBalance := big.NewInt(0)
 		nodeBalance.Mul(distributorBalance, big.NewInt(1e18))
 		nodeBalance.Div(nodeBalance, node.CollateralisationRatio)
@@ -210,24 +226,22 @@ func CalculateAverageFeeAndDistributorShares(rp *rocketpool.RocketPool, contract
 
 		if eligibleMinipools == 0 {
 			// Split it based solely on the collateralisation ratio if there are no minipools (and hence no average fee)
-			node.DistributorBalanceNodeETH = big.NewInt(0).Set(nodeBalance)
-			node.DistributorBalanceUserETH = big.NewInt(0).Sub(distributorBalance, nodeBalance)
+			distributorBalanceNodeEth.Set(nodeBalance)
+			distributorBalanceUserEth.Sub(distributorBalance, nodeBalance)
 		} else {
 			// Amount of ETH given to the NO as a commission
 			commissionEth := big.NewInt(0)
-			commissionEth.Mul(userBalance, node.AverageNodeFee)
+			commissionEth.Mul(userBalance, averageNodeFee)
 			commissionEth.Div(commissionEth, big.NewInt(1e18))
 
-			node.DistributorBalanceNodeETH.Add(nodeBalance, commissionEth)                         // Node gets their portion + commission on user portion
-			node.DistributorBalanceUserETH.Sub(distributorBalance, node.DistributorBalanceNodeETH) // User gets balance - node share
+			distributorBalanceNodeEth.Add(nodeBalance, commissionEth)                    // Node gets their portion + commission on user portion
+			distributorBalanceUserEth.Sub(distributorBalance, distributorBalanceNodeEth) // User gets balance - node share
 		}
-
-	} else {
-		// No distributor balance
-		node.DistributorBalanceNodeETH = big.NewInt(0)
-		node.DistributorBalanceUserETH = big.NewInt(0)
 	}
 
+	node.AverageNodeFee = averageNodeFee
+	node.DistributorBalanceNodeETH = distributorBalanceNodeEth
+	node.DistributorBalanceUserETH = distributorBalanceUserEth
 	return nil
 }
 
@@ -303,4 +317,8 @@ func addNodeDetailsCalls(contracts *NetworkContracts, mc *multicall.MultiCaller,
 	// Atlas
 	mc.AddCall(contracts.RocketNodeDeposit, &details.DepositCreditBalance, "getNodeDepositCredit", address)
 	mc.AddCall(contracts.RocketNodeStaking, &details.CollateralisationRatio, "getNodeETHCollateralisationRatio", address)
+
+	// Houston
+	mc.AddCall(contracts.RocketNodeStaking, &details.EthProvided, "getNodeETHProvided", address)
+	mc.AddCall(contracts.RocketNetworkVoting, &details.VotingInitialised, "getVotingInitialised", address)
 }
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// A snapshot of the network, its nodes, minipools and DAOs at a single block
+type NetworkState struct {
+	// The block the snapshot was taken at
+	ElBlockNumber uint64 `json:"elBlockNumber"`
+
+	// Network details
+	NetworkDetails *NetworkDetails `json:"networkDetails"`
+
+	// Node details
+	NodeDetails []NativeNodeDetails `json:"nodeDetails"`
+
+	// Minipool details
+	MinipoolDetails []NativeMinipoolDetails `json:"minipoolDetails"`
+
+	// Oracle DAO details
+	OracleDaoMemberDetails []OracleDaoMemberDetails `json:"oracleDaoMemberDetails"`
+
+	// Protocol DAO proposals (empty before Houston)
+	ProtocolDaoProposalDetails []protocol.ProtocolDaoProposalDetails `json:"protocolDaoProposalDetails"`
+
+	// Lookups, built by BuildLookups
+	nodeDetailsByAddress            map[common.Address]*NativeNodeDetails
+	minipoolDetailsByAddress        map[common.Address]*NativeMinipoolDetails
+	minipoolDetailsByNode           map[common.Address][]*NativeMinipoolDetails
+	oracleDaoMemberDetailsByAddress map[common.Address]*OracleDaoMemberDetails
+}
+
+// Create a snapshot of the network at the block in opts (or the latest block if opts is nil)
+func CreateNetworkState(rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, opts *bind.CallOpts) (*NetworkState, error) {
+	contracts, err := NewNetworkContracts(rp, multicallerAddress, balanceBatcherAddress, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network contracts: %w", err)
+	}
+	return CreateNetworkStateFromContracts(rp, contracts)
+}
+
+// Create a snapshot of the network at the block of a network contracts container
+func CreateNetworkStateFromContracts(rp *rocketpool.RocketPool, contracts *NetworkContracts) (*NetworkState, error) {
+	state := &NetworkState{
+		ElBlockNumber:              contracts.ElBlockNumber.Uint64(),
+		ProtocolDaoProposalDetails: []protocol.ProtocolDaoProposalDetails{},
+	}
+
+	// Network details
+	var err error
+	state.NetworkDetails, err = NewNetworkDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+
+	// Node details
+	state.NodeDetails, err = GetAllNativeNodeDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting all node details: %w", err)
+	}
+
+	// Minipool details
+	state.MinipoolDetails, err = GetAllNativeMinipoolDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting all minipool details: %w", err)
+	}
+
+	// Oracle DAO details
+	state.OracleDaoMemberDetails, err = GetAllOracleDaoMemberDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Oracle DAO details: %w", err)
+	}
+
+	// Protocol DAO proposals
+	if contracts.RocketDAOProtocolProposal.Address != nil && *contracts.RocketDAOProtocolProposal.Address != (common.Address{}) {
+		state.ProtocolDaoProposalDetails, err = GetAllProtocolDaoProposalDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Protocol DAO proposal details: %w", err)
+		}
+	}
+
+	// Cross-link the details and calculate the derived node fields
+	state.BuildLookups()
+	for i := range state.NodeDetails {
+		node := &state.NodeDetails[i]
+		err = CalculateAverageFeeAndDistributorShares(rp, contracts, node, state.minipoolDetailsByNode[node.NodeAddress])
+		if err != nil {
+			return nil, fmt.Errorf("error calculating average fee and distributor shares for node %s: %w", node.NodeAddress.Hex(), err)
+		}
+	}
+
+	return state, nil
+}
+
+// Build the address lookups and per-node minipool lists.
+// This must be called again after modifying the detail slices of a state that wasn't created by this package.
+func (s *NetworkState) BuildLookups() {
+	s.nodeDetailsByAddress = make(map[common.Address]*NativeNodeDetails, len(s.NodeDetails))
+	s.minipoolDetailsByAddress = make(map[common.Address]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	s.minipoolDetailsByNode = make(map[common.Address][]*NativeMinipoolDetails, len(s.NodeDetails))
+	s.oracleDaoMemberDetailsByAddress = make(map[common.Address]*OracleDaoMemberDetails, len(s.OracleDaoMemberDetails))
+
+	for i := range s.NodeDetails {
+		details := &s.NodeDetails[i]
+		s.nodeDetailsByAddress[details.NodeAddress] = details
+		s.minipoolDetailsByNode[details.NodeAddress] = []*NativeMinipoolDetails{}
+	}
+	for i := range s.MinipoolDetails {
+		details := &s.MinipoolDetails[i]
+		s.minipoolDetailsByAddress[details.MinipoolAddress] = details
+		s.minipoolDetailsByNode[details.NodeAddress] = append(s.minipoolDetailsByNode[details.NodeAddress], details)
+	}
+	for i := range s.OracleDaoMemberDetails {
+		details := &s.OracleDaoMemberDetails[i]
+		s.oracleDaoMemberDetailsByAddress[details.Address] = details
+	}
+}
+
+// Get a node's details
+func (s *NetworkState) GetNodeDetails(nodeAddress common.Address) (*NativeNodeDetails, bool) {
+	if s.nodeDetailsByAddress == nil {
+		s.BuildLookups()
+	}
+	details, exists := s.nodeDetailsByAddress[nodeAddress]
+	return details, exists
+}
+
+// Get a minipool's details
+func (s *NetworkState) GetMinipoolDetails(minipoolAddress common.Address) (*NativeMinipoolDetails, bool) {
+	if s.minipoolDetailsByAddress == nil {
+		s.BuildLookups()
+	}
+	details, exists := s.minipoolDetailsByAddress[minipoolAddress]
+	return details, exists
+}
+
+// Get the details of a node's minipools
+func (s *NetworkState) GetNodeMinipoolDetails(nodeAddress common.Address) []*NativeMinipoolDetails {
+	if s.minipoolDetailsByNode == nil {
+		s.BuildLookups()
+	}
+	return s.minipoolDetailsByNode[nodeAddress]
+}
+
+// Get an Oracle DAO member's details
+func (s *NetworkState) GetOracleDaoMemberDetails(memberAddress common.Address) (*OracleDaoMemberDetails, bool) {
+	if s.oracleDaoMemberDetailsByAddress == nil {
+		s.BuildLookups()
+	}
+	details, exists := s.oracleDaoMemberDetailsByAddress[memberAddress]
+	return details, exists
+}
+
+// Serialize the state to JSON
+func (s *NetworkState) Serialize() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// Serialize the state to gzipped JSON
+func (s *NetworkState) SerializeGzip() ([]byte, error) {
+	data, err := s.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("error compressing network state: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing network state: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Save the state to a file, compressing it if the path ends in .gz
+func (s *NetworkState) Save(path string) error {
+	var data []byte
+	var err error
+	if strings.HasSuffix(path, ".gz") {
+		data, err = s.SerializeGzip()
+	} else {
+		data, err = s.Serialize()
+	}
+	if err != nil {
+		return fmt.Errorf("error serializing network state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving network state to %s: %w", path, err)
+	}
+	return nil
+}
+
+// Parse a state from JSON or gzipped JSON
+func ParseNetworkState(data []byte) (*NetworkState, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing network state: %w", err)
+		}
+		defer reader.Close()
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing network state: %w", err)
+		}
+	}
+	state := &NetworkState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing network state: %w", err)
+	}
+	state.BuildLookups()
+	return state, nil
+}
+
+// Load a state from a JSON or gzipped JSON file
+func LoadNetworkState(path string) (*NetworkState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading network state from %s: %w", path, err)
+	}
+	return ParseNetworkState(data)
+}
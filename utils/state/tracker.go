@@ -0,0 +1,499 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// Settings
+const (
+	DefaultReconcileInterval uint64 = 7200
+	DefaultLogIntervalSize   int64  = 1000
+)
+
+// The details of a network state that a range of blocks changed
+type StateChanges struct {
+	// Set if the state needs a full snapshot, e.g. because a contract was upgraded
+	Reconcile bool `json:"reconcile"`
+
+	// Set if every node needs refreshing, e.g. because the RPL price changed their effective stakes
+	AllNodes bool `json:"allNodes"`
+
+	// Set if the Oracle DAO or Protocol DAO details need refreshing
+	OracleDao   bool `json:"oracleDao"`
+	ProtocolDao bool `json:"protocolDao"`
+
+	// The nodes and minipools to refresh, including newly created ones
+	Nodes     map[common.Address]bool `json:"nodes"`
+	Minipools map[common.Address]bool `json:"minipools"`
+
+	// Minipools that were destroyed
+	RemovedMinipools map[common.Address]bool `json:"removedMinipools"`
+}
+
+// Create an empty set of changes
+func NewStateChanges() *StateChanges {
+	return &StateChanges{
+		Nodes:            map[common.Address]bool{},
+		Minipools:        map[common.Address]bool{},
+		RemovedMinipools: map[common.Address]bool{},
+	}
+}
+
+// Maps Rocket Pool logs to the details of a network state they affect
+type ChangeDetector struct {
+	// Contracts whose events carry a node address as their first indexed argument
+	NodeContracts []common.Address
+
+	// Contracts whose events may refer to existing minipools and nodes
+	MinipoolContracts []common.Address
+
+	// Contracts whose events change the Oracle DAO or Protocol DAO details
+	OracleDaoContracts   []common.Address
+	ProtocolDaoContracts []common.Address
+
+	// The price oracle, whose updates change every node's effective stake
+	PricesContract common.Address
+
+	// The upgrade contract, whose events require a full snapshot
+	UpgradeContract common.Address
+
+	// The IDs of the minipool manager's MinipoolCreated and MinipoolDestroyed events
+	MinipoolCreatedID   common.Hash
+	MinipoolDestroyedID common.Hash
+}
+
+// Create a change detector for the contracts of a network
+func NewChangeDetector(rp *rocketpool.RocketPool, contracts *NetworkContracts) (*ChangeDetector, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	rocketDAONodeTrustedActions, err := rp.GetContract("rocketDAONodeTrustedActions", opts)
+	if err != nil {
+		return nil, err
+	}
+	rocketDAONodeTrustedUpgrade, err := rp.GetContract("rocketDAONodeTrustedUpgrade", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	detector := &ChangeDetector{
+		NodeContracts:        getContractAddresses(contracts.RocketNodeManager, contracts.RocketNodeStaking, contracts.RocketNodeDeposit),
+		MinipoolContracts:    getContractAddresses(contracts.RocketMinipoolManager, contracts.RocketMinipoolQueue, contracts.RocketMinipoolBondReducer, contracts.RocketDepositPool),
+		OracleDaoContracts:   getContractAddresses(contracts.RocketDAONodeTrusted, rocketDAONodeTrustedActions),
+		ProtocolDaoContracts: getContractAddresses(contracts.RocketDAOProtocolProposal, contracts.RocketDAOProtocolVerifier),
+		PricesContract:       *contracts.RocketNetworkPrices.Address,
+		UpgradeContract:      *rocketDAONodeTrustedUpgrade.Address,
+	}
+	if event, exists := contracts.RocketMinipoolManager.ABI.Events["MinipoolCreated"]; exists {
+		detector.MinipoolCreatedID = event.ID
+	}
+	if event, exists := contracts.RocketMinipoolManager.ABI.Events["MinipoolDestroyed"]; exists {
+		detector.MinipoolDestroyedID = event.ID
+	}
+	return detector, nil
+}
+
+// Get the addresses of every contract the detector follows
+func (d *ChangeDetector) GetAddresses() []common.Address {
+	addresses := []common.Address{d.PricesContract, d.UpgradeContract}
+	addresses = append(addresses, d.NodeContracts...)
+	addresses = append(addresses, d.MinipoolContracts...)
+	addresses = append(addresses, d.OracleDaoContracts...)
+	addresses = append(addresses, d.ProtocolDaoContracts...)
+	return addresses
+}
+
+// Get the changes a set of logs makes to a network state.
+// Logs emitted by minipools are recognised by their address, so they must belong to a minipool in the state or one
+// created in the same set of logs.
+func (d *ChangeDetector) GetChanges(state *NetworkState, logs []types.Log) *StateChanges {
+	changes := NewStateChanges()
+	nodeContracts := getAddressSet(d.NodeContracts)
+	minipoolContracts := getAddressSet(d.MinipoolContracts)
+	oracleDaoContracts := getAddressSet(d.OracleDaoContracts)
+	protocolDaoContracts := getAddressSet(d.ProtocolDaoContracts)
+
+	// Find new minipools first so their own events in the same range are recognised
+	for _, log := range logs {
+		if log.Removed || !minipoolContracts[log.Address] || len(log.Topics) < 3 || log.Topics[0] != d.MinipoolCreatedID {
+			continue
+		}
+		if minipool, ok := getTopicAddress(log.Topics[1]); ok {
+			changes.Minipools[minipool] = true
+		}
+		if node, ok := getTopicAddress(log.Topics[2]); ok {
+			changes.Nodes[node] = true
+		}
+	}
+
+	for _, log := range logs {
+		if log.Removed {
+			changes.Reconcile = true
+			continue
+		}
+		switch {
+		case log.Address == d.UpgradeContract:
+			changes.Reconcile = true
+
+		case log.Address == d.PricesContract:
+			changes.AllNodes = true
+
+		case nodeContracts[log.Address]:
+			if len(log.Topics) > 1 {
+				if node, ok := getTopicAddress(log.Topics[1]); ok {
+					changes.Nodes[node] = true
+				}
+			}
+
+		case minipoolContracts[log.Address]:
+			if len(log.Topics) > 2 && log.Topics[0] == d.MinipoolDestroyedID {
+				if minipool, ok := getTopicAddress(log.Topics[1]); ok {
+					changes.RemovedMinipools[minipool] = true
+				}
+				if node, ok := getTopicAddress(log.Topics[2]); ok {
+					changes.Nodes[node] = true
+				}
+				continue
+			}
+			for i := 1; i < len(log.Topics); i++ {
+				address, ok := getTopicAddress(log.Topics[i])
+				if !ok {
+					continue
+				}
+				if _, exists := state.GetMinipoolDetails(address); exists {
+					changes.Minipools[address] = true
+				} else if _, exists := state.GetNodeDetails(address); exists {
+					changes.Nodes[address] = true
+				}
+			}
+
+		case oracleDaoContracts[log.Address]:
+			changes.OracleDao = true
+
+		case protocolDaoContracts[log.Address]:
+			changes.ProtocolDao = true
+
+		default:
+			if _, exists := state.GetMinipoolDetails(log.Address); exists || changes.Minipools[log.Address] {
+				changes.Minipools[log.Address] = true
+			}
+		}
+	}
+
+	// Destroyed minipools don't need refreshing, and refreshed minipools need their nodes refreshed
+	for minipool := range changes.RemovedMinipools {
+		delete(changes.Minipools, minipool)
+	}
+	for minipool := range changes.Minipools {
+		if details, exists := state.GetMinipoolDetails(minipool); exists {
+			changes.Nodes[details.NodeAddress] = true
+		}
+	}
+	return changes
+}
+
+// Keeps a network state up to date by refreshing only the nodes and minipools each new range of blocks affects.
+// Details that change without an event, such as ETH balances and DAO proposal phases, are brought up to date by the
+// network details refresh on every update and by the periodic full reconciliation.
+type StateTracker struct {
+	// The number of blocks between full snapshots
+	ReconcileInterval uint64
+
+	// The block range of each log query
+	LogIntervalSize *big.Int
+
+	rp                    *rocketpool.RocketPool
+	multicallerAddress    common.Address
+	balanceBatcherAddress common.Address
+	contracts             *NetworkContracts
+	detector              *ChangeDetector
+	minipoolEventIDs      []common.Hash
+	state                 *NetworkState
+	lastReconcileBlock    uint64
+	lock                  sync.Mutex
+}
+
+// Create a state tracker, starting from a full snapshot at the block in opts (or the latest block if opts is nil)
+func NewStateTracker(rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, opts *bind.CallOpts) (*StateTracker, error) {
+	tracker := &StateTracker{
+		ReconcileInterval:     DefaultReconcileInterval,
+		LogIntervalSize:       big.NewInt(DefaultLogIntervalSize),
+		rp:                    rp,
+		multicallerAddress:    multicallerAddress,
+		balanceBatcherAddress: balanceBatcherAddress,
+	}
+	if err := tracker.reconcile(opts); err != nil {
+		return nil, err
+	}
+	return tracker, nil
+}
+
+// Get the current state.
+// States are never modified after they're returned, so callers can keep using an old state while the tracker updates.
+func (t *StateTracker) GetState() *NetworkState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.state
+}
+
+// Update the state to the latest block
+func (t *StateTracker) UpdateToLatest() (*StateChanges, error) {
+	latestBlock, err := t.rp.Client.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest block number: %w", err)
+	}
+	return t.Update(latestBlock)
+}
+
+// Update the state to a block, applying the changes of every block since the current state.
+// A full snapshot is taken instead if the reconciliation interval has passed or the blocks upgraded a contract.
+func (t *StateTracker) Update(blockNumber uint64) (*StateChanges, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if blockNumber <= t.state.ElBlockNumber {
+		return NewStateChanges(), nil
+	}
+	if blockNumber-t.lastReconcileBlock >= t.ReconcileInterval {
+		return t.reconcileAt(blockNumber)
+	}
+
+	// Get the logs since the current state
+	fromBlock := big.NewInt(0).SetUint64(t.state.ElBlockNumber + 1)
+	toBlock := big.NewInt(0).SetUint64(blockNumber)
+	logs, err := eth.GetLogs(t.rp, t.detector.GetAddresses(), nil, t.LogIntervalSize, fromBlock, toBlock, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network logs: %w", err)
+	}
+	minipoolLogs, err := eth.GetLogs(t.rp, nil, [][]common.Hash{t.minipoolEventIDs}, t.LogIntervalSize, fromBlock, toBlock, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool logs: %w", err)
+	}
+	changes := t.detector.GetChanges(t.state, append(logs, minipoolLogs...))
+	if changes.Reconcile {
+		return t.reconcileAt(blockNumber)
+	}
+
+	// Apply the changes
+	state, err := t.applyChanges(blockNumber, changes)
+	if err != nil {
+		return nil, fmt.Errorf("error updating network state to block %d: %w", blockNumber, err)
+	}
+	t.state = state
+	return changes, nil
+}
+
+// Replace the state with a full snapshot at a block
+func (t *StateTracker) Reconcile(blockNumber uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	_, err := t.reconcileAt(blockNumber)
+	return err
+}
+
+// Replace the state with a full snapshot at a block, returning the changes that reported it
+func (t *StateTracker) reconcileAt(blockNumber uint64) (*StateChanges, error) {
+	if err := t.reconcile(&bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(blockNumber)}); err != nil {
+		return nil, err
+	}
+	changes := NewStateChanges()
+	changes.Reconcile = true
+	return changes, nil
+}
+
+// Take a full snapshot and reload the contracts the tracker follows
+func (t *StateTracker) reconcile(opts *bind.CallOpts) error {
+	contracts, err := NewNetworkContracts(t.rp, t.multicallerAddress, t.balanceBatcherAddress, opts)
+	if err != nil {
+		return fmt.Errorf("error getting network contracts: %w", err)
+	}
+	detector, err := NewChangeDetector(t.rp, contracts)
+	if err != nil {
+		return fmt.Errorf("error creating change detector: %w", err)
+	}
+	rocketMinipoolDelegate, err := t.rp.GetContract("rocketMinipoolDelegate", &bind.CallOpts{BlockNumber: contracts.ElBlockNumber})
+	if err != nil {
+		return err
+	}
+	state, err := CreateNetworkStateFromContracts(t.rp, contracts)
+	if err != nil {
+		return err
+	}
+
+	t.minipoolEventIDs = []common.Hash{}
+	for _, event := range rocketMinipoolDelegate.ABI.Events {
+		t.minipoolEventIDs = append(t.minipoolEventIDs, event.ID)
+	}
+	sort.Slice(t.minipoolEventIDs, func(i, j int) bool {
+		return bytes.Compare(t.minipoolEventIDs[i].Bytes(), t.minipoolEventIDs[j].Bytes()) < 0
+	})
+	t.contracts = contracts
+	t.detector = detector
+	t.state = state
+	t.lastReconcileBlock = state.ElBlockNumber
+	return nil
+}
+
+// Build a new state at a block from the current one, refreshing the changed details
+func (t *StateTracker) applyChanges(blockNumber uint64, changes *StateChanges) (*NetworkState, error) {
+	contracts := *t.contracts
+	contracts.ElBlockNumber = big.NewInt(0).SetUint64(blockNumber)
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+
+	state := &NetworkState{
+		ElBlockNumber:              blockNumber,
+		NodeDetails:                append([]NativeNodeDetails{}, t.state.NodeDetails...),
+		MinipoolDetails:            make([]NativeMinipoolDetails, 0, len(t.state.MinipoolDetails)),
+		OracleDaoMemberDetails:     t.state.OracleDaoMemberDetails,
+		ProtocolDaoProposalDetails: t.state.ProtocolDaoProposalDetails,
+	}
+	for _, mpd := range t.state.MinipoolDetails {
+		if !changes.RemovedMinipools[mpd.MinipoolAddress] {
+			state.MinipoolDetails = append(state.MinipoolDetails, mpd)
+		}
+	}
+
+	// Network details
+	var err error
+	state.NetworkDetails, err = NewNetworkDetails(t.rp, &contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+
+	// Minipools
+	minipoolAddresses := getSortedAddresses(changes.Minipools)
+	if len(minipoolAddresses) > 0 {
+		versions, err := getMinipoolVersionsFast(t.rp, &contracts, minipoolAddresses, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting minipool versions: %w", err)
+		}
+		details, err := getBulkMinipoolDetails(t.rp, &contracts, minipoolAddresses, versions, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting minipool details: %w", err)
+		}
+		indices := make(map[common.Address]int, len(state.MinipoolDetails))
+		for i, mpd := range state.MinipoolDetails {
+			indices[mpd.MinipoolAddress] = i
+		}
+		for _, mpd := range details {
+			if i, exists := indices[mpd.MinipoolAddress]; exists {
+				state.MinipoolDetails[i] = mpd
+			} else {
+				state.MinipoolDetails = append(state.MinipoolDetails, mpd)
+			}
+		}
+	}
+
+	// Nodes
+	refreshedNodes := changes.Nodes
+	if changes.AllNodes {
+		state.NodeDetails, err = GetAllNativeNodeDetails(t.rp, &contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting all node details: %w", err)
+		}
+		refreshedNodes = map[common.Address]bool{}
+		for _, node := range state.NodeDetails {
+			refreshedNodes[node.NodeAddress] = true
+		}
+	} else {
+		indices := make(map[common.Address]int, len(state.NodeDetails))
+		for i, node := range state.NodeDetails {
+			indices[node.NodeAddress] = i
+		}
+		for _, address := range getSortedAddresses(changes.Nodes) {
+			node, err := GetNativeNodeDetails(t.rp, &contracts, address)
+			if err != nil {
+				return nil, fmt.Errorf("error getting details for node %s: %w", address.Hex(), err)
+			}
+			if i, exists := indices[address]; exists {
+				state.NodeDetails[i] = node
+			} else if node.Exists {
+				state.NodeDetails = append(state.NodeDetails, node)
+			}
+		}
+	}
+
+	// DAOs
+	if changes.OracleDao {
+		state.OracleDaoMemberDetails, err = GetAllOracleDaoMemberDetails(t.rp, &contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Oracle DAO details: %w", err)
+		}
+	}
+	if changes.ProtocolDao {
+		state.ProtocolDaoProposalDetails, err = GetAllProtocolDaoProposalDetails(t.rp, &contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Protocol DAO proposal details: %w", err)
+		}
+	}
+
+	// Cross-link the details and recalculate the derived fields of the refreshed nodes
+	state.BuildLookups()
+	for address := range refreshedNodes {
+		node, exists := state.GetNodeDetails(address)
+		if !exists {
+			continue
+		}
+		err = CalculateAverageFeeAndDistributorShares(t.rp, &contracts, node, state.GetNodeMinipoolDetails(address))
+		if err != nil {
+			return nil, fmt.Errorf("error calculating average fee and distributor shares for node %s: %w", address.Hex(), err)
+		}
+	}
+	return state, nil
+}
+
+// Get the addresses of a set of contracts, skipping ones that aren't deployed
+func getContractAddresses(contracts ...*rocketpool.Contract) []common.Address {
+	addresses := []common.Address{}
+	for _, contract := range contracts {
+		if contract != nil && contract.Address != nil && *contract.Address != (common.Address{}) {
+			addresses = append(addresses, *contract.Address)
+		}
+	}
+	return addresses
+}
+
+// Get a lookup set for a list of addresses
+func getAddressSet(addresses []common.Address) map[common.Address]bool {
+	set := make(map[common.Address]bool, len(addresses))
+	for _, address := range addresses {
+		set[address] = true
+	}
+	return set
+}
+
+// Get the addresses of a set in ascending order
+func getSortedAddresses(set map[common.Address]bool) []common.Address {
+	addresses := make([]common.Address, 0, len(set))
+	for address := range set {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i].Bytes(), addresses[j].Bytes()) < 0
+	})
+	return addresses
+}
+
+// Get the address in an indexed log topic, if the topic holds one
+func getTopicAddress(topic common.Hash) (common.Address, bool) {
+	for _, b := range topic[:common.HashLength-common.AddressLength] {
+		if b != 0 {
+			return common.Address{}, false
+		}
+	}
+	address := common.BytesToAddress(topic.Bytes())
+	return address, address != (common.Address{})
+}
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/rocket-pool/rocketpool-go/network"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// A node whose calculated voting power doesn't match RocketNetworkVoting
+type VotingPowerMismatch struct {
+	NodeAddress common.Address `json:"nodeAddress"`
+	Calculated  *big.Int       `json:"calculated"`
+	OnChain     *big.Int       `json:"onChain"`
+}
+
+// Get the RPL stake that counts towards voting power: the node's stake, capped at the maximum stake for voting power.
+// The cap is a fraction (scaled by 1e18) of the ETH the node has bonded, valued in RPL; a zero RPL price gives no stake.
+func GetVotingPowerStake(rplStake *big.Int, ethProvided *big.Int, rplPrice *big.Int, maximumStakePercent *big.Int) *big.Int {
+	if rplStake == nil || ethProvided == nil || rplPrice == nil || maximumStakePercent == nil || rplPrice.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	maximumStake := big.NewInt(0).Mul(ethProvided, maximumStakePercent)
+	maximumStake.Div(maximumStake, rplPrice)
+	if rplStake.Cmp(maximumStake) > 0 {
+		return maximumStake
+	}
+	return big.NewInt(0).Set(rplStake)
+}
+
+// Calculate voting power with the Houston formula: the square root of the capped RPL stake, in 1e18 fixed point
+func CalculateVotingPower(rplStake *big.Int, ethProvided *big.Int, rplPrice *big.Int, maximumStakePercent *big.Int) *big.Int {
+	stake := GetVotingPowerStake(rplStake, ethProvided, rplPrice, maximumStakePercent)
+	stake.Mul(stake, eth.EthToWei(1))
+	return stake.Sqrt(stake)
+}
+
+// Calculate a node's voting power from its details and the network settings at the same block.
+// Nodes that haven't initialised voting have no voting power.
+func GetNodeVotingPower(networkDetails *NetworkDetails, node *NativeNodeDetails) *big.Int {
+	if !node.VotingInitialised {
+		return big.NewInt(0)
+	}
+	return CalculateVotingPower(node.RplStake, node.EthProvided, networkDetails.RplPrice, networkDetails.MaximumStakeForVotingPower)
+}
+
+// Calculate a node's voting power in a state snapshot
+func (s *NetworkState) GetNodeVotingPower(nodeAddress common.Address) (*big.Int, error) {
+	node, exists := s.GetNodeDetails(nodeAddress)
+	if !exists {
+		return nil, fmt.Errorf("node %s is not in the network state", nodeAddress.Hex())
+	}
+	return GetNodeVotingPower(s.NetworkDetails, node), nil
+}
+
+// Project a node's voting power if it staked (positive) or withdrew (negative) an amount of RPL
+func (s *NetworkState) ProjectNodeVotingPower(nodeAddress common.Address, rplStakeChange *big.Int) (*big.Int, error) {
+	node, exists := s.GetNodeDetails(nodeAddress)
+	if !exists {
+		return nil, fmt.Errorf("node %s is not in the network state", nodeAddress.Hex())
+	}
+	projected := *node
+	projected.RplStake = big.NewInt(0).Add(node.RplStake, rplStakeChange)
+	if projected.RplStake.Sign() < 0 {
+		return nil, fmt.Errorf("node %s can't withdraw more than its RPL stake", nodeAddress.Hex())
+	}
+	return GetNodeVotingPower(s.NetworkDetails, &projected), nil
+}
+
+// Compare the calculated voting power of every voting node against RocketNetworkVoting's getVotingPower at the snapshot's block
+func (s *NetworkState) CheckVotingPowers(rp *rocketpool.RocketPool, multicallerAddress common.Address) ([]VotingPowerMismatch, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: big.NewInt(0).SetUint64(s.ElBlockNumber),
+	}
+	votingInfos, err := network.GetNodeInfoSnapshotFast(rp, uint32(s.ElBlockNumber), multicallerAddress, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting on-chain voting power: %w", err)
+	}
+
+	mismatches := []VotingPowerMismatch{}
+	for _, info := range votingInfos {
+		calculated := big.NewInt(0)
+		if node, exists := s.GetNodeDetails(info.NodeAddress); exists {
+			calculated = GetNodeVotingPower(s.NetworkDetails, node)
+		}
+		if info.VotingPower == nil || calculated.Cmp(info.VotingPower) != 0 {
+			mismatches = append(mismatches, VotingPowerMismatch{
+				NodeAddress: info.NodeAddress,
+				Calculated:  calculated,
+				OnChain:     info.VotingPower,
+			})
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,241 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// Settings
+const (
+	DefaultConfirmations  uint64        = 1
+	DefaultPollInterval   time.Duration = 2 * time.Second
+	DefaultFeeBumpPercent uint64        = 10
+	MinFeeBumpPercent     uint64        = 10 // Execution clients reject replacements with a smaller bump
+	CancelGasLimit        uint64        = 21000
+)
+
+// Manager settings
+type Config struct {
+	// Number of blocks a transaction must be buried under (including its own) before its receipt is returned
+	Confirmations uint64
+
+	// How often to check for receipts while waiting
+	PollInterval time.Duration
+
+	// How much to raise the fees of a replacement transaction by, as a percentage
+	FeeBumpPercent uint64
+
+	// If set, pending transactions are replaced with bumped fees after waiting this long
+	ReplaceAfter time.Duration
+
+	// If set, automatic replacements never raise the max fee above this
+	MaxFeeCap *big.Int
+}
+
+// Sends transactions, allocating nonces locally so concurrent submissions from the same account are queued
+type Manager struct {
+	client   rocketpool.ExecutionClient
+	chainID  *big.Int
+	config   Config
+	accounts map[common.Address]*account
+	decoder  *eventDecoder
+	lock     sync.Mutex
+}
+
+// The local nonce state of an account
+type account struct {
+	nextNonce *uint64
+	lock      sync.Mutex
+}
+
+// Create a new transaction manager
+// If rp is set, events emitted by any Rocket Pool contract are decoded into receipts
+func NewManager(client rocketpool.ExecutionClient, chainID *big.Int, rp *rocketpool.RocketPool, config Config) *Manager {
+	if config.Confirmations == 0 {
+		config.Confirmations = DefaultConfirmations
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.FeeBumpPercent < MinFeeBumpPercent {
+		config.FeeBumpPercent = DefaultFeeBumpPercent
+	}
+	return &Manager{
+		client:   client,
+		chainID:  chainID,
+		config:   config,
+		accounts: map[common.Address]*account{},
+		decoder:  newEventDecoder(rp),
+	}
+}
+
+// Register contracts whose events should be decoded into receipts
+func (m *Manager) RegisterContracts(contracts ...*rocketpool.Contract) {
+	m.decoder.register(contracts...)
+}
+
+// Discard the local nonce of an account so it is reloaded from the network on the next submission
+func (m *Manager) ResetNonce(address common.Address) {
+	acct := m.getAccount(address)
+	acct.lock.Lock()
+	defer acct.lock.Unlock()
+	acct.nextNonce = nil
+}
+
+// Transact on a contract method, using the next local nonce of the sender
+func (m *Manager) Transact(ctx context.Context, contract *rocketpool.Contract, opts *bind.TransactOpts, method string, params ...interface{}) (*Transaction, error) {
+	m.decoder.register(contract)
+	return m.submit(ctx, opts, func(optsWithNonce *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.TransactContext(ctx, optsWithNonce, method, params...)
+	})
+}
+
+// Send a transaction with arbitrary data to an address, using the next local nonce of the sender
+func (m *Manager) Send(ctx context.Context, toAddress common.Address, data []byte, opts *bind.TransactOpts) (*Transaction, error) {
+	return m.submit(ctx, opts, func(optsWithNonce *bind.TransactOpts) (*types.Transaction, error) {
+		value := optsWithNonce.Value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+
+		// Estimate gas limit
+		gasLimit := optsWithNonce.GasLimit
+		if gasLimit == 0 {
+			estimate, err := m.client.EstimateGas(ctx, ethereum.CallMsg{
+				From:  optsWithNonce.From,
+				To:    &toAddress,
+				Data:  data,
+				Value: value,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error estimating gas: %w", err)
+			}
+			gasLimit = uint64(float64(estimate) * rocketpool.GasLimitMultiplier)
+		}
+
+		// Get fees
+		tipCap, feeCap, err := m.getFees(ctx, optsWithNonce.GasTipCap, optsWithNonce.GasFeeCap)
+		if err != nil {
+			return nil, err
+		}
+
+		// Sign and send
+		tx, err := optsWithNonce.Signer(optsWithNonce.From, types.NewTx(&types.DynamicFeeTx{
+			ChainID:   m.chainID,
+			Nonce:     optsWithNonce.Nonce.Uint64(),
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       gasLimit,
+			To:        &toAddress,
+			Value:     value,
+			Data:      data,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("error signing transaction: %w", err)
+		}
+		if err := m.client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	})
+}
+
+// Submit a transaction with the next local nonce of the sender, holding the account lock until it is sent
+func (m *Manager) submit(ctx context.Context, opts *bind.TransactOpts, send func(*bind.TransactOpts) (*types.Transaction, error)) (*Transaction, error) {
+	if opts == nil || opts.Signer == nil {
+		return nil, errors.New("transaction options must have a signer")
+	}
+	if opts.Nonce != nil {
+		return nil, errors.New("transaction options must not have a nonce; it is allocated by the transaction manager")
+	}
+	acct := m.getAccount(opts.From)
+	acct.lock.Lock()
+	defer acct.lock.Unlock()
+
+	// Load the nonce from the network if it isn't known yet
+	if acct.nextNonce == nil {
+		nonce, err := m.client.PendingNonceAt(ctx, opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("error getting nonce for %s: %w", opts.From.Hex(), err)
+		}
+		acct.nextNonce = &nonce
+	}
+	nonce := *acct.nextNonce
+
+	// Send the transaction
+	optsCopy := *opts
+	optsCopy.Context = ctx
+	optsCopy.Nonce = big.NewInt(0).SetUint64(nonce)
+	tx, err := send(&optsCopy)
+	if err != nil {
+		// The local nonce may be out of sync with transactions sent elsewhere
+		if isNonceError(err) {
+			acct.nextNonce = nil
+		}
+		return nil, err
+	}
+	next := nonce + 1
+	acct.nextNonce = &next
+
+	return &Transaction{
+		From:     opts.From,
+		Nonce:    nonce,
+		Attempts: []*types.Transaction{tx},
+		sentAt:   time.Now(),
+		signer:   opts.Signer,
+	}, nil
+}
+
+// Get the local state of an account
+func (m *Manager) getAccount(address common.Address) *account {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	acct, exists := m.accounts[address]
+	if !exists {
+		acct = &account{}
+		m.accounts[address] = acct
+	}
+	return acct
+}
+
+// Get EIP-1559 fees, suggesting any that weren't provided from the latest block
+func (m *Manager) getFees(ctx context.Context, tipCap *big.Int, feeCap *big.Int) (*big.Int, *big.Int, error) {
+	if tipCap == nil {
+		var err error
+		tipCap, err = m.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting suggested priority fee: %w", err)
+		}
+	}
+	if feeCap == nil {
+		header, err := m.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting latest block header: %w", err)
+		}
+		if header.BaseFee == nil {
+			return nil, nil, errors.New("latest block has no base fee; EIP-1559 is not active")
+		}
+		feeCap = big.NewInt(0).Add(tipCap, big.NewInt(0).Mul(header.BaseFee, big.NewInt(2)))
+	}
+	return tipCap, feeCap, nil
+}
+
+// Check if a submission error was caused by a stale nonce
+func isNonceError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "nonce too low") ||
+		strings.Contains(message, "nonce too high") ||
+		strings.Contains(message, "already known") ||
+		strings.Contains(message, "replacement transaction underpriced")
+}
@@ -0,0 +1,257 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// A confirmed transaction receipt with its decoded Rocket Pool events
+type Receipt struct {
+	*types.Receipt
+
+	// The attempt that was mined
+	Transaction *types.Transaction
+
+	// The number of blocks the receipt was buried under (including its own) when it was returned
+	Confirmations uint64
+
+	// The number of times the transaction was seen mined and then reorged out while waiting
+	Reorgs int
+
+	// Events from logs emitted by known contracts, in log order
+	Events []rocketpool.ContractEvent
+}
+
+// Get the decoded events with the given name
+func (r *Receipt) GetEvents(eventName string) []rocketpool.ContractEvent {
+	events := []rocketpool.ContractEvent{}
+	for _, event := range r.Events {
+		if event.Name == eventName {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Wait for any attempt of a transaction to be mined and confirmed.
+// If the mined attempt reverted, the receipt is returned along with ErrTransactionFailed.
+// Automatic replacements that fail are recorded in the transaction's replacement errors.
+func (m *Manager) Wait(ctx context.Context, tx *Transaction) (*Receipt, error) {
+	reorgs := 0
+	var lastSeen *types.Receipt
+	for {
+		receipt, confirmations, err := m.getMinedReceipt(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Track reorgs of a previously mined attempt
+		if lastSeen != nil && (receipt == nil || receipt.BlockHash != lastSeen.BlockHash) {
+			reorgs++
+		}
+		lastSeen = receipt
+
+		if receipt != nil && confirmations >= m.config.Confirmations {
+			return m.makeReceipt(ctx, tx, receipt, confirmations, reorgs)
+		}
+
+		if receipt == nil {
+			// Check if the nonce was used by a transaction sent elsewhere
+			nonce, err := m.client.NonceAt(ctx, tx.From, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error getting nonce for %s: %w", tx.From.Hex(), err)
+			}
+			if nonce > tx.Nonce {
+				receipt, _, err = m.getMinedReceipt(ctx, tx)
+				if err != nil {
+					return nil, err
+				}
+				if receipt == nil {
+					return nil, fmt.Errorf("%w: nonce %d of %s", ErrNonceConsumed, tx.Nonce, tx.From.Hex())
+				}
+			}
+
+			// Replace the transaction if it has been pending too long.
+			// A failed replacement doesn't stop the existing attempts from being mined, so it's recorded and waiting continues.
+			if receipt == nil && m.config.ReplaceAfter > 0 {
+				tx.lock.Lock()
+				stuck := time.Since(tx.sentAt) >= m.config.ReplaceAfter
+				tx.lock.Unlock()
+				if stuck {
+					latest := tx.Latest()
+					_, err := m.replace(ctx, tx, latest.To(), latest.Value(), latest.Data(), latest.Gas(), nil, nil, m.config.MaxFeeCap)
+					if err != nil {
+						tx.recordReplacementError(fmt.Errorf("error replacing stuck transaction %s: %w", latest.Hash().Hex(), err))
+					}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.config.PollInterval):
+		}
+	}
+}
+
+// Send a transaction on a contract method and wait for it to be confirmed
+func (m *Manager) TransactAndWait(ctx context.Context, contract *rocketpool.Contract, opts *bind.TransactOpts, method string, params ...interface{}) (*Receipt, error) {
+	tx, err := m.Transact(ctx, contract, opts, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Wait(ctx, tx)
+}
+
+// Get the receipt of whichever attempt was mined in the canonical chain, and its confirmation count
+func (m *Manager) getMinedReceipt(ctx context.Context, tx *Transaction) (*types.Receipt, uint64, error) {
+	attempts := tx.GetAttempts()
+	for i := len(attempts) - 1; i >= 0; i-- {
+		receipt, err := m.client.TransactionReceipt(ctx, attempts[i].Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting receipt for %s: %w", attempts[i].Hash().Hex(), err)
+		}
+
+		// Ignore receipts from blocks that have been reorged out
+		header, err := m.client.HeaderByNumber(ctx, receipt.BlockNumber)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting header for block %s: %w", receipt.BlockNumber.String(), err)
+		}
+		if header.Hash() != receipt.BlockHash {
+			continue
+		}
+
+		latestBlock, err := m.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting latest block number: %w", err)
+		}
+		confirmations := uint64(0)
+		if latestBlock >= receipt.BlockNumber.Uint64() {
+			confirmations = latestBlock - receipt.BlockNumber.Uint64() + 1
+		}
+		return receipt, confirmations, nil
+	}
+	return nil, 0, nil
+}
+
+// Create a receipt with decoded events
+func (m *Manager) makeReceipt(ctx context.Context, tx *Transaction, receipt *types.Receipt, confirmations uint64, reorgs int) (*Receipt, error) {
+	var mined *types.Transaction
+	for _, attempt := range tx.GetAttempts() {
+		if attempt.Hash() == receipt.TxHash {
+			mined = attempt
+			break
+		}
+	}
+	events, err := m.decoder.decode(ctx, receipt)
+	if err != nil {
+		return nil, err
+	}
+	result := &Receipt{
+		Receipt:       receipt,
+		Transaction:   mined,
+		Confirmations: confirmations,
+		Reorgs:        reorgs,
+		Events:        events,
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return result, fmt.Errorf("%w: %s", ErrTransactionFailed, receipt.TxHash.Hex())
+	}
+	return result, nil
+}
+
+// Decodes logs emitted by registered contracts, resolving other Rocket Pool contracts through RocketStorage
+type eventDecoder struct {
+	rp        *rocketpool.RocketPool
+	contracts map[common.Address]*rocketpool.Contract
+	lock      sync.Mutex
+}
+
+// Create a new event decoder
+func newEventDecoder(rp *rocketpool.RocketPool) *eventDecoder {
+	return &eventDecoder{
+		rp:        rp,
+		contracts: map[common.Address]*rocketpool.Contract{},
+	}
+}
+
+// Register contracts to decode the events of
+func (d *eventDecoder) register(contracts ...*rocketpool.Contract) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, contract := range contracts {
+		if contract != nil && contract.Address != nil {
+			d.contracts[*contract.Address] = contract
+		}
+	}
+}
+
+// Decode the logs of a receipt that were emitted by known contracts
+func (d *eventDecoder) decode(ctx context.Context, receipt *types.Receipt) ([]rocketpool.ContractEvent, error) {
+	events := []rocketpool.ContractEvent{}
+	for _, log := range receipt.Logs {
+		contract, err := d.getContract(ctx, log.Address, receipt.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if contract == nil || len(log.Topics) == 0 {
+			continue
+		}
+		if _, err := contract.ABI.EventByID(log.Topics[0]); err != nil {
+			continue
+		}
+		event, err := contract.DecodeLog(*log)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding log %d of %s: %w", log.Index, receipt.TxHash.Hex(), err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Get the contract at an address, or nil if it isn't a known Rocket Pool contract
+func (d *eventDecoder) getContract(ctx context.Context, address common.Address, blockNumber *big.Int) (*rocketpool.Contract, error) {
+	d.lock.Lock()
+	contract, exists := d.contracts[address]
+	d.lock.Unlock()
+	if exists || d.rp == nil {
+		return contract, nil
+	}
+
+	// Resolve the contract's name from RocketStorage
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: blockNumber}
+	name, err := d.rp.RocketStorage.GetString(opts, crypto.Keccak256Hash([]byte("contract.name"), address.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("error getting contract name for %s: %w", address.Hex(), err)
+	}
+	if name != "" {
+		contract, err = d.rp.MakeContractContext(ctx, name, address, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Unknown addresses are remembered too so they're only looked up once
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.contracts[address] = contract
+	return contract, nil
+}
@@ -0,0 +1,160 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Returned when a transaction's nonce was used by a transaction the manager didn't send
+var ErrNonceConsumed = errors.New("nonce was consumed by another transaction")
+
+// Returned when a mined transaction reverted
+var ErrTransactionFailed = errors.New("transaction failed with status 0")
+
+// A transaction sent by the manager, along with every replacement broadcast for its nonce
+type Transaction struct {
+	From        common.Address
+	Nonce       uint64
+	Attempts    []*types.Transaction
+	IsCancelled bool
+
+	sentAt            time.Time
+	signer            bind.SignerFn
+	replacementErrors []error
+	lock              sync.Mutex
+}
+
+// Get the hash of the latest attempt
+func (t *Transaction) Hash() common.Hash {
+	return t.Latest().Hash()
+}
+
+// Get the latest attempt
+func (t *Transaction) Latest() *types.Transaction {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.Attempts[len(t.Attempts)-1]
+}
+
+// Get a copy of every attempt, oldest first
+func (t *Transaction) GetAttempts() []*types.Transaction {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	attempts := make([]*types.Transaction, len(t.Attempts))
+	copy(attempts, t.Attempts)
+	return attempts
+}
+
+// Get the errors from automatic replacements that failed while waiting, oldest first
+func (t *Transaction) GetReplacementErrors() []error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	errs := make([]error, len(t.replacementErrors))
+	copy(errs, t.replacementErrors)
+	return errs
+}
+
+// Record a failed automatic replacement, waiting another full period before the next one
+func (t *Transaction) recordReplacementError(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.replacementErrors = append(t.replacementErrors, err)
+	t.sentAt = time.Now()
+}
+
+// Replace a pending transaction with one that pays higher fees.
+// If tipCap or feeCap is nil, the bumped fee or the current network suggestion is used, whichever is higher.
+func (m *Manager) SpeedUp(ctx context.Context, tx *Transaction, tipCap *big.Int, feeCap *big.Int) (*types.Transaction, error) {
+	latest := tx.Latest()
+	return m.replace(ctx, tx, latest.To(), latest.Value(), latest.Data(), latest.Gas(), tipCap, feeCap, nil)
+}
+
+// Cancel a pending transaction by replacing it with an empty transfer to the sender
+func (m *Manager) Cancel(ctx context.Context, tx *Transaction, tipCap *big.Int, feeCap *big.Int) (*types.Transaction, error) {
+	replacement, err := m.replace(ctx, tx, &tx.From, big.NewInt(0), nil, CancelGasLimit, tipCap, feeCap, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx.lock.Lock()
+	tx.IsCancelled = true
+	tx.lock.Unlock()
+	return replacement, nil
+}
+
+// Broadcast a replacement for a transaction with the same nonce and higher fees
+func (m *Manager) replace(ctx context.Context, tx *Transaction, to *common.Address, value *big.Int, data []byte, gasLimit uint64, tipCap *big.Int, feeCap *big.Int, maxFeeCap *big.Int) (*types.Transaction, error) {
+	latest := tx.Latest()
+
+	// Replacements must raise both fees by the minimum bump
+	minTipCap := m.bumpFee(latest.GasTipCap())
+	minFeeCap := m.bumpFee(latest.GasFeeCap())
+	if tipCap != nil && tipCap.Cmp(minTipCap) < 0 {
+		return nil, fmt.Errorf("priority fee %s is below the minimum replacement fee of %s", tipCap.String(), minTipCap.String())
+	}
+	if feeCap != nil && feeCap.Cmp(minFeeCap) < 0 {
+		return nil, fmt.Errorf("max fee %s is below the minimum replacement fee of %s", feeCap.String(), minFeeCap.String())
+	}
+
+	// Use the current network fees if they are higher than the bumped ones
+	if tipCap == nil || feeCap == nil {
+		suggestedTipCap, suggestedFeeCap, err := m.getFees(ctx, tipCap, feeCap)
+		if err != nil {
+			return nil, err
+		}
+		tipCap = maxBig(suggestedTipCap, minTipCap)
+		feeCap = maxBig(suggestedFeeCap, minFeeCap)
+	}
+	if feeCap.Cmp(tipCap) < 0 {
+		feeCap = big.NewInt(0).Set(tipCap)
+	}
+	if maxFeeCap != nil && feeCap.Cmp(maxFeeCap) > 0 {
+		return nil, fmt.Errorf("replacement max fee %s exceeds the limit of %s", feeCap.String(), maxFeeCap.String())
+	}
+
+	// Sign and send
+	replacement, err := tx.signer(tx.From, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   m.chainID,
+		Nonce:     tx.Nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("error signing replacement transaction: %w", err)
+	}
+	if err := m.client.SendTransaction(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("error sending replacement for nonce %d: %w", tx.Nonce, err)
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+	tx.Attempts = append(tx.Attempts, replacement)
+	tx.sentAt = time.Now()
+	return replacement, nil
+}
+
+// Raise a fee by the configured bump percentage, rounding up
+func (m *Manager) bumpFee(fee *big.Int) *big.Int {
+	bumped := big.NewInt(0).Mul(fee, big.NewInt(int64(100+m.config.FeeBumpPercent)))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// Get the larger of two values
+func maxBig(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// The ciphersuite used by Beacon chain signatures (proof of possession scheme)
+const SignatureDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+
+// The order of the BLS12-381 subgroups
+var groupOrder, _ = big.NewInt(0).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+// Get the public key of a BLS secret key
+func GetPubkey(secretKey *big.Int) (types.ValidatorPubkey, error) {
+	sk, err := getSecretKey(secretKey)
+	if err != nil {
+		return types.ValidatorPubkey{}, err
+	}
+	defer sk.Zeroize()
+	return types.BytesToValidatorPubkey(new(blst.P1Affine).From(sk).Compress()), nil
+}
+
+// Sign a message with a BLS secret key
+func Sign(secretKey *big.Int, message []byte) (types.ValidatorSignature, error) {
+	sk, err := getSecretKey(secretKey)
+	if err != nil {
+		return types.ValidatorSignature{}, err
+	}
+	defer sk.Zeroize()
+	signature := new(blst.P2Affine).Sign(sk, message, []byte(SignatureDST))
+	if signature == nil {
+		return types.ValidatorSignature{}, errors.New("error signing message")
+	}
+	return types.BytesToValidatorSignature(signature.Compress()), nil
+}
+
+// Check a BLS signature of a message.
+// Returns an error if the pubkey or signature isn't a valid encoding of a point in the correct subgroup, or if the pubkey is the point at infinity.
+func Verify(pubkey types.ValidatorPubkey, message []byte, signature types.ValidatorSignature) (bool, error) {
+	pubkeyPoint := new(blst.P1Affine).Uncompress(pubkey.Bytes())
+	if pubkeyPoint == nil {
+		return false, fmt.Errorf("invalid pubkey %s: not a valid compressed G1 point", pubkey.Hex())
+	}
+	if !pubkeyPoint.KeyValidate() {
+		return false, fmt.Errorf("invalid pubkey %s: the point at infinity or not in the G1 subgroup", pubkey.Hex())
+	}
+	signaturePoint := new(blst.P2Affine).Uncompress(signature.Bytes())
+	if signaturePoint == nil {
+		return false, errors.New("invalid signature: not a valid compressed G2 point")
+	}
+	if !signaturePoint.SigValidate(false) {
+		return false, errors.New("invalid signature: not in the G2 subgroup")
+	}
+	return signaturePoint.Verify(false, pubkeyPoint, false, message, []byte(SignatureDST)), nil
+}
+
+// Get a blst secret key, checking that it's in range
+func getSecretKey(secretKey *big.Int) (*blst.SecretKey, error) {
+	if secretKey == nil || secretKey.Sign() <= 0 || secretKey.Cmp(groupOrder) >= 0 {
+		return nil, errors.New("secret key is not between 1 and the group order")
+	}
+	sk := new(blst.SecretKey).Deserialize(secretKey.FillBytes(make([]byte, 32)))
+	if sk == nil {
+		return nil, errors.New("invalid secret key")
+	}
+	return sk, nil
+}
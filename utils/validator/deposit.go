@@ -0,0 +1,227 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/go-ssz"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// Deposit settings
+const (
+	PrelaunchDepositAmount uint64 = 1000000000  // gwei, sent by node.Deposit
+	StakeDepositAmount     uint64 = 31000000000 // gwei, sent by minipool.Stake
+)
+
+// The deposit domain type
+var DomainDeposit = [4]byte{0x03, 0x00, 0x00, 0x00}
+
+// Genesis fork versions; deposits are always signed with the genesis fork version
+var (
+	MainnetGenesisForkVersion = [4]byte{0x00, 0x00, 0x00, 0x00}
+	PraterGenesisForkVersion  = [4]byte{0x00, 0x00, 0x10, 0x20}
+	HoleskyGenesisForkVersion = [4]byte{0x01, 0x01, 0x70, 0x00}
+)
+
+// The withdrawal credentials prefix for execution layer addresses
+const Eth1AddressWithdrawalPrefix byte = 0x01
+
+// Validator deposit data
+type DepositData struct {
+	Pubkey                types.ValidatorPubkey    `json:"pubkey"`
+	WithdrawalCredentials common.Hash              `json:"withdrawalCredentials"`
+	Amount                uint64                   `json:"amount"`
+	Signature             types.ValidatorSignature `json:"signature"`
+	DepositDataRoot       common.Hash              `json:"depositDataRoot"`
+}
+
+// SSZ containers
+type depositMessage struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+}
+type depositDataContainer struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+	Signature             []byte `ssz-size:"96"`
+}
+type forkData struct {
+	CurrentVersion        []byte `ssz-size:"4"`
+	GenesisValidatorsRoot []byte `ssz-size:"32"`
+}
+type signingData struct {
+	ObjectRoot []byte `ssz-size:"32"`
+	Domain     []byte `ssz-size:"32"`
+}
+
+// Create signed deposit data for a validator
+func CreateDepositData(secretKey *big.Int, withdrawalCredentials common.Hash, amount uint64, genesisForkVersion [4]byte) (DepositData, error) {
+	pubkey, err := GetPubkey(secretKey)
+	if err != nil {
+		return DepositData{}, err
+	}
+	signingRoot, err := GetDepositSigningRoot(pubkey, withdrawalCredentials, amount, genesisForkVersion)
+	if err != nil {
+		return DepositData{}, err
+	}
+	signature, err := Sign(secretKey, signingRoot[:])
+	if err != nil {
+		return DepositData{}, fmt.Errorf("error signing deposit data: %w", err)
+	}
+	depositDataRoot, err := GetDepositDataRoot(pubkey, withdrawalCredentials, amount, signature)
+	if err != nil {
+		return DepositData{}, err
+	}
+	return DepositData{
+		Pubkey:                pubkey,
+		WithdrawalCredentials: withdrawalCredentials,
+		Amount:                amount,
+		Signature:             signature,
+		DepositDataRoot:       depositDataRoot,
+	}, nil
+}
+
+// Get the deposit data root, as checked by the deposit contract
+func GetDepositDataRoot(pubkey types.ValidatorPubkey, withdrawalCredentials common.Hash, amount uint64, signature types.ValidatorSignature) (common.Hash, error) {
+	root, err := ssz.HashTreeRoot(depositDataContainer{
+		Pubkey:                pubkey.Bytes(),
+		WithdrawalCredentials: withdrawalCredentials[:],
+		Amount:                amount,
+		Signature:             signature.Bytes(),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit data root: %w", err)
+	}
+	return root, nil
+}
+
+// Get the deposit domain for a genesis fork version
+func GetDepositDomain(genesisForkVersion [4]byte) (common.Hash, error) {
+	forkDataRoot, err := ssz.HashTreeRoot(forkData{
+		CurrentVersion:        genesisForkVersion[:],
+		GenesisValidatorsRoot: make([]byte, common.HashLength),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting fork data root: %w", err)
+	}
+	var domain common.Hash
+	copy(domain[:4], DomainDeposit[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain, nil
+}
+
+// Get the root that a deposit's signature signs
+func GetDepositSigningRoot(pubkey types.ValidatorPubkey, withdrawalCredentials common.Hash, amount uint64, genesisForkVersion [4]byte) (common.Hash, error) {
+	messageRoot, err := ssz.HashTreeRoot(depositMessage{
+		Pubkey:                pubkey.Bytes(),
+		WithdrawalCredentials: withdrawalCredentials[:],
+		Amount:                amount,
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit message root: %w", err)
+	}
+	domain, err := GetDepositDomain(genesisForkVersion)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signingRoot, err := ssz.HashTreeRoot(signingData{
+		ObjectRoot: messageRoot[:],
+		Domain:     domain[:],
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit signing root: %w", err)
+	}
+	return signingRoot, nil
+}
+
+// Check a deposit's signature against the deposit domain of a genesis fork version
+func VerifyDepositSignature(pubkey types.ValidatorPubkey, withdrawalCredentials common.Hash, amount uint64, signature types.ValidatorSignature, genesisForkVersion [4]byte) error {
+	signingRoot, err := GetDepositSigningRoot(pubkey, withdrawalCredentials, amount, genesisForkVersion)
+	if err != nil {
+		return err
+	}
+	valid, err := Verify(pubkey, signingRoot[:], signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid deposit signature for validator %s", pubkey.Hex())
+	}
+	return nil
+}
+
+// Check a deposit's signature and deposit data root
+func VerifyDepositData(data DepositData, genesisForkVersion [4]byte) error {
+	if err := VerifyDepositSignature(data.Pubkey, data.WithdrawalCredentials, data.Amount, data.Signature, genesisForkVersion); err != nil {
+		return err
+	}
+	depositDataRoot, err := GetDepositDataRoot(data.Pubkey, data.WithdrawalCredentials, data.Amount, data.Signature)
+	if err != nil {
+		return err
+	}
+	if depositDataRoot != data.DepositDataRoot {
+		return fmt.Errorf("deposit data root %s does not match expected root %s", data.DepositDataRoot.Hex(), depositDataRoot.Hex())
+	}
+	return nil
+}
+
+// Get the withdrawal credentials for an execution layer address
+func GetAddressWithdrawalCredentials(address common.Address) common.Hash {
+	var withdrawalCredentials common.Hash
+	withdrawalCredentials[0] = Eth1AddressWithdrawalPrefix
+	copy(withdrawalCredentials[12:], address[:])
+	return withdrawalCredentials
+}
+
+// Check that withdrawal credentials match a minipool's
+func CheckMinipoolWithdrawalCredentials(rp *rocketpool.RocketPool, minipoolAddress common.Address, withdrawalCredentials common.Hash, opts *bind.CallOpts) error {
+	expected, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, opts)
+	if err != nil {
+		return fmt.Errorf("error getting withdrawal credentials for minipool %s: %w", minipoolAddress.Hex(), err)
+	}
+	if expected != withdrawalCredentials {
+		return fmt.Errorf("withdrawal credentials %s do not match minipool %s credentials %s", withdrawalCredentials.Hex(), minipoolAddress.Hex(), expected.Hex())
+	}
+	return nil
+}
+
+// Validate the deposit data for a node.Deposit call before sending it
+func ValidateNodeDeposit(rp *rocketpool.RocketPool, pubkey types.ValidatorPubkey, signature types.ValidatorSignature, depositDataRoot common.Hash, expectedMinipoolAddress common.Address, genesisForkVersion [4]byte, opts *bind.CallOpts) error {
+	if expectedMinipoolAddress == (common.Address{}) {
+		return errors.New("expected minipool address is not set")
+	}
+	return validateMinipoolDeposit(rp, expectedMinipoolAddress, pubkey, signature, depositDataRoot, PrelaunchDepositAmount, genesisForkVersion, opts)
+}
+
+// Validate the deposit data for a minipool.Stake call before sending it
+func ValidateStake(rp *rocketpool.RocketPool, minipoolAddress common.Address, signature types.ValidatorSignature, depositDataRoot common.Hash, genesisForkVersion [4]byte, opts *bind.CallOpts) error {
+	pubkey, err := minipool.GetMinipoolPubkey(rp, minipoolAddress, opts)
+	if err != nil {
+		return fmt.Errorf("error getting pubkey for minipool %s: %w", minipoolAddress.Hex(), err)
+	}
+	return validateMinipoolDeposit(rp, minipoolAddress, pubkey, signature, depositDataRoot, StakeDepositAmount, genesisForkVersion, opts)
+}
+
+// Validate deposit data against a minipool's withdrawal credentials
+func validateMinipoolDeposit(rp *rocketpool.RocketPool, minipoolAddress common.Address, pubkey types.ValidatorPubkey, signature types.ValidatorSignature, depositDataRoot common.Hash, amount uint64, genesisForkVersion [4]byte, opts *bind.CallOpts) error {
+	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, opts)
+	if err != nil {
+		return fmt.Errorf("error getting withdrawal credentials for minipool %s: %w", minipoolAddress.Hex(), err)
+	}
+	return VerifyDepositData(DepositData{
+		Pubkey:                pubkey,
+		WithdrawalCredentials: withdrawalCredentials,
+		Amount:                amount,
+		Signature:             signature,
+		DepositDataRoot:       depositDataRoot,
+	}, genesisForkVersion)
+}
@@ -0,0 +1,171 @@
+package validator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils"
+)
+
+// Settings
+const PrestakeEventBatchSize = 20
+
+// The reason a prelaunch minipool should be scrubbed
+type FrontrunReason string
+
+const (
+	// The first validly signed deposit for the pubkey had different withdrawal credentials
+	FrontrunReason_WrongWithdrawalCredentials FrontrunReason = "wrong_withdrawal_credentials"
+
+	// The minipool's own deposit has an invalid signature, so the Beacon chain will ignore it
+	FrontrunReason_InvalidSignature FrontrunReason = "invalid_signature"
+)
+
+// The evidence that a prelaunch minipool should be scrubbed
+type FrontrunReport struct {
+	MinipoolAddress common.Address        `json:"minipoolAddress"`
+	Pubkey          types.ValidatorPubkey `json:"pubkey"`
+	Reason          FrontrunReason        `json:"reason"`
+
+	// The minipool's prestake event
+	Prestake minipool.PrestakeData `json:"prestake"`
+
+	// The deposit that determined the reason
+	OffendingDeposit utils.DepositData `json:"offendingDeposit"`
+
+	// The deposits for the pubkey, in the order they were made
+	Deposits []utils.DepositData `json:"deposits"`
+}
+
+// Check all prelaunch minipools for deposits that would prevent them from staking.
+// Deposits are scanned from startBlock, which should be no later than the deposit contract's deployment.
+// Vacant minipools (solo validator migrations) never emit a prestake event because their validators are already on the
+// Beacon chain, so frontrunning doesn't apply to them; they're skipped and their addresses are returned separately.
+func GetFrontrunMinipools(rp *rocketpool.RocketPool, genesisForkVersion [4]byte, startBlock *big.Int, intervalSize *big.Int, opts *bind.CallOpts) ([]FrontrunReport, []common.Address, error) {
+
+	// Get the prelaunch minipools and their prestake events
+	minipoolAddresses, err := minipool.GetPrelaunchMinipoolAddresses(rp, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting prelaunch minipool addresses: %w", err)
+	}
+	prestakes, vacant, err := getPrestakeEvents(rp, minipoolAddresses, intervalSize, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Get the deposits for the minipool pubkeys
+	pubkeys := make(map[types.ValidatorPubkey]bool, len(prestakes))
+	vacantMinipools := []common.Address{}
+	for i, prestake := range prestakes {
+		if vacant[i] {
+			vacantMinipools = append(vacantMinipools, minipoolAddresses[i])
+			continue
+		}
+		pubkeys[prestake.Pubkey] = true
+	}
+	deposits, err := utils.GetDeposits(rp, pubkeys, startBlock, intervalSize, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting deposits for prelaunch minipools: %w", err)
+	}
+
+	// Check each minipool
+	reports := []FrontrunReport{}
+	for i, minipoolAddress := range minipoolAddresses {
+		if vacant[i] {
+			continue
+		}
+		report, found := CheckPrestakeDeposits(minipoolAddress, prestakes[i], deposits[prestakes[i].Pubkey], genesisForkVersion)
+		if found {
+			reports = append(reports, report)
+		}
+	}
+	return reports, vacantMinipools, nil
+
+}
+
+// Compare a minipool's prestake event with all of the deposits for its pubkey, which must be in the order they were made.
+// The Beacon chain ignores initial deposits with invalid signatures, so the first validly signed deposit sets the withdrawal credentials.
+// Returns a report and true if the minipool should be scrubbed.
+func CheckPrestakeDeposits(minipoolAddress common.Address, prestake minipool.PrestakeData, deposits []utils.DepositData, genesisForkVersion [4]byte) (FrontrunReport, bool) {
+	report := FrontrunReport{
+		MinipoolAddress: minipoolAddress,
+		Pubkey:          prestake.Pubkey,
+		Prestake:        prestake,
+		Deposits:        deposits,
+	}
+	for _, deposit := range deposits {
+		err := VerifyDepositSignature(deposit.Pubkey, deposit.WithdrawalCredentials, deposit.Amount, deposit.Signature, genesisForkVersion)
+		isMinipoolDeposit := deposit.WithdrawalCredentials == prestake.WithdrawalCredentials
+		if err != nil {
+			if isMinipoolDeposit {
+				report.Reason = FrontrunReason_InvalidSignature
+				report.OffendingDeposit = deposit
+				return report, true
+			}
+			continue
+		}
+		if !isMinipoolDeposit {
+			report.Reason = FrontrunReason_WrongWithdrawalCredentials
+			report.OffendingDeposit = deposit
+			return report, true
+		}
+		return FrontrunReport{}, false
+	}
+	return FrontrunReport{}, false
+}
+
+// Get the prestake events for a list of minipools, and whether each one is vacant (vacant minipools have no prestake event)
+func getPrestakeEvents(rp *rocketpool.RocketPool, minipoolAddresses []common.Address, intervalSize *big.Int, opts *bind.CallOpts) ([]minipool.PrestakeData, []bool, error) {
+
+	// Load the events in batches
+	prestakes := make([]minipool.PrestakeData, len(minipoolAddresses))
+	vacant := make([]bool, len(minipoolAddresses))
+	for bsi := 0; bsi < len(minipoolAddresses); bsi += PrestakeEventBatchSize {
+
+		// Get batch start & end index
+		msi := bsi
+		mei := bsi + PrestakeEventBatchSize
+		if mei > len(minipoolAddresses) {
+			mei = len(minipoolAddresses)
+		}
+
+		// Load events
+		var wg errgroup.Group
+		for mi := msi; mi < mei; mi++ {
+			mi := mi
+			wg.Go(func() error {
+				mp, err := minipool.NewMinipool(rp, minipoolAddresses[mi], opts)
+				if err != nil {
+					return fmt.Errorf("error creating binding for minipool %s: %w", minipoolAddresses[mi].Hex(), err)
+				}
+				if mpv3, ok := minipool.GetMinipoolAsV3(mp); ok {
+					vacant[mi], err = mpv3.GetVacant(opts)
+					if err != nil {
+						return err
+					}
+					if vacant[mi] {
+						return nil
+					}
+				}
+				prestakes[mi], err = mp.GetPrestakeEvent(intervalSize, opts)
+				if err != nil {
+					return fmt.Errorf("error getting prestake event for minipool %s: %w", minipoolAddresses[mi].Hex(), err)
+				}
+				return nil
+			})
+		}
+		if err := wg.Wait(); err != nil {
+			return nil, nil, err
+		}
+
+	}
+	return prestakes, vacant, nil
+
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -14,6 +15,11 @@ import (
 
 // Wait for a transaction to get mined
 func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*types.Receipt, error) {
+	return WaitForTransactionContext(context.Background(), client, hash)
+}
+
+// Wait for a transaction to get mined, aborting when the context is done
+func WaitForTransactionContext(ctx context.Context, client rocketpool.ExecutionClient, hash common.Hash) (*types.Receipt, error) {
 
 	var tx *types.Transaction
 	var err error
@@ -24,10 +30,14 @@ func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*t
 			return nil, fmt.Errorf("Transaction not found after 30 seconds.")
 		}
 
-		tx, _, err = client.TransactionByHash(context.Background(), hash)
+		tx, _, err = client.TransactionByHash(ctx, hash)
 		if err != nil {
-			if err.Error() == "not found" {
-				time.Sleep(1 * time.Second)
+			if errors.Is(err, ethereum.NotFound) {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(1 * time.Second):
+				}
 				continue
 			}
 			return nil, err
@@ -37,7 +47,7 @@ func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*t
 	}
 
 	// Wait for transaction to be mined
-	txReceipt, err := bind.WaitMined(context.Background(), client, tx)
+	txReceipt, err := bind.WaitMined(ctx, client, tx)
 	if err != nil {
 		return nil, err
 	}